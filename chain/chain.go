@@ -17,9 +17,12 @@ import (
 type InfoChain struct {
 	Conn                *conn.Conn
 	Wallet              *wallet.Wallet
+	FeePayer            conn.Signer // nil when the owner pays the transaction fees
 	ProgramSuperID      solana.PublicKey
 	ProgramSuperMachine solana.PublicKey
 	ProgramSuperOrder   solana.PublicKey
+	MachineUUID         machine_uuid.MachineUUID
+	Offline             config.OfflineConfig
 }
 
 // GetChainInfo returns *Infochain and error when the connection fails
@@ -29,6 +32,14 @@ func GetChainInfo(cfg *config.SolanaConfig, machineUUID machine_uuid.MachineUUID
 		return nil, fmt.Errorf("> conn.NewConn: %v", err)
 	}
 
+	feePayer, err := wallet.NewFeePayer(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("> wallet.NewFeePayer: %v", err)
+	}
+	if feePayer != nil {
+		logs.Normal(fmt.Sprintf("feePayer : %v", feePayer.PublicKey().String()))
+	}
+
 	wallet, err := wallet.InitWallet(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("> wallet.InitWallet: %v", err)
@@ -36,7 +47,7 @@ func GetChainInfo(cfg *config.SolanaConfig, machineUUID machine_uuid.MachineUUID
 
 	programID := solana.MustPublicKeyFromBase58(pattern.PROGRAM_SUPER_ID)
 
-	seedMachine := utils.GenMachine(wallet.Wallet.PublicKey(), machineUUID)
+	seedMachine := utils.GenMachine(wallet.PublicKey(), machineUUID)
 
 	machineAccount, _, err := solana.FindProgramAddress(
 		seedMachine,
@@ -50,8 +61,11 @@ func GetChainInfo(cfg *config.SolanaConfig, machineUUID machine_uuid.MachineUUID
 	chainInfo := &InfoChain{
 		Conn:                newConn,
 		Wallet:              wallet,
+		FeePayer:            feePayer,
 		ProgramSuperID:      programID,
 		ProgramSuperMachine: machineAccount,
+		MachineUUID:         machineUUID,
+		Offline:             cfg.Offline,
 	}
 
 	return chainInfo, nil
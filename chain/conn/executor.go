@@ -0,0 +1,393 @@
+package conn
+
+import (
+	logs "SuperNet-Node/utils/log_utils"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+const (
+	// MaxAttempts bounds how many times a transaction is signed and sent before giving up.
+	MaxAttempts = 5
+	// MaxRetries bounds how many times a single RPC call is retried on a transient error.
+	MaxRetries = 5
+)
+
+// RetryBackoff is the delay before the first retry; it doubles up to MaxRetryBackoff.
+// They are variables so that tests can shorten them.
+var (
+	RetryBackoff    = 500 * time.Millisecond
+	MaxRetryBackoff = 8 * time.Second
+)
+
+// ConfirmPollInterval is how often the signature status is polled while confirming.
+var ConfirmPollInterval = 2 * time.Second
+
+// Signer signs transaction messages on behalf of a public key.
+// solana.PrivateKey is a Signer holding the key locally.
+type Signer interface {
+	PublicKey() solana.PublicKey
+	Sign(message []byte) (solana.Signature, error)
+}
+
+// Tx describes a transaction for Execute.
+type Tx struct {
+	// Name is used in logs only.
+	Name         string
+	Instructions []solana.Instruction
+	Payer        solana.PublicKey
+	// Signers must include the payer and every other account required to sign.
+	Signers []Signer
+}
+
+// TxResult is the outcome of a confirmed transaction.
+type TxResult struct {
+	Signature    solana.Signature
+	Slot         uint64
+	Fee          uint64
+	ComputeUnits uint64
+	Logs         []string
+}
+
+// TxError is returned when a transaction fails in simulation or on chain.
+type TxError struct {
+	// Signature is zero when the transaction failed in simulation.
+	Signature solana.Signature
+	Err       interface{}
+	Logs      []string
+}
+
+func (e *TxError) Error() string {
+	var sb strings.Builder
+	if e.Signature.IsZero() {
+		sb.WriteString(fmt.Sprintf("simulation failed: %v", e.Err))
+	} else {
+		sb.WriteString(fmt.Sprintf("transaction %v failed: %v", e.Signature, e.Err))
+	}
+	if programErr := DecodeProgramError(e.Err, e.Logs); programErr != nil {
+		sb.WriteString(fmt.Sprintf(" (%v)", programErr))
+	}
+	for _, line := range e.Logs {
+		sb.WriteString("\n  ")
+		sb.WriteString(line)
+	}
+	return sb.String()
+}
+
+// errBlockhashExpired means the transaction can no longer land and has to be signed again.
+var errBlockhashExpired = errors.New("blockhash expired")
+
+// Execute signs tx with a recent blockhash, simulates it, sends it and waits until it is confirmed.
+// A failed simulation is returned as a *TxError carrying the program logs. When the blockhash
+// expires before the transaction lands it is signed again with a fresh one, and transient RPC
+// errors are retried with exponential backoff.
+func (conn *Conn) Execute(ctx context.Context, tx Tx) (*TxResult, error) {
+	logs.Normal(fmt.Sprintf("=============== %v Transaction", tx.Name))
+
+	for attempt := 1; attempt <= MaxAttempts; attempt++ {
+		result, err := conn.execute(ctx, tx)
+		if errors.Is(err, errBlockhashExpired) {
+			logs.Warning(fmt.Sprintf("%v: blockhash expired, signing again (attempt %v/%v)", tx.Name, attempt, MaxAttempts))
+			continue
+		}
+		return result, err
+	}
+	return nil, fmt.Errorf("%v: blockhash expired %v times", tx.Name, MaxAttempts)
+}
+
+func (conn *Conn) execute(ctx context.Context, tx Tx) (*TxResult, error) {
+	var latest *rpc.GetLatestBlockhashResult
+	err := retry(ctx, func() (err error) {
+		latest, err = conn.RpcClient.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("> GetLatestBlockhash: %v", err)
+	}
+
+	budget, err := conn.newBudget(ctx, tx.Instructions)
+	if err != nil {
+		return nil, fmt.Errorf("> newBudget: %v", err)
+	}
+
+	transaction, err := tx.sign(latest.Value.Blockhash, budget)
+	if err != nil {
+		return nil, err
+	}
+
+	var sim *rpc.SimulateTransactionResponse
+	err = retry(ctx, func() (err error) {
+		sim, err = conn.RpcClient.SimulateTransactionWithOpts(ctx, transaction, &rpc.SimulateTransactionOpts{
+			Commitment: rpc.CommitmentProcessed,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("> SimulateTransaction: %v", err)
+	}
+	if sim.Value.Err != nil {
+		if fmt.Sprint(sim.Value.Err) == "BlockhashNotFound" {
+			return nil, errBlockhashExpired
+		}
+		return nil, &TxError{Err: sim.Value.Err, Logs: sim.Value.Logs}
+	}
+
+	if budget != nil && budget.derived && sim.Value.UnitsConsumed != nil {
+		budget.setUnitsConsumed(*sim.Value.UnitsConsumed, conn.PriorityFee.MaxFee)
+		transaction, err = tx.sign(latest.Value.Blockhash, budget)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if budget != nil {
+		logs.Normal(fmt.Sprintf("%v compute budget: limit %v, price %v micro-lamports/CU, priority fee %v lamports",
+			tx.Name, budget.limit, budget.price, budget.fee()))
+	}
+
+	return conn.send(ctx, tx.Name, transaction, sim.Value, latest.Value.LastValidBlockHeight)
+}
+
+// send broadcasts a simulated transaction, waits until it is confirmed and reads its fee.
+// lastValidBlockHeight bounds the confirmation wait, see confirm.
+func (conn *Conn) send(
+	ctx context.Context,
+	name string,
+	transaction *solana.Transaction,
+	sim *rpc.SimulateTransactionResult,
+	lastValidBlockHeight uint64) (*TxResult, error) {
+	// The transaction was just simulated, so the preflight check is skipped.
+	var sig solana.Signature
+	err := retry(ctx, func() (err error) {
+		sig, err = conn.RpcClient.SendTransactionWithOpts(ctx, transaction, rpc.TransactionOpts{
+			SkipPreflight: true,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("> SendTransactionWithOpts: %v", err)
+	}
+	logs.Normal(fmt.Sprintf("%v sent : %v", name, sig))
+
+	slot, err := conn.confirm(ctx, sig, lastValidBlockHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TxResult{Signature: sig, Slot: slot, Logs: sim.Logs}
+	if sim.UnitsConsumed != nil {
+		result.ComputeUnits = *sim.UnitsConsumed
+	}
+
+	// Fee and consumed compute units are read from the landed transaction when the node has it.
+	out, err := conn.getTransaction(ctx, sig)
+	if err != nil {
+		logs.Warning(fmt.Sprintf("%v: GetTransaction %v: %v", name, sig, err))
+		return result, nil
+	}
+	if out.Meta != nil {
+		result.Fee = out.Meta.Fee
+		result.Logs = out.Meta.LogMessages
+		if out.Meta.ComputeUnitsConsumed != nil {
+			result.ComputeUnits = *out.Meta.ComputeUnitsConsumed
+		}
+	}
+	return result, nil
+}
+
+// sign builds the transaction on blockhash, prepending the compute budget if any, and signs it.
+func (tx Tx) sign(blockhash solana.Hash, budget *budget) (*solana.Transaction, error) {
+	instructions := tx.Instructions
+	if budget != nil {
+		instructions = append(budget.instructions(), instructions...)
+	}
+
+	transaction, err := solana.NewTransaction(
+		instructions,
+		blockhash,
+		solana.TransactionPayer(tx.Payer),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("> solana.NewTransaction: %v", err)
+	}
+
+	missing, err := SignPartial(transaction, tx.Signers)
+	if err != nil {
+		return nil, err
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("no signer for %v", missing)
+	}
+	return transaction, nil
+}
+
+// SignPartial signs transaction with the signers it has and returns the required signers that
+// are missing. Signatures already present are kept and missing ones are left zero, so the
+// transaction can be passed on to be completed elsewhere.
+func SignPartial(transaction *solana.Transaction, signers []Signer) ([]solana.PublicKey, error) {
+	message, err := transaction.Message.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("> Message.MarshalBinary: %v", err)
+	}
+
+	required := transaction.Message.AccountKeys[:transaction.Message.Header.NumRequiredSignatures]
+	if len(transaction.Signatures) != len(required) {
+		transaction.Signatures = make([]solana.Signature, len(required))
+	}
+
+	var missing []solana.PublicKey
+	for i, key := range required {
+		if !transaction.Signatures[i].IsZero() {
+			continue
+		}
+		signer := findSigner(signers, key)
+		if signer == nil {
+			missing = append(missing, key)
+			continue
+		}
+		signature, err := signer.Sign(message)
+		if err != nil {
+			return nil, fmt.Errorf("> Sign %v: %v", key, err)
+		}
+		transaction.Signatures[i] = signature
+	}
+	return missing, nil
+}
+
+func findSigner(signers []Signer, key solana.PublicKey) Signer {
+	for _, signer := range signers {
+		if signer.PublicKey().Equals(key) {
+			return signer
+		}
+	}
+	return nil
+}
+
+// confirm waits until sig is confirmed and returns its slot. It returns errBlockhashExpired
+// once the chain is past lastValidBlockHeight without the transaction having landed.
+func (conn *Conn) confirm(ctx context.Context, sig solana.Signature, lastValidBlockHeight uint64) (uint64, error) {
+	ticker := time.NewTicker(ConfirmPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-ticker.C:
+		}
+
+		var statuses *rpc.GetSignatureStatusesResult
+		err := retry(ctx, func() (err error) {
+			statuses, err = conn.RpcClient.GetSignatureStatuses(ctx, false, sig)
+			return err
+		})
+		if err != nil {
+			return 0, fmt.Errorf("> GetSignatureStatuses: %v", err)
+		}
+
+		if len(statuses.Value) > 0 && statuses.Value[0] != nil {
+			status := statuses.Value[0]
+			if status.Err != nil {
+				txErr := &TxError{Signature: sig, Err: status.Err}
+				if out, err := conn.getTransaction(ctx, sig); err == nil && out.Meta != nil {
+					txErr.Logs = out.Meta.LogMessages
+				}
+				return 0, txErr
+			}
+			if status.ConfirmationStatus == rpc.ConfirmationStatusConfirmed ||
+				status.ConfirmationStatus == rpc.ConfirmationStatusFinalized {
+				return status.Slot, nil
+			}
+			continue
+		}
+
+		var height uint64
+		err = retry(ctx, func() (err error) {
+			height, err = conn.RpcClient.GetBlockHeight(ctx, rpc.CommitmentConfirmed)
+			return err
+		})
+		if err != nil {
+			return 0, fmt.Errorf("> GetBlockHeight: %v", err)
+		}
+		if height > lastValidBlockHeight {
+			return 0, errBlockhashExpired
+		}
+	}
+}
+
+// getTransaction fetches a confirmed transaction, waiting briefly for the node to index it.
+func (conn *Conn) getTransaction(ctx context.Context, sig solana.Signature) (*rpc.GetTransactionResult, error) {
+	maxVersion := uint64(0)
+	var out *rpc.GetTransactionResult
+	err := retry(ctx, func() (err error) {
+		out, err = conn.RpcClient.GetTransaction(ctx, sig, &rpc.GetTransactionOpts{
+			Commitment:                     rpc.CommitmentConfirmed,
+			MaxSupportedTransactionVersion: &maxVersion,
+		})
+		if errors.Is(err, rpc.ErrNotFound) {
+			return transientError{err}
+		}
+		return err
+	})
+	return out, err
+}
+
+// transientError marks an error as worth retrying.
+type transientError struct{ error }
+
+// retry runs fn until it succeeds, returns a permanent error or MaxRetries is reached,
+// sleeping with exponential backoff between attempts.
+func retry(ctx context.Context, fn func() error) error {
+	backoff := RetryBackoff
+	for i := 0; ; i++ {
+		err := fn()
+		if err == nil || !isTransient(err) || i == MaxRetries {
+			return err
+		}
+		logs.Warning(fmt.Sprintf("transient rpc error, retrying in %v: %v", backoff, err))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, MaxRetryBackoff)
+	}
+}
+
+// isTransient reports whether err is a network or node-side failure that may succeed on retry.
+func isTransient(err error) bool {
+	if errors.As(err, &transientError{}) {
+		return true
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var rpcErr *jsonrpc.RPCError
+	if errors.As(err, &rpcErr) {
+		switch rpcErr.Code {
+		// Node behind, slot skipped or not yet available, and minimum context slot not reached.
+		case -32004, -32005, -32007, -32014, -32016:
+			return true
+		}
+		return false
+	}
+	msg := err.Error()
+	for _, s := range []string{"429", "502", "503", "504", "connection reset", "connection refused", "EOF", "timeout"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package conn
+
+import (
+	logs "SuperNet-Node/utils/log_utils"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// SubmitConfirmTimeout is how long a submitted durable nonce transaction is waited for
+// before it is broadcast again.
+const SubmitConfirmTimeout = 90 * time.Second
+
+// BuildOnNonce builds tx on the current value of a durable nonce account instead of a recent
+// blockhash, so it stays valid until the nonce is advanced, and signs it with the signers it has.
+// It returns the transaction together with the signers still missing.
+func (conn *Conn) BuildOnNonce(ctx context.Context, tx Tx, nonceAccount solana.PublicKey) (*solana.Transaction, []solana.PublicKey, error) {
+	nonce, err := conn.GetNonce(ctx, nonceAccount)
+	if err != nil {
+		return nil, nil, fmt.Errorf("> GetNonce: %v", err)
+	}
+
+	// AdvanceNonceAccount has to be the first instruction of a durable nonce transaction.
+	instructions := append([]solana.Instruction{
+		system.NewAdvanceNonceAccountInstruction(
+			nonceAccount,
+			solana.SysVarRecentBlockHashesPubkey,
+			nonce.AuthorizedPubkey,
+		).Build(),
+	}, tx.Instructions...)
+
+	transaction, err := solana.NewTransaction(
+		instructions,
+		solana.Hash(nonce.Nonce),
+		solana.TransactionPayer(tx.Payer),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("> solana.NewTransaction: %v", err)
+	}
+
+	missing, err := SignPartial(transaction, tx.Signers)
+	if err != nil {
+		return nil, nil, err
+	}
+	return transaction, missing, nil
+}
+
+// GetNonce reads a durable nonce account.
+func (conn *Conn) GetNonce(ctx context.Context, nonceAccount solana.PublicKey) (*system.NonceAccount, error) {
+	var resp *rpc.GetAccountInfoResult
+	err := retry(ctx, func() (err error) {
+		resp, err = conn.RpcClient.GetAccountInfo(ctx, nonceAccount)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("> GetAccountInfo: %w", err)
+	}
+	if !resp.Value.Owner.Equals(solana.SystemProgramID) {
+		return nil, fmt.Errorf("%v is not a nonce account", nonceAccount)
+	}
+
+	var nonce system.NonceAccount
+	if err := nonce.UnmarshalWithDecoder(bin.NewBinDecoder(resp.GetBinary())); err != nil {
+		return nil, fmt.Errorf("> UnmarshalWithDecoder: %v", err)
+	}
+	if nonce.State != 1 {
+		return nil, fmt.Errorf("nonce account %v is not initialized", nonceAccount)
+	}
+	return &nonce, nil
+}
+
+// Submit broadcasts a fully signed transaction built elsewhere, typically on a durable nonce,
+// and waits until it is confirmed. A failed simulation is returned as a *TxError.
+func (conn *Conn) Submit(ctx context.Context, name string, transaction *solana.Transaction) (*TxResult, error) {
+	if err := transaction.VerifySignatures(); err != nil {
+		return nil, fmt.Errorf("> VerifySignatures: %v", err)
+	}
+
+	var sim *rpc.SimulateTransactionResponse
+	err := retry(ctx, func() (err error) {
+		sim, err = conn.RpcClient.SimulateTransactionWithOpts(ctx, transaction, &rpc.SimulateTransactionOpts{
+			SigVerify:  true,
+			Commitment: rpc.CommitmentProcessed,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("> SimulateTransaction: %v", err)
+	}
+	if sim.Value.Err != nil {
+		return nil, &TxError{Err: sim.Value.Err, Logs: sim.Value.Logs}
+	}
+
+	// A durable nonce transaction does not expire, so it is broadcast again until it lands;
+	// resending the same signed transaction cannot make it land twice.
+	for attempt := 1; attempt <= MaxAttempts; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, SubmitConfirmTimeout)
+		result, err := conn.send(attemptCtx, name, transaction, sim.Value, math.MaxUint64)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil {
+			return result, err
+		}
+		logs.Warning(fmt.Sprintf("%v not confirmed yet, broadcasting again (attempt %v/%v)", name, attempt, MaxAttempts))
+	}
+	return nil, fmt.Errorf("%v not confirmed after %v attempts", name, MaxAttempts)
+}
@@ -0,0 +1,354 @@
+package conn
+
+import (
+	logs "SuperNet-Node/utils/log_utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+const (
+	// HealthCheckInterval is how often every endpoint is health-checked.
+	HealthCheckInterval = 30 * time.Second
+	// HealthCheckTimeout bounds a single health check.
+	HealthCheckTimeout = 10 * time.Second
+	// MaxSlotLag is how many slots an endpoint may trail the most advanced one and still be healthy.
+	MaxSlotLag = 150
+	// slotLagPenalty is the latency an endpoint is penalised with for every slot it trails.
+	slotLagPenalty = 10 * time.Millisecond
+	// failurePenalty is the latency an endpoint is penalised with for every consecutive failure.
+	failurePenalty = time.Second
+	// latencyWeight is the weight of the newest sample in the latency moving average.
+	latencyWeight = 0.3
+)
+
+// Endpoint is one RPC provider of a Pool along with its health.
+type Endpoint struct {
+	URL    string
+	client *rpc.Client
+
+	mu                  sync.Mutex
+	healthy             bool
+	slot                uint64
+	slotLag             uint64
+	latency             time.Duration
+	consecutiveFailures int
+	lastError           string
+	calls               uint64
+	failures            uint64
+	served              map[string]uint64
+}
+
+// EndpointMetrics is a snapshot of the health and usage of an Endpoint.
+// The URL of a provider often holds an API key, so only its host is reported.
+type EndpointMetrics struct {
+	URL                 string            `json:"URL"`
+	Healthy             bool              `json:"Healthy"`
+	Slot                uint64            `json:"Slot"`
+	SlotLag             uint64            `json:"SlotLag"`
+	LatencyMs           int64             `json:"LatencyMs"`
+	ConsecutiveFailures int               `json:"ConsecutiveFailures"`
+	LastError           string            `json:"LastError"`
+	Calls               uint64            `json:"Calls"`
+	Failures            uint64            `json:"Failures"`
+	Served              map[string]uint64 `json:"Served"`
+}
+
+// Pool is a JSON-RPC client spreading calls over several Solana RPC endpoints.
+// Reads go to the healthiest endpoint and fail over to the next one on transient errors;
+// transactions are sent to the Broadcast healthiest endpoints at once.
+type Pool struct {
+	endpoints []*Endpoint
+	broadcast int
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+var _ rpc.JSONRPCClient = (*Pool)(nil)
+
+// NewPool returns a Pool over urls, in order of preference, and starts health-checking them.
+// broadcast is how many endpoints each transaction is sent to.
+func NewPool(urls []string, broadcast int) *Pool {
+	pool := &Pool{
+		broadcast: max(broadcast, 1),
+		stop:      make(chan struct{}),
+	}
+	for _, url := range urls {
+		pool.endpoints = append(pool.endpoints, &Endpoint{
+			URL:     url,
+			client:  rpc.New(url),
+			healthy: true,
+			served:  make(map[string]uint64),
+		})
+	}
+
+	if len(pool.endpoints) > 1 {
+		go pool.healthLoop()
+	}
+	return pool
+}
+
+// Close stops the health checks.
+func (pool *Pool) Close() {
+	pool.stopOnce.Do(func() { close(pool.stop) })
+}
+
+// Metrics returns the health and usage of every endpoint, healthiest first.
+func (pool *Pool) Metrics() []EndpointMetrics {
+	metrics := make([]EndpointMetrics, 0, len(pool.endpoints))
+	for _, endpoint := range pool.ranked() {
+		endpoint.mu.Lock()
+		served := make(map[string]uint64, len(endpoint.served))
+		for method, n := range endpoint.served {
+			served[method] = n
+		}
+		host := redactURL(endpoint.URL)
+		metrics = append(metrics, EndpointMetrics{
+			URL:                 host,
+			Healthy:             endpoint.healthy,
+			Slot:                endpoint.slot,
+			SlotLag:             endpoint.slotLag,
+			LatencyMs:           endpoint.latency.Milliseconds(),
+			ConsecutiveFailures: endpoint.consecutiveFailures,
+			LastError:           strings.ReplaceAll(endpoint.lastError, endpoint.URL, host),
+			Calls:               endpoint.calls,
+			Failures:            endpoint.failures,
+			Served:              served,
+		})
+		endpoint.mu.Unlock()
+	}
+	return metrics
+}
+
+// redactURL returns the host of the endpoint URL raw, leaving out the path and query holding API keys.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "invalid URL"
+	}
+	return u.Host
+}
+
+// CallForInto implements rpc.JSONRPCClient.
+func (pool *Pool) CallForInto(ctx context.Context, out interface{}, method string, params []interface{}) error {
+	if method == "sendTransaction" && pool.broadcast > 1 && len(pool.endpoints) > 1 {
+		return pool.broadcastCall(ctx, out, method, params)
+	}
+	return pool.call(ctx, method, func(client *rpc.Client) error {
+		return client.RPCCallForInto(ctx, out, method, params)
+	})
+}
+
+// CallWithCallback implements rpc.JSONRPCClient.
+func (pool *Pool) CallWithCallback(
+	ctx context.Context,
+	method string,
+	params []interface{},
+	callback func(*http.Request, *http.Response) error,
+) error {
+	return pool.call(ctx, method, func(client *rpc.Client) error {
+		return client.RPCCallWithCallback(ctx, method, params, callback)
+	})
+}
+
+// CallBatch implements rpc.JSONRPCClient.
+func (pool *Pool) CallBatch(ctx context.Context, requests jsonrpc.RPCRequests) (out jsonrpc.RPCResponses, err error) {
+	err = pool.call(ctx, "batch", func(client *rpc.Client) (err error) {
+		out, err = client.RPCCallBatch(ctx, requests)
+		return err
+	})
+	return out, err
+}
+
+// call runs fn against the healthiest endpoint, failing over to the next one on transient errors.
+func (pool *Pool) call(ctx context.Context, method string, fn func(client *rpc.Client) error) error {
+	var err error
+	for _, endpoint := range pool.ranked() {
+		start := time.Now()
+		err = fn(endpoint.client)
+		endpoint.record(method, time.Since(start), err)
+		if err == nil || !isTransient(err) || ctx.Err() != nil {
+			return err
+		}
+		logs.Warning(fmt.Sprintf("rpc %v failed on %v, failing over: %v", method, endpoint.URL, err))
+	}
+	return err
+}
+
+// broadcastCall sends the same call to the healthiest endpoints at once and decodes the first success into out.
+func (pool *Pool) broadcastCall(ctx context.Context, out interface{}, method string, params []interface{}) error {
+	endpoints := pool.ranked()
+	if len(endpoints) > pool.broadcast {
+		endpoints = endpoints[:pool.broadcast]
+	}
+
+	type response struct {
+		result json.RawMessage
+		err    error
+	}
+	responses := make(chan response, len(endpoints))
+	for _, endpoint := range endpoints {
+		go func(endpoint *Endpoint) {
+			var result json.RawMessage
+			start := time.Now()
+			err := endpoint.client.RPCCallForInto(ctx, &result, method, params)
+			endpoint.record(method, time.Since(start), err)
+			responses <- response{result, err}
+		}(endpoint)
+	}
+
+	var errs []error
+	for range endpoints {
+		resp := <-responses
+		if resp.err == nil {
+			return json.Unmarshal(resp.result, out)
+		}
+		errs = append(errs, resp.err)
+	}
+	return errors.Join(errs...)
+}
+
+// ranked returns the endpoints ordered from the healthiest to the least healthy.
+func (pool *Pool) ranked() []*Endpoint {
+	type scored struct {
+		endpoint *Endpoint
+		healthy  bool
+		score    time.Duration
+	}
+	list := make([]scored, 0, len(pool.endpoints))
+	for _, endpoint := range pool.endpoints {
+		endpoint.mu.Lock()
+		list = append(list, scored{
+			endpoint: endpoint,
+			healthy:  endpoint.healthy,
+			score: endpoint.latency +
+				time.Duration(endpoint.slotLag)*slotLagPenalty +
+				time.Duration(endpoint.consecutiveFailures)*failurePenalty,
+		})
+		endpoint.mu.Unlock()
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].healthy != list[j].healthy {
+			return list[i].healthy
+		}
+		return list[i].score < list[j].score
+	})
+
+	ranked := make([]*Endpoint, 0, len(list))
+	for _, s := range list {
+		ranked = append(ranked, s.endpoint)
+	}
+	return ranked
+}
+
+// record accounts a call served by the endpoint.
+func (endpoint *Endpoint) record(method string, latency time.Duration, err error) {
+	endpoint.mu.Lock()
+	defer endpoint.mu.Unlock()
+
+	endpoint.calls++
+	endpoint.served[method]++
+	if err != nil && isTransient(err) {
+		endpoint.failures++
+		endpoint.consecutiveFailures++
+		endpoint.lastError = err.Error()
+		return
+	}
+	endpoint.consecutiveFailures = 0
+	endpoint.observeLatency(latency)
+}
+
+func (endpoint *Endpoint) observeLatency(latency time.Duration) {
+	if endpoint.latency == 0 {
+		endpoint.latency = latency
+		return
+	}
+	endpoint.latency = time.Duration(latencyWeight*float64(latency) + (1-latencyWeight)*float64(endpoint.latency))
+}
+
+// healthLoop checks every endpoint each HealthCheckInterval until the pool is closed.
+func (pool *Pool) healthLoop() {
+	ticker := time.NewTicker(HealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		pool.checkHealth()
+		select {
+		case <-pool.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkHealth probes every endpoint with getHealth and getSlot, then derives the slot lag
+// of each endpoint from the most advanced one.
+func (pool *Pool) checkHealth() {
+	var wg sync.WaitGroup
+	for _, endpoint := range pool.endpoints {
+		wg.Add(1)
+		go func(endpoint *Endpoint) {
+			defer wg.Done()
+			endpoint.checkHealth()
+		}(endpoint)
+	}
+	wg.Wait()
+
+	var maxSlot uint64
+	for _, endpoint := range pool.endpoints {
+		endpoint.mu.Lock()
+		maxSlot = max(maxSlot, endpoint.slot)
+		endpoint.mu.Unlock()
+	}
+	for _, endpoint := range pool.endpoints {
+		endpoint.mu.Lock()
+		if endpoint.slot > 0 {
+			endpoint.slotLag = maxSlot - endpoint.slot
+			if endpoint.slotLag > MaxSlotLag {
+				endpoint.healthy = false
+				endpoint.lastError = fmt.Sprintf("%v slots behind", endpoint.slotLag)
+			}
+		}
+		if !endpoint.healthy {
+			logs.Warning(fmt.Sprintf("rpc endpoint %v unhealthy: %v", endpoint.URL, endpoint.lastError))
+		}
+		endpoint.mu.Unlock()
+	}
+}
+
+func (endpoint *Endpoint) checkHealth() {
+	ctx, cancel := context.WithTimeout(context.Background(), HealthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	health, err := endpoint.client.GetHealth(ctx)
+	latency := time.Since(start)
+	if err == nil && health != rpc.HealthOk {
+		err = fmt.Errorf("getHealth: %v", health)
+	}
+	var slot uint64
+	if err == nil {
+		slot, err = endpoint.client.GetSlot(ctx, rpc.CommitmentProcessed)
+	}
+
+	endpoint.mu.Lock()
+	defer endpoint.mu.Unlock()
+	if err != nil {
+		endpoint.healthy = false
+		endpoint.lastError = err.Error()
+		return
+	}
+	endpoint.healthy = true
+	endpoint.slot = slot
+	endpoint.observeLatency(latency)
+}
@@ -0,0 +1,125 @@
+package conn
+
+import (
+	logs "SuperNet-Node/utils/log_utils"
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// computeUnitMargin is added on top of the simulated compute units, in percent.
+const computeUnitMargin = 20
+
+// budget is the compute budget of one transaction.
+type budget struct {
+	limit uint32
+	price uint64
+	// derived is set when the limit still has to be derived from the simulation.
+	derived bool
+}
+
+// newBudget returns the compute budget of a transaction made of instructions,
+// or nil when priority fees are disabled.
+func (conn *Conn) newBudget(ctx context.Context, instructions []solana.Instruction) (*budget, error) {
+	cfg := conn.PriorityFee
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	b := &budget{limit: cfg.ComputeUnitLimit, price: cfg.ComputeUnitPrice}
+	if b.limit == 0 {
+		// Simulate with the maximum limit, then shrink it to what the transaction consumed.
+		b.limit = computebudget.MAX_COMPUTE_UNIT_LIMIT
+		b.derived = true
+	}
+
+	if b.price == 0 {
+		price, err := conn.recentPriorityFee(ctx, writableAccounts(instructions), cfg.Percentile)
+		if err != nil {
+			return nil, fmt.Errorf("> recentPriorityFee: %v", err)
+		}
+		b.price = price
+	}
+
+	b.capPrice(cfg.MaxFee)
+	return b, nil
+}
+
+// setUnitsConsumed derives the limit from the compute units consumed in simulation.
+func (b *budget) setUnitsConsumed(units uint64, maxFee uint64) {
+	limit := units + units*computeUnitMargin/100
+	if limit > computebudget.MAX_COMPUTE_UNIT_LIMIT {
+		limit = computebudget.MAX_COMPUTE_UNIT_LIMIT
+	}
+	b.limit = uint32(limit)
+	b.derived = false
+	b.capPrice(maxFee)
+}
+
+// capPrice lowers the price so that the priority fee stays within maxFee lamports.
+func (b *budget) capPrice(maxFee uint64) {
+	if maxFee == 0 || b.limit == 0 {
+		return
+	}
+	if b.fee() > maxFee {
+		capped := maxFee * 1_000_000 / uint64(b.limit)
+		logs.Warning(fmt.Sprintf("priority fee capped: price %v -> %v micro-lamports/CU", b.price, capped))
+		b.price = capped
+	}
+}
+
+// fee returns the priority fee in lamports.
+func (b *budget) fee() uint64 {
+	return b.price * uint64(b.limit) / 1_000_000
+}
+
+// instructions returns the ComputeBudget instructions to prepend to the transaction.
+func (b *budget) instructions() []solana.Instruction {
+	return []solana.Instruction{
+		computebudget.NewSetComputeUnitLimitInstruction(b.limit).Build(),
+		computebudget.NewSetComputeUnitPriceInstruction(b.price).Build(),
+	}
+}
+
+// recentPriorityFee returns the given percentile of the prioritization fees recently paid
+// by transactions that locked any of the accounts.
+func (conn *Conn) recentPriorityFee(ctx context.Context, accounts solana.PublicKeySlice, percentile int) (uint64, error) {
+	var out []rpc.PriorizationFeeResult
+	err := retry(ctx, func() (err error) {
+		out, err = conn.RpcClient.GetRecentPrioritizationFees(ctx, accounts)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(out) == 0 {
+		return 0, nil
+	}
+
+	fees := make([]uint64, 0, len(out))
+	for _, fee := range out {
+		fees = append(fees, fee.PrioritizationFee)
+	}
+	sort.Slice(fees, func(i, j int) bool { return fees[i] < fees[j] })
+
+	index := (len(fees) - 1) * percentile / 100
+	return fees[index], nil
+}
+
+// writableAccounts returns the accounts written by the instructions, which are the ones
+// whose write locks the transaction competes for.
+func writableAccounts(instructions []solana.Instruction) solana.PublicKeySlice {
+	var accounts solana.PublicKeySlice
+	for _, instruction := range instructions {
+		for _, account := range instruction.Accounts() {
+			if account.IsWritable {
+				accounts.UniqueAppend(account.PublicKey)
+			}
+		}
+	}
+	return accounts
+}
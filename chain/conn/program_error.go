@@ -0,0 +1,163 @@
+package conn
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ProgramError is a custom error returned by an Anchor program.
+type ProgramError struct {
+	// Instruction is the index of the failed instruction, -1 when unknown.
+	Instruction int
+	Code        uint32
+	Name        string
+	Message     string
+}
+
+func (e *ProgramError) String() string {
+	var sb strings.Builder
+	if e.Instruction >= 0 {
+		sb.WriteString(fmt.Sprintf("instruction %v: ", e.Instruction))
+	}
+	if e.Name != "" {
+		sb.WriteString(fmt.Sprintf("%v (%v)", e.Name, e.Code))
+	} else {
+		sb.WriteString(fmt.Sprintf("custom program error %v", e.Code))
+	}
+	if e.Message != "" {
+		sb.WriteString(": " + e.Message)
+	}
+	return sb.String()
+}
+
+// anchorLogPattern matches the error Anchor logs before failing, e.g.
+// "Program log: AnchorError occurred. Error Code: ConstraintSeeds. Error Number: 2006. Error Message: A seeds constraint was violated."
+var anchorLogPattern = regexp.MustCompile(`AnchorError.*Error Code: (\w+)\. Error Number: (\d+)\. Error Message: (.*?)\.?$`)
+
+// customLogPattern matches the runtime log of a failed instruction, e.g. "Program ... failed: custom program error: 0x1771".
+var customLogPattern = regexp.MustCompile(`custom program error: 0x([0-9a-fA-F]+)`)
+
+// DecodeProgramError extracts the custom program error of a failed transaction from its error
+// and logs. It returns nil when the transaction did not fail with a custom error.
+func DecodeProgramError(txErr interface{}, logs []string) *ProgramError {
+	programErr := &ProgramError{Instruction: -1}
+	found := false
+
+	if index, code, ok := instructionCustomError(txErr); ok {
+		programErr.Instruction, programErr.Code, found = index, code, true
+	}
+
+	// The program logs carry the name and message of errors declared by the program itself.
+	for _, line := range logs {
+		if m := anchorLogPattern.FindStringSubmatch(line); m != nil {
+			code, err := strconv.ParseUint(m[2], 10, 32)
+			if err != nil {
+				continue
+			}
+			programErr.Code, programErr.Name, programErr.Message = uint32(code), m[1], m[3]
+			return programErr
+		}
+		if m := customLogPattern.FindStringSubmatch(line); m != nil && !found {
+			code, err := strconv.ParseUint(m[1], 16, 32)
+			if err != nil {
+				continue
+			}
+			programErr.Code, found = uint32(code), true
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	if known, ok := anchorErrors[programErr.Code]; ok {
+		programErr.Name, programErr.Message = known[0], known[1]
+	}
+	return programErr
+}
+
+// instructionCustomError reads {"InstructionError":[index,{"Custom":code}]} from a transaction error.
+func instructionCustomError(txErr interface{}) (int, uint32, bool) {
+	if txErr == nil {
+		return 0, 0, false
+	}
+	data, err := json.Marshal(txErr)
+	if err != nil {
+		return 0, 0, false
+	}
+	var parsed struct {
+		InstructionError []json.RawMessage
+	}
+	if err := json.Unmarshal(data, &parsed); err != nil || len(parsed.InstructionError) != 2 {
+		return 0, 0, false
+	}
+	var index int
+	var custom struct {
+		Custom *uint32
+	}
+	if json.Unmarshal(parsed.InstructionError[0], &index) != nil ||
+		json.Unmarshal(parsed.InstructionError[1], &custom) != nil || custom.Custom == nil {
+		return 0, 0, false
+	}
+	return index, *custom.Custom, true
+}
+
+// anchorErrors are the errors of the Anchor framework, by code. Errors declared by the
+// program start at 6000 and are only known from its logs.
+var anchorErrors = map[uint32][2]string{
+	100:  {"InstructionMissing", "8 byte instruction identifier not provided"},
+	101:  {"InstructionFallbackNotFound", "Fallback functions are not supported"},
+	102:  {"InstructionDidNotDeserialize", "The program could not deserialize the given instruction"},
+	103:  {"InstructionDidNotSerialize", "The program could not serialize the given instruction"},
+	1000: {"IdlInstructionStub", "The program was compiled without idl instructions"},
+	1001: {"IdlInstructionInvalidProgram", "Invalid program given to the IDL instruction"},
+	2000: {"ConstraintMut", "A mut constraint was violated"},
+	2001: {"ConstraintHasOne", "A has one constraint was violated"},
+	2002: {"ConstraintSigner", "A signer constraint was violated"},
+	2003: {"ConstraintRaw", "A raw constraint was violated"},
+	2004: {"ConstraintOwner", "An owner constraint was violated"},
+	2005: {"ConstraintRentExempt", "A rent exemption constraint was violated"},
+	2006: {"ConstraintSeeds", "A seeds constraint was violated"},
+	2007: {"ConstraintExecutable", "An executable constraint was violated"},
+	2009: {"ConstraintAssociated", "An associated constraint was violated"},
+	2010: {"ConstraintAssociatedInit", "An associated init constraint was violated"},
+	2011: {"ConstraintClose", "A close constraint was violated"},
+	2012: {"ConstraintAddress", "An address constraint was violated"},
+	2013: {"ConstraintZero", "Expected zero account discriminant"},
+	2014: {"ConstraintTokenMint", "A token mint constraint was violated"},
+	2015: {"ConstraintTokenOwner", "A token owner constraint was violated"},
+	2016: {"ConstraintMintMintAuthority", "A mint mint authority constraint was violated"},
+	2017: {"ConstraintMintFreezeAuthority", "A mint freeze authority constraint was violated"},
+	2018: {"ConstraintMintDecimals", "A mint decimals constraint was violated"},
+	2019: {"ConstraintSpace", "A space constraint was violated"},
+	2020: {"ConstraintAccountIsNone", "A required account for the constraint is None"},
+	2500: {"RequireViolated", "A require expression was violated"},
+	2501: {"RequireEqViolated", "A require_eq expression was violated"},
+	2502: {"RequireKeysEqViolated", "A require_keys_eq expression was violated"},
+	2503: {"RequireNeqViolated", "A require_neq expression was violated"},
+	2504: {"RequireKeysNeqViolated", "A require_keys_neq expression was violated"},
+	2505: {"RequireGtViolated", "A require_gt expression was violated"},
+	2506: {"RequireGteViolated", "A require_gte expression was violated"},
+	3000: {"AccountDiscriminatorAlreadySet", "The account discriminator was already set on this account"},
+	3001: {"AccountDiscriminatorNotFound", "No 8 byte discriminator was found on the account"},
+	3002: {"AccountDiscriminatorMismatch", "8 byte discriminator did not match what was expected"},
+	3003: {"AccountDidNotDeserialize", "Failed to deserialize the account"},
+	3004: {"AccountDidNotSerialize", "Failed to serialize the account"},
+	3005: {"AccountNotEnoughKeys", "Not enough account keys given to the instruction"},
+	3006: {"AccountNotMutable", "The given account is not mutable"},
+	3007: {"AccountOwnedByWrongProgram", "The given account is owned by a different program than expected"},
+	3008: {"InvalidProgramId", "Program ID was not as expected"},
+	3009: {"InvalidProgramExecutable", "Program account is not executable"},
+	3010: {"AccountNotSigner", "The given account did not sign"},
+	3011: {"AccountNotSystemOwned", "The given account is not owned by the system program"},
+	3012: {"AccountNotInitialized", "The program expected this account to be already initialized"},
+	3013: {"AccountNotProgramData", "The given account is not a program data account"},
+	3014: {"AccountNotAssociatedTokenAccount", "The given account is not the associated token account"},
+	3015: {"AccountSysvarMismatch", "The given public key does not match the required sysvar"},
+	3016: {"AccountReallocExceedsLimit", "The account reallocation exceeds the MAX_PERMITTED_DATA_INCREASE limit"},
+	3017: {"AccountDuplicateReallocs", "The account was duplicated for more than one reallocation"},
+	4100: {"DeclaredProgramIdMismatch", "The declared program id does not match the actual program id"},
+	5000: {"Deprecated", "The API being used is deprecated and should no longer be used"},
+}
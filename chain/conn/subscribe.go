@@ -0,0 +1,269 @@
+package conn
+
+import (
+	logs "SuperNet-Node/utils/log_utils"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+)
+
+// PollInterval is how often an account is polled while its WebSocket subscription is down.
+// It is a variable so that `node simulate` can shorten it.
+var PollInterval = 1 * time.Minute
+
+// WatchAccount streams the raw data of an account into the returned channel.
+// Updates are pushed by an accountSubscribe WebSocket subscription; while the socket
+// is down the account is polled every pollInterval and the subscription is retried.
+// Only the latest state is kept, so a slow reader never blocks the watcher.
+// The channel is closed when ctx is cancelled.
+func (conn *Conn) WatchAccount(ctx context.Context, account solana.PublicKey, pollInterval time.Duration) <-chan []byte {
+	out := make(chan []byte, 1)
+
+	go func() {
+		defer close(out)
+		for {
+			err := conn.subscribeAccount(ctx, account, out)
+			if ctx.Err() != nil {
+				return
+			}
+			logs.Warning(fmt.Sprintf("accountSubscribe %v dropped, falling back to polling: %v", account, err))
+
+			// Catch up on changes missed while the socket was down, then retry the subscription.
+			if data, err := conn.getAccountData(ctx, account); err == nil {
+				sendLatest(out, data)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(pollInterval):
+			}
+		}
+	}()
+
+	return out
+}
+
+// subscribeAccount forwards account notifications into out until the socket drops or ctx is cancelled.
+func (conn *Conn) subscribeAccount(ctx context.Context, account solana.PublicKey, out chan []byte) error {
+	wsClient, err := ws.Connect(ctx, conn.WsEndpoint)
+	if err != nil {
+		return fmt.Errorf("> ws.Connect: %v", err)
+	}
+
+	// Closing the client unblocks Recv, both on cancellation and on return.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		wsClient.Close()
+	}()
+
+	sub, err := wsClient.AccountSubscribe(account, rpc.CommitmentConfirmed)
+	if err != nil {
+		return fmt.Errorf("> AccountSubscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	// The subscription only reports changes, so publish the current state first.
+	if data, err := conn.getAccountData(ctx, account); err == nil {
+		sendLatest(out, data)
+	}
+
+	for {
+		res, err := sub.Recv()
+		if err != nil {
+			return fmt.Errorf("> sub.Recv: %v", err)
+		}
+		if res.Value.Account.Data == nil {
+			continue
+		}
+		sendLatest(out, res.Value.Account.Data.GetBinary())
+	}
+}
+
+// getAccountData fetches the raw data of an account over HTTP RPC.
+func (conn *Conn) getAccountData(ctx context.Context, account solana.PublicKey) ([]byte, error) {
+	resp, err := conn.RpcClient.GetAccountInfoWithOpts(ctx, account, &rpc.GetAccountInfoOpts{
+		Commitment: rpc.CommitmentConfirmed,
+	})
+	if err != nil {
+		if !errors.Is(err, rpc.ErrNotFound) {
+			logs.Warning(fmt.Sprintf("GetAccountInfo %v: %v", account, err))
+		}
+		return nil, err
+	}
+	return resp.GetBinary(), nil
+}
+
+// sendLatest replaces any unread value in out with data.
+func sendLatest(out chan []byte, data []byte) {
+	select {
+	case out <- data:
+	default:
+		select {
+		case <-out:
+		default:
+		}
+		out <- data
+	}
+}
+
+// LogEntry is the log output of a transaction mentioning a watched account.
+type LogEntry struct {
+	Signature solana.Signature
+	Slot      uint64
+	// Time is the block time of a transaction caught up over HTTP RPC, or when the notification
+	// was received, logsSubscribe does not report the block time.
+	Time time.Time
+	// Err is the error of a failed transaction, nil when it succeeded.
+	Err  interface{}
+	Logs []string
+}
+
+// WatchLogs streams the logs of the transactions mentioning account into the returned channel.
+// Entries are pushed by a logsSubscribe WebSocket subscription. Transactions since the signature
+// since, and those missed while the socket is down, are caught up over HTTP RPC every pollInterval;
+// with a zero since only new transactions are streamed. Unlike WatchAccount no entry is dropped, so the
+// reader must keep up. The channel is closed when ctx is cancelled.
+func (conn *Conn) WatchLogs(ctx context.Context, account solana.PublicKey, since solana.Signature, pollInterval time.Duration) <-chan LogEntry {
+	out := make(chan LogEntry, 64)
+
+	go func() {
+		defer close(out)
+		last := since
+		for {
+			err := conn.subscribeLogs(ctx, account, &last, out)
+			if ctx.Err() != nil {
+				return
+			}
+			logs.Warning(fmt.Sprintf("logsSubscribe %v dropped, falling back to polling: %v", account, err))
+
+			if err := conn.catchUpLogs(ctx, account, &last, out); err != nil && ctx.Err() == nil {
+				logs.Warning(fmt.Sprintf("Catch up logs of %v: %v", account, err))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(pollInterval):
+			}
+		}
+	}()
+
+	return out
+}
+
+// subscribeLogs forwards log notifications into out until the socket drops or ctx is cancelled.
+func (conn *Conn) subscribeLogs(ctx context.Context, account solana.PublicKey, last *solana.Signature, out chan LogEntry) error {
+	wsClient, err := ws.Connect(ctx, conn.WsEndpoint)
+	if err != nil {
+		return fmt.Errorf("> ws.Connect: %v", err)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		wsClient.Close()
+	}()
+
+	sub, err := wsClient.LogsSubscribeMentions(account, rpc.CommitmentConfirmed)
+	if err != nil {
+		return fmt.Errorf("> LogsSubscribeMentions: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	// Subscribed first, so that nothing falls between the catch up and the notifications.
+	// A transaction may then be reported twice.
+	if err := conn.catchUpLogs(ctx, account, last, out); err != nil {
+		logs.Warning(fmt.Sprintf("Catch up logs of %v: %v", account, err))
+	}
+
+	for {
+		res, err := sub.Recv()
+		if err != nil {
+			return fmt.Errorf("> sub.Recv: %v", err)
+		}
+		entry := LogEntry{
+			Signature: res.Value.Signature,
+			Slot:      res.Context.Slot,
+			Time:      time.Now(),
+			Err:       res.Value.Err,
+			Logs:      res.Value.Logs,
+		}
+		if !sendEntry(ctx, out, entry) {
+			return ctx.Err()
+		}
+		*last = entry.Signature
+	}
+}
+
+// catchUpLogs forwards into out the logs of the transactions mentioning account after last, oldest first.
+func (conn *Conn) catchUpLogs(ctx context.Context, account solana.PublicKey, last *solana.Signature, out chan LogEntry) error {
+	if last.IsZero() {
+		return nil
+	}
+
+	// Signatures come newest first, 1000 at most per page.
+	var signatures []*rpc.TransactionSignature
+	before := solana.Signature{}
+	for {
+		page, err := conn.RpcClient.GetSignaturesForAddressWithOpts(ctx, account, &rpc.GetSignaturesForAddressOpts{
+			Before:     before,
+			Until:      *last,
+			Commitment: rpc.CommitmentConfirmed,
+		})
+		if err != nil {
+			return fmt.Errorf("> GetSignaturesForAddress: %v", err)
+		}
+		signatures = append(signatures, page...)
+		if len(page) < 1000 {
+			break
+		}
+		before = page[len(page)-1].Signature
+	}
+
+	for i := len(signatures) - 1; i >= 0; i-- {
+		signature := signatures[i]
+		tx, err := conn.getTransaction(ctx, signature.Signature)
+		if err != nil {
+			return fmt.Errorf("> GetTransaction %v: %v", signature.Signature, err)
+		}
+		entry := LogEntry{
+			Signature: signature.Signature,
+			Slot:      signature.Slot,
+			Err:       signature.Err,
+		}
+		if tx.BlockTime != nil {
+			entry.Time = tx.BlockTime.Time()
+		}
+		if tx.Meta != nil {
+			entry.Logs = tx.Meta.LogMessages
+		}
+		if !sendEntry(ctx, out, entry) {
+			return ctx.Err()
+		}
+		*last = entry.Signature
+	}
+	return nil
+}
+
+// sendEntry waits until out accepts entry, reporting false when ctx is cancelled first.
+func sendEntry(ctx context.Context, out chan LogEntry, entry LogEntry) bool {
+	select {
+	case out <- entry:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,358 @@
+package fakerpc
+
+import (
+	"SuperNet-Node/chain/super/distri_ai"
+	"SuperNet-Node/utils"
+	"fmt"
+	"time"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+// computeUnits is the compute units reported for every transaction.
+const computeUnits = 20000
+
+// programError is an error of the emulated program, reported as an Anchor error.
+type programError struct {
+	Code    uint32
+	Name    string
+	Message string
+}
+
+func (e *programError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Name, e.Message)
+}
+
+var (
+	errFallbackNotFound  = &programError{101, "InstructionFallbackNotFound", "Fallback functions are not supported"}
+	errConstraintSigner  = &programError{2002, "ConstraintSigner", "A signer constraint was violated"}
+	errConstraintHasOne  = &programError{2001, "ConstraintHasOne", "A has one constraint was violated"}
+	errDidNotDeserialize = &programError{3003, "AccountDidNotDeserialize", "Failed to deserialize the account"}
+	errNotInitialized    = &programError{3012, "AccountNotInitialized", "The program expected this account to be already initialized"}
+	errAlreadyInUse      = &programError{6000, "AccountInUse", "The account is already in use"}
+	errStatus            = &programError{6001, "StatusError", "The status of the account does not allow the instruction"}
+	errDuration          = &programError{6002, "DurationError", "The duration exceeds the maximum duration of the machine"}
+	errUnsupportedByFake = &programError{6999, "UnsupportedByFake", "The instruction is not emulated by the fake RPC server"}
+)
+
+// execute runs the instructions of tx on s.accounts, returning its logs and its error in the shape
+// of the RPC, nil when it succeeded. Instructions of other programs only log their invocation.
+// s.mu must be held, and the caller restores the accounts when the transaction fails.
+func (s *Server) execute(tx *solana.Transaction, now time.Time) ([]string, interface{}, uint64) {
+	var logs []string
+	for i, compiled := range tx.Message.Instructions {
+		program, err := tx.Message.Program(compiled.ProgramIDIndex)
+		if err != nil {
+			return logs, map[string]interface{}{"InstructionError": []interface{}{i, "InvalidAccountData"}}, 0
+		}
+		logs = append(logs, fmt.Sprintf("Program %v invoke [1]", program))
+		if program.Equals(s.programID) {
+			accounts, err := compiled.ResolveInstructionAccounts(&tx.Message)
+			if err != nil {
+				return logs, map[string]interface{}{"InstructionError": []interface{}{i, "InvalidAccountData"}}, 0
+			}
+			name, programErr := s.apply(accounts, compiled.Data, now)
+			if name != "" {
+				logs = append(logs, "Program log: Instruction: "+name)
+			}
+			if programErr != nil {
+				logs = append(logs,
+					fmt.Sprintf("Program log: AnchorError occurred. Error Code: %v. Error Number: %v. Error Message: %v.",
+						programErr.Name, programErr.Code, programErr.Message),
+					fmt.Sprintf("Program %v failed: custom program error: 0x%x", program, programErr.Code))
+				return logs, map[string]interface{}{"InstructionError": []interface{}{i, map[string]uint32{"Custom": programErr.Code}}}, computeUnits
+			}
+		}
+		logs = append(logs, fmt.Sprintf("Program %v success", program))
+	}
+	return logs, nil, computeUnits
+}
+
+// apply emulates the effects of a DistriAi instruction on the program accounts and returns its name.
+// Token transfers are not emulated.
+func (s *Server) apply(accounts []*solana.AccountMeta, data []byte, now time.Time) (string, *programError) {
+	decoded, err := distri_ai.DecodeInstruction(accounts, data)
+	if err != nil {
+		return "", errFallbackNotFound
+	}
+	name := distri_ai.InstructionIDToName(decoded.TypeID)
+
+	switch inst := decoded.Impl.(type) {
+	case *distri_ai.AddMachine:
+		if !inst.GetOwnerAccount().IsSigner {
+			return name, errConstraintSigner
+		}
+		if _, ok := s.accounts[inst.GetMachineAccount().PublicKey]; ok {
+			return name, errAlreadyInUse
+		}
+		return name, s.put(inst.GetMachineAccount().PublicKey, distri_ai.Machine{
+			Owner:    inst.GetOwnerAccount().PublicKey,
+			Uuid:     *inst.Uuid,
+			Metadata: *inst.Metadata,
+			Status:   distri_ai.MachineStatusIdle,
+		})
+
+	case *distri_ai.RemoveMachine:
+		machine, err := s.ownedMachine(inst.GetMachineAccount(), inst.GetOwnerAccount())
+		if err != nil {
+			return name, err
+		}
+		if machine.Status == distri_ai.MachineStatusRenting {
+			return name, errStatus
+		}
+		delete(s.accounts, inst.GetMachineAccount().PublicKey)
+		return name, nil
+
+	case *distri_ai.MakeOffer:
+		machine, err := s.ownedMachine(inst.GetMachineAccount(), inst.GetOwnerAccount())
+		if err != nil {
+			return name, err
+		}
+		if machine.Status == distri_ai.MachineStatusRenting {
+			return name, errStatus
+		}
+		machine.Status = distri_ai.MachineStatusForRent
+		machine.Price, machine.MaxDuration, machine.Disk = *inst.Price, *inst.MaxDuration, *inst.Disk
+		return name, s.put(inst.GetMachineAccount().PublicKey, machine)
+
+	case *distri_ai.CancelOffer:
+		machine, err := s.ownedMachine(inst.GetMachineAccount(), inst.GetOwnerAccount())
+		if err != nil {
+			return name, err
+		}
+		if machine.Status != distri_ai.MachineStatusForRent {
+			return name, errStatus
+		}
+		machine.Status = distri_ai.MachineStatusIdle
+		return name, s.put(inst.GetMachineAccount().PublicKey, machine)
+
+	case *distri_ai.PlaceOrder:
+		if !inst.GetBuyerAccount().IsSigner {
+			return name, errConstraintSigner
+		}
+		machine, err := s.machine(inst.GetMachineAccount().PublicKey)
+		if err != nil {
+			return name, err
+		}
+		if machine.Status != distri_ai.MachineStatusForRent {
+			return name, errStatus
+		}
+		if *inst.Duration == 0 || *inst.Duration > machine.MaxDuration {
+			return name, errDuration
+		}
+		orderAccount := inst.GetOrderAccount().PublicKey
+		if _, ok := s.accounts[orderAccount]; ok {
+			return name, errAlreadyInUse
+		}
+		machine.Status = distri_ai.MachineStatusRenting
+		machine.OrderPda = orderAccount
+		if err := s.put(inst.GetMachineAccount().PublicKey, machine); err != nil {
+			return name, err
+		}
+		return name, s.put(orderAccount, distri_ai.Order{
+			OrderId:   *inst.OrderId,
+			Buyer:     inst.GetBuyerAccount().PublicKey,
+			Seller:    machine.Owner,
+			MachineId: machine.Uuid,
+			Price:     machine.Price,
+			Duration:  *inst.Duration,
+			Total:     machine.Price * uint64(*inst.Duration),
+			Metadata:  *inst.Metadata,
+			Status:    distri_ai.OrderStatusPreparing,
+			OrderTime: now.Unix(),
+		})
+
+	case *distri_ai.RenewOrder:
+		order, err := s.order(inst.GetOrderAccount().PublicKey)
+		if err != nil {
+			return name, err
+		}
+		if !inst.GetBuyerAccount().IsSigner || !order.Buyer.Equals(inst.GetBuyerAccount().PublicKey) {
+			return name, errConstraintSigner
+		}
+		if order.Status != distri_ai.OrderStatusTraining {
+			return name, errStatus
+		}
+		order.Duration += *inst.Duration
+		order.Total += order.Price * uint64(*inst.Duration)
+		return name, s.put(inst.GetOrderAccount().PublicKey, order)
+
+	case *distri_ai.RefundOrder:
+		order, err := s.order(inst.GetOrderAccount().PublicKey)
+		if err != nil {
+			return name, err
+		}
+		if !inst.GetBuyerAccount().IsSigner || !order.Buyer.Equals(inst.GetBuyerAccount().PublicKey) {
+			return name, errConstraintSigner
+		}
+		if order.Status != distri_ai.OrderStatusTraining {
+			return name, errStatus
+		}
+		order.Status = distri_ai.OrderStatusRefunded
+		order.RefundTime = now.Unix()
+		return name, s.endOrder(inst.GetMachineAccount().PublicKey, inst.GetOrderAccount().PublicKey, order)
+
+	case *distri_ai.StartOrder:
+		order, err := s.order(inst.GetOrderAccount().PublicKey)
+		if err != nil {
+			return name, err
+		}
+		if !inst.GetSellerAccount().IsSigner || !order.Seller.Equals(inst.GetSellerAccount().PublicKey) {
+			return name, errConstraintSigner
+		}
+		if order.Status != distri_ai.OrderStatusPreparing {
+			return name, errStatus
+		}
+		order.Status = distri_ai.OrderStatusTraining
+		order.StartTime = now.Unix()
+		return name, s.put(inst.GetOrderAccount().PublicKey, order)
+
+	case *distri_ai.OrderCompleted:
+		order, err := s.sellerOrder(inst.GetOrderAccount(), inst.GetSellerAccount())
+		if err != nil {
+			return name, err
+		}
+		if order.Status != distri_ai.OrderStatusTraining {
+			return name, errStatus
+		}
+		order.Status = distri_ai.OrderStatusCompleted
+		order.Metadata = *inst.Metadata
+		return name, s.endOrder(inst.GetMachineAccount().PublicKey, inst.GetOrderAccount().PublicKey, order)
+
+	case *distri_ai.OrderFailed:
+		order, err := s.sellerOrder(inst.GetOrderAccount(), inst.GetSellerAccount())
+		if err != nil {
+			return name, err
+		}
+		if order.Status != distri_ai.OrderStatusPreparing && order.Status != distri_ai.OrderStatusTraining {
+			return name, errStatus
+		}
+		order.Status = distri_ai.OrderStatusFailed
+		order.Metadata = *inst.Metadata
+		return name, s.endOrder(inst.GetMachineAccount().PublicKey, inst.GetOrderAccount().PublicKey, order)
+
+	case *distri_ai.SubmitTask:
+		machine, err := s.ownedMachine(inst.GetMachineAccount(), inst.GetOwnerAccount())
+		if err != nil {
+			return name, err
+		}
+		taskAccount := inst.GetTaskAccount().PublicKey
+		if _, ok := s.accounts[taskAccount]; ok {
+			return name, errAlreadyInUse
+		}
+		if err := s.put(taskAccount, distri_ai.Task{
+			Uuid:      *inst.Uuid,
+			Period:    *inst.Period,
+			Owner:     machine.Owner,
+			MachineId: machine.Uuid,
+			Metadata:  *inst.Metadata,
+		}); err != nil {
+			return name, err
+		}
+
+		reward := distri_ai.Reward{Period: *inst.Period, StartTime: utils.PeriodStartTime(*inst.Period).Unix()}
+		if err := s.get(inst.GetRewardAccount().PublicKey, &reward); err != nil && err != errNotInitialized {
+			return name, err
+		}
+		reward.TaskNum++
+		if err := s.put(inst.GetRewardAccount().PublicKey, reward); err != nil {
+			return name, err
+		}
+
+		rewardMachine := distri_ai.RewardMachine{Period: *inst.Period, Owner: machine.Owner, MachineId: machine.Uuid}
+		if err := s.get(inst.GetRewardMachineAccount().PublicKey, &rewardMachine); err != nil && err != errNotInitialized {
+			return name, err
+		}
+		if rewardMachine.TaskNum == 0 {
+			reward.MachineNum++
+			if err := s.put(inst.GetRewardAccount().PublicKey, reward); err != nil {
+				return name, err
+			}
+		}
+		rewardMachine.TaskNum++
+		return name, s.put(inst.GetRewardMachineAccount().PublicKey, rewardMachine)
+	}
+	return name, errUnsupportedByFake
+}
+
+// endOrder stores an ended order and makes its machine ForRent again.
+func (s *Server) endOrder(machineAccount, orderAccount solana.PublicKey, order distri_ai.Order) *programError {
+	machine, err := s.machine(machineAccount)
+	if err != nil {
+		return err
+	}
+	if !machine.OrderPda.Equals(orderAccount) {
+		return errConstraintHasOne
+	}
+	if order.Status == distri_ai.OrderStatusCompleted {
+		machine.CompletedCount++
+	} else if order.Status == distri_ai.OrderStatusFailed {
+		machine.FailedCount++
+	}
+	machine.Status = distri_ai.MachineStatusForRent
+	machine.OrderPda = solana.PublicKey{}
+	if err := s.put(machineAccount, machine); err != nil {
+		return err
+	}
+	return s.put(orderAccount, order)
+}
+
+func (s *Server) machine(pubkey solana.PublicKey) (distri_ai.Machine, *programError) {
+	var machine distri_ai.Machine
+	return machine, s.get(pubkey, &machine)
+}
+
+func (s *Server) order(pubkey solana.PublicKey) (distri_ai.Order, *programError) {
+	var order distri_ai.Order
+	return order, s.get(pubkey, &order)
+}
+
+// ownedMachine returns the machine, checking that owner signed and owns it.
+func (s *Server) ownedMachine(machineMeta, owner *solana.AccountMeta) (distri_ai.Machine, *programError) {
+	machine, err := s.machine(machineMeta.PublicKey)
+	if err != nil {
+		return machine, err
+	}
+	if !owner.IsSigner || !machine.Owner.Equals(owner.PublicKey) {
+		return machine, errConstraintSigner
+	}
+	return machine, nil
+}
+
+// sellerOrder returns the order, checking that seller signed and sold it.
+func (s *Server) sellerOrder(orderMeta, seller *solana.AccountMeta) (distri_ai.Order, *programError) {
+	order, err := s.order(orderMeta.PublicKey)
+	if err != nil {
+		return order, err
+	}
+	if !seller.IsSigner || !order.Seller.Equals(seller.PublicKey) {
+		return order, errConstraintSigner
+	}
+	return order, nil
+}
+
+// get decodes the program account at pubkey into v.
+func (s *Server) get(pubkey solana.PublicKey, v interface{}) *programError {
+	acc, ok := s.accounts[pubkey]
+	if !ok {
+		return errNotInitialized
+	}
+	if !acc.Owner.Equals(s.programID) {
+		return errDidNotDeserialize
+	}
+	if err := bin.UnmarshalBorsh(v, acc.Data); err != nil {
+		return errDidNotDeserialize
+	}
+	return nil
+}
+
+// put stores v as a program account at pubkey.
+func (s *Server) put(pubkey solana.PublicKey, v interface{}) *programError {
+	data, err := bin.MarshalBorsh(v)
+	if err != nil {
+		return errDidNotDeserialize
+	}
+	s.accounts[pubkey] = account{Lamports: rentExempt(len(data)), Owner: s.programID, Data: data}
+	return nil
+}
@@ -0,0 +1,643 @@
+// Package fakerpc is an in-memory Solana JSON-RPC server emulating the DistriAi program,
+// so that the node can run against it offline with base.rpc pointing to it.
+// It serves the HTTP methods used by the node only; there is no WebSocket endpoint,
+// so subscriptions fall back to polling.
+package fakerpc
+
+import (
+	"SuperNet-Node/chain/super/distri_ai"
+	"bytes"
+	"cmp"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+// blockhashValidity is the number of blocks a blockhash stays valid, as on a real cluster.
+const blockhashValidity = 150
+
+// lamportsPerSignature is the base fee charged per signature.
+const lamportsPerSignature = 5000
+
+type account struct {
+	Lamports uint64
+	Owner    solana.PublicKey
+	Data     []byte
+}
+
+type transaction struct {
+	Signature solana.Signature
+	Slot      uint64
+	BlockTime int64
+	Raw       []byte
+	Err       interface{}
+	Logs      []string
+	Fee       uint64
+	// PreBalances and PostBalances follow the account keys of the message.
+	PreBalances  []uint64
+	PostBalances []uint64
+}
+
+// Transaction is a landed transaction, as listed by Transactions.
+type Transaction struct {
+	Signature solana.Signature
+	Slot      uint64
+	BlockTime time.Time
+	// Err is the error of a failed transaction in the shape of the RPC, nil when it succeeded.
+	Err  interface{}
+	Logs []string
+}
+
+// Server holds the accounts and the transactions of the fake cluster. Each landed transaction
+// produces a new slot, and is confirmed at once.
+type Server struct {
+	programID solana.PublicKey
+
+	mu           sync.Mutex
+	slot         uint64
+	blockhash    solana.Hash
+	accounts     map[solana.PublicKey]account
+	transactions map[solana.Signature]*transaction
+	// signatures lists the transactions mentioning each account, oldest first.
+	signatures map[solana.PublicKey][]solana.Signature
+	// now is the clock of the cluster, stamping blocks and program timestamps.
+	now func() time.Time
+	// down makes every request fail as an unavailable node would.
+	down bool
+}
+
+// NewServer returns an empty cluster running the DistriAi program at programID.
+func NewServer(programID solana.PublicKey) *Server {
+	s := &Server{
+		programID:    programID,
+		slot:         1,
+		accounts:     map[solana.PublicKey]account{},
+		transactions: map[solana.Signature]*transaction{},
+		signatures:   map[solana.PublicKey][]solana.Signature{},
+		now:          time.Now,
+	}
+	s.newBlockhash()
+	return s
+}
+
+// ListenAndServe serves JSON-RPC on addr, e.g. 127.0.0.1:8899.
+func (s *Server) ListenAndServe(addr string) error {
+	return http.ListenAndServe(addr, s)
+}
+
+// SetClock replaces the clock of the cluster, e.g. to make orders start in the past.
+func (s *Server) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// SetDown makes the server answer 503 Service Unavailable to every request until it is set up again.
+func (s *Server) SetDown(down bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.down = down
+}
+
+// Transactions returns the landed transactions, oldest first.
+func (s *Server) Transactions() []Transaction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	transactions := make([]Transaction, 0, len(s.transactions))
+	for _, record := range s.transactions {
+		transactions = append(transactions, Transaction{
+			Signature: record.Signature,
+			Slot:      record.Slot,
+			BlockTime: time.Unix(record.BlockTime, 0),
+			Err:       record.Err,
+			Logs:      slices.Clone(record.Logs),
+		})
+	}
+	slices.SortFunc(transactions, func(a, b Transaction) int { return cmp.Compare(a.Slot, b.Slot) })
+	return transactions
+}
+
+// SetAccount stores an account owned by owner.
+func (s *Server) SetAccount(pubkey solana.PublicKey, owner solana.PublicKey, lamports uint64, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accounts[pubkey] = account{Lamports: lamports, Owner: owner, Data: slices.Clone(data)}
+}
+
+// Account returns the data of an account, false when it does not exist.
+func (s *Server) Account(pubkey solana.PublicKey) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	acc, ok := s.accounts[pubkey]
+	return slices.Clone(acc.Data), ok
+}
+
+// SetMachine stores a Machine account of the program.
+func (s *Server) SetMachine(pubkey solana.PublicKey, machine distri_ai.Machine) error {
+	return s.setProgramAccount(pubkey, machine)
+}
+
+// SetOrder stores an Order account of the program.
+func (s *Server) SetOrder(pubkey solana.PublicKey, order distri_ai.Order) error {
+	return s.setProgramAccount(pubkey, order)
+}
+
+// SetReward stores a Reward account of the program.
+func (s *Server) SetReward(pubkey solana.PublicKey, reward distri_ai.Reward) error {
+	return s.setProgramAccount(pubkey, reward)
+}
+
+// SetRewardMachine stores a RewardMachine account of the program.
+func (s *Server) SetRewardMachine(pubkey solana.PublicKey, reward distri_ai.RewardMachine) error {
+	return s.setProgramAccount(pubkey, reward)
+}
+
+func (s *Server) setProgramAccount(pubkey solana.PublicKey, value interface{}) error {
+	data, err := bin.MarshalBorsh(value)
+	if err != nil {
+		return fmt.Errorf("> MarshalBorsh: %v", err)
+	}
+	s.SetAccount(pubkey, s.programID, rentExempt(len(data)), data)
+	return nil
+}
+
+// newBlockhash starts a new slot; s.mu must be held unless s is not shared yet.
+func (s *Server) newBlockhash() {
+	rand.Read(s.blockhash[:])
+}
+
+// rentExempt returns the rent exempt balance of an account holding size bytes.
+func rentExempt(size int) uint64 {
+	return uint64(128+size) * 6960
+}
+
+type request struct {
+	JSONRPC string            `json:"jsonrpc"`
+	ID      json.RawMessage   `json:"id"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+func invalidParams(format string, a ...interface{}) *rpcError {
+	return &rpcError{Code: -32602, Message: fmt.Sprintf(format, a...)}
+}
+
+// ServeHTTP answers a JSON-RPC request or a batch of them.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.mu.Lock()
+	down := s.down
+	s.mu.Unlock()
+	if down {
+		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+		writeJSON(w, response{JSONRPC: "2.0", Error: &rpcError{Code: -32700, Message: "Parse error"}})
+		return
+	}
+
+	if len(raw) > 0 && raw[0] == '[' {
+		var requests []request
+		if err := json.Unmarshal(raw, &requests); err != nil {
+			writeJSON(w, response{JSONRPC: "2.0", Error: &rpcError{Code: -32600, Message: "Invalid Request"}})
+			return
+		}
+		responses := make([]response, 0, len(requests))
+		for _, req := range requests {
+			responses = append(responses, s.handle(req))
+		}
+		writeJSON(w, responses)
+		return
+	}
+
+	var req request
+	if err := json.Unmarshal(raw, &req); err != nil {
+		writeJSON(w, response{JSONRPC: "2.0", Error: &rpcError{Code: -32600, Message: "Invalid Request"}})
+		return
+	}
+	writeJSON(w, s.handle(req))
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func (s *Server) handle(req request) response {
+	resp := response{JSONRPC: "2.0", ID: req.ID}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result interface{}
+	var err error
+	switch req.Method {
+	case "getHealth":
+		result = "ok"
+	case "getSlot":
+		result = s.slot
+	case "getBlockHeight":
+		result = s.slot
+	case "getLatestBlockhash":
+		result = s.withContext(map[string]interface{}{
+			"blockhash":            s.blockhash.String(),
+			"lastValidBlockHeight": s.slot + blockhashValidity,
+		})
+	case "getRecentPrioritizationFees":
+		result = []interface{}{}
+	case "getMinimumBalanceForRentExemption":
+		var size int
+		if err = param(req.Params, 0, &size); err == nil {
+			result = rentExempt(size)
+		}
+	case "getBalance":
+		result, err = s.getBalance(req.Params)
+	case "getAccountInfo":
+		result, err = s.getAccountInfo(req.Params)
+	case "getMultipleAccounts":
+		result, err = s.getMultipleAccounts(req.Params)
+	case "getProgramAccounts":
+		result, err = s.getProgramAccounts(req.Params)
+	case "simulateTransaction":
+		result, err = s.simulateTransaction(req.Params)
+	case "sendTransaction":
+		result, err = s.sendTransaction(req.Params)
+	case "getSignatureStatuses":
+		result, err = s.getSignatureStatuses(req.Params)
+	case "getTransaction":
+		result, err = s.getTransaction(req.Params)
+	case "getSignaturesForAddress":
+		result, err = s.getSignaturesForAddress(req.Params)
+	default:
+		err = &rpcError{Code: -32601, Message: fmt.Sprintf("Method not found: %v", req.Method)}
+	}
+
+	if err != nil {
+		rpcErr, ok := err.(*rpcError)
+		if !ok {
+			rpcErr = &rpcError{Code: -32603, Message: err.Error()}
+		}
+		resp.Error = rpcErr
+		return resp
+	}
+	if result == nil {
+		// A missing account is a null result, which omitempty would drop.
+		resp.Result = json.RawMessage("null")
+	} else {
+		resp.Result = result
+	}
+	return resp
+}
+
+// param decodes the i-th parameter into v, leaving v unchanged when it is absent.
+func param(params []json.RawMessage, i int, v interface{}) error {
+	if i >= len(params) {
+		return nil
+	}
+	if err := json.Unmarshal(params[i], v); err != nil {
+		return invalidParams("invalid param %v: %v", i, err)
+	}
+	return nil
+}
+
+func pubkeyParam(params []json.RawMessage, i int) (solana.PublicKey, error) {
+	var s string
+	if err := param(params, i, &s); err != nil {
+		return solana.PublicKey{}, err
+	}
+	pubkey, err := solana.PublicKeyFromBase58(s)
+	if err != nil {
+		return solana.PublicKey{}, invalidParams("Invalid param: %v", err)
+	}
+	return pubkey, nil
+}
+
+func (s *Server) withContext(value interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"context": map[string]interface{}{"slot": s.slot},
+		"value":   value,
+	}
+}
+
+// encodeAccount returns an account in the base64 encoding, nil when it does not exist.
+func (s *Server) encodeAccount(pubkey solana.PublicKey) interface{} {
+	acc, ok := s.accounts[pubkey]
+	if !ok {
+		return nil
+	}
+	return map[string]interface{}{
+		"data":       []string{base64.StdEncoding.EncodeToString(acc.Data), "base64"},
+		"executable": false,
+		"lamports":   acc.Lamports,
+		"owner":      acc.Owner.String(),
+		"rentEpoch":  0,
+		"space":      len(acc.Data),
+	}
+}
+
+func (s *Server) getBalance(params []json.RawMessage) (interface{}, error) {
+	pubkey, err := pubkeyParam(params, 0)
+	if err != nil {
+		return nil, err
+	}
+	return s.withContext(s.accounts[pubkey].Lamports), nil
+}
+
+func (s *Server) getAccountInfo(params []json.RawMessage) (interface{}, error) {
+	pubkey, err := pubkeyParam(params, 0)
+	if err != nil {
+		return nil, err
+	}
+	return s.withContext(s.encodeAccount(pubkey)), nil
+}
+
+func (s *Server) getMultipleAccounts(params []json.RawMessage) (interface{}, error) {
+	var keys []solana.PublicKey
+	if err := param(params, 0, &keys); err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		values = append(values, s.encodeAccount(key))
+	}
+	return s.withContext(values), nil
+}
+
+func (s *Server) getProgramAccounts(params []json.RawMessage) (interface{}, error) {
+	owner, err := pubkeyParam(params, 0)
+	if err != nil {
+		return nil, err
+	}
+	var opts struct {
+		Filters []struct {
+			DataSize *int `json:"dataSize"`
+			Memcmp   *struct {
+				Offset int           `json:"offset"`
+				Bytes  solana.Base58 `json:"bytes"`
+			} `json:"memcmp"`
+		} `json:"filters"`
+	}
+	if err := param(params, 1, &opts); err != nil {
+		return nil, err
+	}
+
+	var filters []func(data []byte) bool
+	for _, f := range opts.Filters {
+		switch {
+		case f.DataSize != nil:
+			size := *f.DataSize
+			filters = append(filters, func(data []byte) bool { return len(data) == size })
+		case f.Memcmp != nil:
+			bytes := []byte(f.Memcmp.Bytes)
+			offset := f.Memcmp.Offset
+			filters = append(filters, func(data []byte) bool {
+				return offset+len(bytes) <= len(data) && string(data[offset:offset+len(bytes)]) == string(bytes)
+			})
+		}
+	}
+
+	keys := make([]solana.PublicKey, 0, len(s.accounts))
+	for key := range s.accounts {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b solana.PublicKey) int { return bytes.Compare(a[:], b[:]) })
+	out := []interface{}{}
+	for _, key := range keys {
+		acc := s.accounts[key]
+		if !acc.Owner.Equals(owner) {
+			continue
+		}
+		if !slices.ContainsFunc(filters, func(filter func([]byte) bool) bool { return !filter(acc.Data) }) {
+			out = append(out, map[string]interface{}{"pubkey": key.String(), "account": s.encodeAccount(key)})
+		}
+	}
+	return out, nil
+}
+
+// decodeTransaction decodes the base64 transaction of sendTransaction and simulateTransaction.
+func decodeTransaction(params []json.RawMessage) (*solana.Transaction, []byte, error) {
+	var encoded string
+	if err := param(params, 0, &encoded); err != nil {
+		return nil, nil, err
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, nil, invalidParams("only base64 encoded transactions are supported: %v", err)
+	}
+	tx, err := solana.TransactionFromDecoder(bin.NewBinDecoder(raw))
+	if err != nil {
+		return nil, nil, invalidParams("failed to deserialize transaction: %v", err)
+	}
+	return tx, raw, nil
+}
+
+func (s *Server) simulateTransaction(params []json.RawMessage) (interface{}, error) {
+	tx, _, err := decodeTransaction(params)
+	if err != nil {
+		return nil, err
+	}
+	var opts struct {
+		Accounts *struct {
+			Addresses []solana.PublicKey `json:"addresses"`
+		} `json:"accounts"`
+	}
+	if err := param(params, 1, &opts); err != nil {
+		return nil, err
+	}
+
+	// The simulation runs on a copy of the accounts, which is discarded.
+	saved := s.accounts
+	s.accounts = maps.Clone(saved)
+	defer func() { s.accounts = saved }()
+
+	logs, txErr, units := s.execute(tx, s.now())
+	value := map[string]interface{}{
+		"err":           txErr,
+		"logs":          logs,
+		"unitsConsumed": units,
+		"accounts":      nil,
+	}
+	if opts.Accounts != nil {
+		accounts := make([]interface{}, 0, len(opts.Accounts.Addresses))
+		for _, address := range opts.Accounts.Addresses {
+			accounts = append(accounts, s.encodeAccount(address))
+		}
+		value["accounts"] = accounts
+	}
+	return s.withContext(value), nil
+}
+
+func (s *Server) sendTransaction(params []json.RawMessage) (interface{}, error) {
+	tx, raw, err := decodeTransaction(params)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.VerifySignatures(); err != nil {
+		return nil, &rpcError{Code: -32003, Message: fmt.Sprintf("Transaction signature verification failure: %v", err)}
+	}
+	signature := tx.Signatures[0]
+	if _, ok := s.transactions[signature]; ok {
+		return signature.String(), nil
+	}
+
+	keys := tx.Message.AccountKeys
+	record := &transaction{
+		Signature:   signature,
+		Raw:         raw,
+		Fee:         lamportsPerSignature * uint64(len(tx.Signatures)),
+		PreBalances: s.balances(keys),
+	}
+
+	// A failed transaction leaves the accounts unchanged.
+	saved := maps.Clone(s.accounts)
+	record.Logs, record.Err, _ = s.execute(tx, s.now())
+	if record.Err != nil {
+		s.accounts = saved
+	}
+	if payer, ok := s.accounts[keys[0]]; ok {
+		payer.Lamports -= min(payer.Lamports, record.Fee)
+		s.accounts[keys[0]] = payer
+	}
+	record.PostBalances = s.balances(keys)
+
+	s.slot++
+	s.newBlockhash()
+	record.Slot = s.slot
+	record.BlockTime = s.now().Unix()
+	s.transactions[signature] = record
+	for _, key := range keys {
+		s.signatures[key] = append(s.signatures[key], signature)
+	}
+	return signature.String(), nil
+}
+
+func (s *Server) balances(keys []solana.PublicKey) []uint64 {
+	balances := make([]uint64, 0, len(keys))
+	for _, key := range keys {
+		balances = append(balances, s.accounts[key].Lamports)
+	}
+	return balances
+}
+
+func (s *Server) getSignatureStatuses(params []json.RawMessage) (interface{}, error) {
+	var signatures []solana.Signature
+	if err := param(params, 0, &signatures); err != nil {
+		return nil, err
+	}
+	statuses := make([]interface{}, 0, len(signatures))
+	for _, signature := range signatures {
+		record, ok := s.transactions[signature]
+		if !ok {
+			statuses = append(statuses, nil)
+			continue
+		}
+		statuses = append(statuses, map[string]interface{}{
+			"slot":               record.Slot,
+			"confirmations":      nil,
+			"err":                record.Err,
+			"confirmationStatus": "finalized",
+		})
+	}
+	return s.withContext(statuses), nil
+}
+
+func (s *Server) getTransaction(params []json.RawMessage) (interface{}, error) {
+	var signature solana.Signature
+	if err := param(params, 0, &signature); err != nil {
+		return nil, err
+	}
+	record, ok := s.transactions[signature]
+	if !ok {
+		return nil, nil
+	}
+	return map[string]interface{}{
+		"slot":        record.Slot,
+		"blockTime":   record.BlockTime,
+		"transaction": []string{base64.StdEncoding.EncodeToString(record.Raw), "base64"},
+		"meta": map[string]interface{}{
+			"err":                  record.Err,
+			"fee":                  record.Fee,
+			"logMessages":          record.Logs,
+			"preBalances":          record.PreBalances,
+			"postBalances":         record.PostBalances,
+			"innerInstructions":    []interface{}{},
+			"preTokenBalances":     []interface{}{},
+			"postTokenBalances":    []interface{}{},
+			"computeUnitsConsumed": computeUnits,
+		},
+	}, nil
+}
+
+func (s *Server) getSignaturesForAddress(params []json.RawMessage) (interface{}, error) {
+	address, err := pubkeyParam(params, 0)
+	if err != nil {
+		return nil, err
+	}
+	var opts struct {
+		Limit  int              `json:"limit"`
+		Before solana.Signature `json:"before"`
+		Until  solana.Signature `json:"until"`
+	}
+	if err := param(params, 1, &opts); err != nil {
+		return nil, err
+	}
+	if opts.Limit <= 0 || opts.Limit > 1000 {
+		opts.Limit = 1000
+	}
+
+	// Newest first, as a real node does.
+	signatures := s.signatures[address]
+	out := []interface{}{}
+	started := opts.Before.IsZero()
+	for i := len(signatures) - 1; i >= 0 && len(out) < opts.Limit; i-- {
+		signature := signatures[i]
+		if signature == opts.Until {
+			break
+		}
+		if !started {
+			started = signature == opts.Before
+			continue
+		}
+		record := s.transactions[signature]
+		out = append(out, map[string]interface{}{
+			"signature":          signature.String(),
+			"slot":               record.Slot,
+			"err":                record.Err,
+			"memo":               nil,
+			"blockTime":          record.BlockTime,
+			"confirmationStatus": "finalized",
+		})
+	}
+	return out, nil
+}
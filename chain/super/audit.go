@@ -0,0 +1,245 @@
+package super
+
+import (
+	"SuperNet-Node/chain/conn"
+	"SuperNet-Node/config"
+	"SuperNet-Node/pattern"
+	dbutils "SuperNet-Node/utils/db_utils"
+	logs "SuperNet-Node/utils/log_utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// auditPrefix prefixes the Badger keys of the audit log, followed by the time of the record.
+const auditPrefix = "audit/"
+
+const (
+	AuditConfirmed         = "confirmed"
+	AuditFailed            = "failed"
+	AuditAwaitingSignature = "awaiting_signature"
+)
+
+// AuditRecord is a transaction sent by the node. Records are only ever appended.
+type AuditRecord struct {
+	Time         time.Time `json:"Time"`
+	Name         string    `json:"Name"`
+	Machine      string    `json:"Machine"`
+	Order        string    `json:"Order"`
+	Signature    string    `json:"Signature"`
+	Slot         uint64    `json:"Slot"`
+	Fee          uint64    `json:"Fee"`
+	ComputeUnits uint64    `json:"ComputeUnits"`
+	Status       string    `json:"Status"`
+	Error        string    `json:"Error"`
+}
+
+// HistoryFilter selects audit records; zero fields match everything.
+type HistoryFilter struct {
+	Order string
+	// Name matches the full instruction name or the part after the dot, e.g. "order_start".
+	Name string
+	From time.Time
+	To   time.Time
+}
+
+// orderTx are the transactions sent for the order in ProgramSuperOrder.
+var orderTx = map[string]bool{
+	pattern.TX_HASHRATE_MARKET_ORDER_START:     true,
+	pattern.TX_HASHRATE_MARKET_ORDER_COMPLETED: true,
+	pattern.TX_HASHRATE_MARKET_ORDER_FAILED:    true,
+}
+
+// auditSeq tells apart records appended in the same nanosecond.
+var auditSeq atomic.Uint32
+
+// auditLog is the audit log held open by OpenAuditLog. Other processes only open it for the time
+// of a write or a query, so that it is free for the node to start.
+var auditLog struct {
+	mu sync.Mutex
+	db *badger.DB
+}
+
+// ForwardAudit, when set, hands a record to the running node holding the audit log,
+// returning an error when no node is running.
+var ForwardAudit func(record AuditRecord) error
+
+// OpenAuditLog opens the audit log at console.auditLog and holds it until CloseAuditLog.
+func OpenAuditLog() error {
+	auditLog.mu.Lock()
+	defer auditLog.mu.Unlock()
+	if auditLog.db != nil {
+		return nil
+	}
+	db, err := dbutils.Open(config.GlobalConfig.Console.AuditLog)
+	if err != nil {
+		return fmt.Errorf("> dbutils.Open %v: %v", config.GlobalConfig.Console.AuditLog, err)
+	}
+	auditLog.db = db
+	return nil
+}
+
+// CloseAuditLog closes the audit log opened by OpenAuditLog.
+func CloseAuditLog() {
+	auditLog.mu.Lock()
+	defer auditLog.mu.Unlock()
+	if auditLog.db != nil {
+		auditLog.db.Close()
+		auditLog.db = nil
+	}
+}
+
+// withAuditLog calls fn with the audit log, opening it for the call when it is not held.
+func withAuditLog(fn func(db *badger.DB) error) error {
+	auditLog.mu.Lock()
+	defer auditLog.mu.Unlock()
+	if auditLog.db != nil {
+		return fn(auditLog.db)
+	}
+	db, err := dbutils.Open(config.GlobalConfig.Console.AuditLog)
+	if err != nil {
+		return fmt.Errorf("> dbutils.Open %v: %v", config.GlobalConfig.Console.AuditLog, err)
+	}
+	defer db.Close()
+	return fn(db)
+}
+
+// auditLogHeld reports whether this process holds the audit log.
+func auditLogHeld() bool {
+	auditLog.mu.Lock()
+	defer auditLog.mu.Unlock()
+	return auditLog.db != nil
+}
+
+// order returns the order a transaction is sent for, if any.
+func (chain WrapperSuper) order(name string) string {
+	if !orderTx[name] || chain.ProgramSuperOrder.IsZero() {
+		return ""
+	}
+	return chain.ProgramSuperOrder.String()
+}
+
+// newAuditRecord returns the record of a transaction sent through the executor.
+func newAuditRecord(name string, result *conn.TxResult, err error) AuditRecord {
+	record := AuditRecord{
+		Time:   time.Now(),
+		Name:   name,
+		Status: AuditConfirmed,
+	}
+	if result != nil {
+		record.Signature = result.Signature.String()
+		record.Slot = result.Slot
+		record.Fee = result.Fee
+		record.ComputeUnits = result.ComputeUnits
+	}
+	if err != nil {
+		record.Status = AuditFailed
+		record.Error = err.Error()
+		var txErr *conn.TxError
+		if errors.As(err, &txErr) && !txErr.Signature.IsZero() {
+			record.Signature = txErr.Signature.String()
+		}
+	}
+	return record
+}
+
+// appendAudit stores record in the audit log, logging instead of failing the transaction.
+// A command run next to the node has the node store it, as the node holds the audit log.
+func appendAudit(record AuditRecord) {
+	if !auditLogHeld() && ForwardAudit != nil {
+		if err := ForwardAudit(record); err == nil {
+			return
+		}
+	}
+	if err := AppendAudit(record); err != nil {
+		logs.Warning(fmt.Sprintf("%v %v not added to the audit log: %v", record.Name, record.Signature, err))
+	}
+}
+
+// AppendAudit stores record in the audit log.
+func AppendAudit(record AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("> json.Marshal: %v", err)
+	}
+	key := fmt.Sprintf("%s%020d-%05d", auditPrefix, record.Time.UnixNano(), auditSeq.Add(1)%100000)
+	return withAuditLog(func(db *badger.DB) error {
+		return dbutils.Update(db, []byte(key), data)
+	})
+}
+
+// MigrateAuditLog moves the records that earlier versions kept in the node database to the audit log.
+func MigrateAuditLog() error {
+	db, err := dbutils.OpenDB()
+	if err != nil {
+		return fmt.Errorf("> dbutils.OpenDB: %v", err)
+	}
+
+	var keys [][]byte
+	err = withAuditLog(func(auditDB *badger.DB) error {
+		return dbutils.Scan(db, []byte(auditPrefix), nil, func(key, value []byte) (bool, error) {
+			if err := dbutils.Update(auditDB, key, value); err != nil {
+				return false, err
+			}
+			keys = append(keys, key)
+			return true, nil
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("> dbutils.Scan: %v", err)
+	}
+	for _, key := range keys {
+		if err := dbutils.Delete(db, key); err != nil {
+			return fmt.Errorf("> dbutils.Delete: %v", err)
+		}
+	}
+	if len(keys) > 0 {
+		logs.Normal(fmt.Sprintf("Moved %v records to the audit log %v", len(keys), config.GlobalConfig.Console.AuditLog))
+	}
+	return nil
+}
+
+// History returns the audit records matching filter, oldest first.
+func History(filter HistoryFilter) ([]AuditRecord, error) {
+	var start []byte
+	if !filter.From.IsZero() {
+		start = []byte(fmt.Sprintf("%s%020d", auditPrefix, filter.From.UnixNano()))
+	}
+
+	var records []AuditRecord
+	err := withAuditLog(func(db *badger.DB) error {
+		return dbutils.Scan(db, []byte(auditPrefix), start, func(key, value []byte) (bool, error) {
+			var record AuditRecord
+			if err := json.Unmarshal(value, &record); err != nil {
+				return false, fmt.Errorf("> json.Unmarshal %s: %v", key, err)
+			}
+			if !filter.To.IsZero() && record.Time.After(filter.To) {
+				return false, nil
+			}
+			if filter.matches(record) {
+				records = append(records, record)
+			}
+			return true, nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("> dbutils.Scan: %v", err)
+	}
+	return records, nil
+}
+
+func (filter HistoryFilter) matches(record AuditRecord) bool {
+	if filter.Order != "" && record.Order != filter.Order {
+		return false
+	}
+	if filter.Name != "" && record.Name != filter.Name && !strings.HasSuffix(record.Name, pattern.DOT+filter.Name) {
+		return false
+	}
+	return true
+}
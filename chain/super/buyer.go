@@ -0,0 +1,217 @@
+package super
+
+import (
+	"SuperNet-Node/chain/conn"
+	"SuperNet-Node/chain/super/distri_ai"
+	"SuperNet-Node/pattern"
+	"SuperNet-Node/utils"
+	logs "SuperNet-Node/utils/log_utils"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// WrapperBuyer sends the transactions of a buyer renting machines on the market.
+type WrapperBuyer struct {
+	Conn           *conn.Conn
+	Buyer          conn.Signer
+	FeePayer       conn.Signer // nil when the buyer pays the transaction fees
+	ProgramSuperID solana.PublicKey
+}
+
+func NewBuyerWrapper(c *conn.Conn, buyer conn.Signer, feePayer conn.Signer) *WrapperBuyer {
+	return &WrapperBuyer{
+		Conn:           c,
+		Buyer:          buyer,
+		FeePayer:       feePayer,
+		ProgramSuperID: solana.MustPublicKeyFromBase58(pattern.PROGRAM_SUPER_ID),
+	}
+}
+
+// PlaceOrder rents machine for duration hours and returns the new order account.
+func (chain WrapperBuyer) PlaceOrder(
+	ctx context.Context,
+	machine solana.PublicKey,
+	orderID [16]byte,
+	duration uint32,
+	orderPlacedMetadata pattern.OrderPlacedMetadata) (solana.PublicKey, string, error) {
+	logs.Normal(fmt.Sprintf("Extrinsic : %v", pattern.TX_HASHRATE_MARKET_PLACE_ORDER))
+
+	jsonData, err := json.Marshal(orderPlacedMetadata)
+	if err != nil {
+		return solana.PublicKey{}, "", fmt.Errorf("> json.Marshal: %v", err)
+	}
+
+	buyer := chain.Buyer.PublicKey()
+	order, _, err := solana.FindProgramAddress(utils.GenOrder(buyer, orderID), chain.ProgramSuperID)
+	if err != nil {
+		return solana.PublicKey{}, "", fmt.Errorf("> FindProgramAddress: %v", err)
+	}
+	buyerAta, vault, ecpc, err := chain.tokenAccounts(buyer)
+	if err != nil {
+		return solana.PublicKey{}, "", err
+	}
+
+	distri_ai.SetProgramID(chain.ProgramSuperID)
+	sig, err := chain.execute(
+		ctx,
+		pattern.TX_HASHRATE_MARKET_PLACE_ORDER,
+		distri_ai.NewPlaceOrderInstruction(
+			orderID,
+			duration,
+			string(jsonData),
+			machine,
+			order,
+			buyer,
+			buyerAta,
+			vault,
+			ecpc,
+			solana.TokenProgramID,
+			solana.SPLAssociatedTokenAccountProgramID,
+			solana.SystemProgramID,
+		).Build(),
+	)
+	return order, sig, err
+}
+
+// RenewOrder extends an order by duration hours.
+func (chain WrapperBuyer) RenewOrder(ctx context.Context, orderAccount solana.PublicKey, order distri_ai.Order, duration uint32) (string, error) {
+	logs.Normal(fmt.Sprintf("Extrinsic : %v", pattern.TX_HASHRATE_MARKET_RENEW_ORDER))
+
+	machine, err := orderMachine(order, chain.ProgramSuperID)
+	if err != nil {
+		return "", err
+	}
+	buyerAta, vault, ecpc, err := chain.tokenAccounts(chain.Buyer.PublicKey())
+	if err != nil {
+		return "", err
+	}
+
+	distri_ai.SetProgramID(chain.ProgramSuperID)
+	return chain.execute(
+		ctx,
+		pattern.TX_HASHRATE_MARKET_RENEW_ORDER,
+		distri_ai.NewRenewOrderInstruction(
+			duration,
+			machine,
+			orderAccount,
+			chain.Buyer.PublicKey(),
+			buyerAta,
+			vault,
+			ecpc,
+			solana.TokenProgramID,
+			solana.SPLAssociatedTokenAccountProgramID,
+		).Build(),
+	)
+}
+
+// RefundOrder ends a Training order early. The unused hours are refunded to the buyer
+// and the used ones paid to the seller.
+func (chain WrapperBuyer) RefundOrder(ctx context.Context, orderAccount solana.PublicKey, order distri_ai.Order) (string, error) {
+	logs.Normal(fmt.Sprintf("Extrinsic : %v", pattern.TX_HASHRATE_MARKET_REFUND_ORDER))
+
+	machine, err := orderMachine(order, chain.ProgramSuperID)
+	if err != nil {
+		return "", err
+	}
+	buyerAta, vault, ecpc, err := chain.tokenAccounts(chain.Buyer.PublicKey())
+	if err != nil {
+		return "", err
+	}
+	sellerAta, _, err := solana.FindAssociatedTokenAddress(order.Seller, ecpc)
+	if err != nil {
+		return "", fmt.Errorf("> FindAssociatedTokenAddress: %v", err)
+	}
+
+	distri_ai.SetProgramID(chain.ProgramSuperID)
+	return chain.execute(
+		ctx,
+		pattern.TX_HASHRATE_MARKET_REFUND_ORDER,
+		distri_ai.NewRefundOrderInstruction(
+			machine,
+			orderAccount,
+			chain.Buyer.PublicKey(),
+			buyerAta,
+			sellerAta,
+			vault,
+			ecpc,
+			solana.TokenProgramID,
+			solana.SPLAssociatedTokenAccountProgramID,
+			solana.SystemProgramID,
+		).Build(),
+	)
+}
+
+// RemoveOrder closes the account of a finished order, returning its rent to the buyer.
+func (chain WrapperBuyer) RemoveOrder(ctx context.Context, orderAccount solana.PublicKey) (string, error) {
+	logs.Normal(fmt.Sprintf("Extrinsic : %v", pattern.TX_HASHRATE_MARKET_REMOVE_ORDER))
+
+	distri_ai.SetProgramID(chain.ProgramSuperID)
+	return chain.execute(
+		ctx,
+		pattern.TX_HASHRATE_MARKET_REMOVE_ORDER,
+		distri_ai.NewRemoveOrderInstruction(
+			orderAccount,
+			chain.Buyer.PublicKey(),
+		).Build(),
+	)
+}
+
+// WatchOrder pushes every decoded update of the given order account into the returned channel
+// until ctx is cancelled.
+func (chain WrapperBuyer) WatchOrder(ctx context.Context, orderID solana.PublicKey) <-chan distri_ai.Order {
+	return watchOrder(ctx, chain.Conn, orderID)
+}
+
+// tokenAccounts returns the SNT account of owner, the vault of the program and the SNT mint.
+func (chain WrapperBuyer) tokenAccounts(owner solana.PublicKey) (solana.PublicKey, solana.PublicKey, solana.PublicKey, error) {
+	ecpc := solana.MustPublicKeyFromBase58(pattern.SNT_TOKEN_ID)
+	ata, _, err := solana.FindAssociatedTokenAddress(owner, ecpc)
+	if err != nil {
+		return ata, solana.PublicKey{}, ecpc, fmt.Errorf("> FindAssociatedTokenAddress: %v", err)
+	}
+	vault, _, err := solana.FindProgramAddress(utils.GenVault(), chain.ProgramSuperID)
+	if err != nil {
+		return ata, vault, ecpc, fmt.Errorf("> FindProgramAddress: %v", err)
+	}
+	return ata, vault, ecpc, nil
+}
+
+// orderMachine returns the machine account an order was placed on.
+func orderMachine(order distri_ai.Order, programID solana.PublicKey) (solana.PublicKey, error) {
+	machine, _, err := solana.FindProgramAddress(
+		utils.GenMachine(order.Seller, utils.ByteUUIDToStrUUID(order.MachineId)),
+		programID,
+	)
+	if err != nil {
+		return machine, fmt.Errorf("> FindProgramAddress: %v", err)
+	}
+	return machine, nil
+}
+
+// execute sends a transaction signed by the buyer and paid for by the fee payer,
+// or by the buyer when there is none.
+func (chain WrapperBuyer) execute(ctx context.Context, name string, instructions ...solana.Instruction) (string, error) {
+	tx := conn.Tx{
+		Name:         name,
+		Instructions: instructions,
+		Payer:        chain.Buyer.PublicKey(),
+		Signers:      []conn.Signer{chain.Buyer},
+	}
+	if chain.FeePayer != nil {
+		tx.Payer = chain.FeePayer.PublicKey()
+		tx.Signers = append(tx.Signers, chain.FeePayer)
+	}
+
+	result, err := chain.Conn.Execute(ctx, tx)
+	if err != nil {
+		return "", fmt.Errorf("> Execute: %w", err)
+	}
+
+	logs.Vital(fmt.Sprintf("%s completed : %v, slot: %v, fee: %v, compute units: %v",
+		name, result.Signature, result.Slot, result.Fee, result.ComputeUnits))
+
+	return result.Signature.String(), nil
+}
@@ -0,0 +1,239 @@
+package super
+
+import (
+	"SuperNet-Node/chain/conn"
+	dbutils "SuperNet-Node/utils/db_utils"
+	logs "SuperNet-Node/utils/log_utils"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/gagliardetto/solana-go"
+)
+
+// eventPrefix prefixes the Badger keys of the event index, followed by the slot and the signature.
+const eventPrefix = "event/"
+
+// eventCursorKey holds the signature of the last indexed transaction, where the listener resumes after a restart.
+const eventCursorKey = "eventCursor"
+
+// ProgramEvent is a transaction of the DistriAi program, as told by its logs.
+type ProgramEvent struct {
+	Signature string    `json:"Signature"`
+	Slot      uint64    `json:"Slot"`
+	Time      time.Time `json:"Time"`
+	Failed    bool      `json:"Failed"`
+	// Instructions are the names of the program instructions, e.g. PlaceOrder, in execution order.
+	Instructions []string      `json:"Instructions"`
+	Events       []AnchorEvent `json:"Events"`
+}
+
+// AnchorEvent is an event emitted by the program with emit!. The program publishes no event
+// definitions, so the events are kept raw: the discriminator and the undecoded data.
+type AnchorEvent struct {
+	Discriminator string `json:"Discriminator"`
+	// Data is the Borsh encoded event, without its discriminator.
+	Data []byte `json:"Data"`
+}
+
+// EventFilter selects indexed events; zero fields match everything.
+type EventFilter struct {
+	Instruction string
+	FromSlot    uint64
+	// Limit keeps the latest events only.
+	Limit int
+}
+
+// HasInstruction reports whether the transaction ran the named instruction.
+func (event ProgramEvent) HasInstruction(name string) bool {
+	return slices.Contains(event.Instructions, name)
+}
+
+// ParseLogs extracts the instructions and events of programID from the log output of a transaction.
+// Logs of other programs, including those invoked by programID, are skipped.
+func ParseLogs(programID solana.PublicKey, lines []string) ([]string, []AnchorEvent) {
+	program := programID.String()
+	var instructions []string
+	var events []AnchorEvent
+	// stack holds the programs being invoked, the innermost last.
+	var stack []string
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) >= 3 && fields[0] == "Program" && strings.HasPrefix(fields[2], "invoke"):
+			stack = append(stack, fields[1])
+		case len(fields) >= 3 && fields[0] == "Program" && (fields[2] == "success" || fields[2] == "failed:"):
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case len(stack) == 0 || stack[len(stack)-1] != program:
+		case strings.HasPrefix(line, "Program log: Instruction: "):
+			instructions = append(instructions, strings.TrimPrefix(line, "Program log: Instruction: "))
+		case strings.HasPrefix(line, "Program data: "):
+			data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(line, "Program data: "))
+			if err != nil || len(data) < 8 {
+				continue
+			}
+			events = append(events, AnchorEvent{Discriminator: hex.EncodeToString(data[:8]), Data: data[8:]})
+		}
+	}
+	return instructions, events
+}
+
+// Listener indexes the transactions of the program that mention an account and publishes them to its subscribers.
+type Listener struct {
+	conn      *conn.Conn
+	programID solana.PublicKey
+	account   solana.PublicKey
+
+	mu          sync.Mutex
+	subscribers map[chan ProgramEvent]bool
+}
+
+// NewListener returns a listener of the transactions of programID mentioning account; Run starts it.
+func NewListener(c *conn.Conn, programID solana.PublicKey, account solana.PublicKey) *Listener {
+	return &Listener{
+		conn:        c,
+		programID:   programID,
+		account:     account,
+		subscribers: map[chan ProgramEvent]bool{},
+	}
+}
+
+// NewListener returns a listener of the transactions touching the machine: orders placed,
+// renewed or refunded against it, the transactions of its orders and its claims.
+func (chain WrapperSuper) NewListener() *Listener {
+	return NewListener(chain.Conn, chain.ProgramSuperID, chain.ProgramSuperMachine)
+}
+
+// Subscribe returns a channel receiving the events indexed from now on, and the function to stop receiving them.
+// Events are dropped for a subscriber whose buffer is full.
+func (l *Listener) Subscribe(buffer int) (<-chan ProgramEvent, func()) {
+	ch := make(chan ProgramEvent, buffer)
+	l.mu.Lock()
+	l.subscribers[ch] = true
+	l.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if l.subscribers[ch] {
+				delete(l.subscribers, ch)
+				close(ch)
+			}
+		})
+	}
+}
+
+// Run indexes the transactions until ctx is cancelled, resuming after the last indexed one.
+// The channels of the subscribers are closed when it returns.
+func (l *Listener) Run(ctx context.Context) {
+	defer func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		for ch := range l.subscribers {
+			delete(l.subscribers, ch)
+			close(ch)
+		}
+	}()
+
+	var since solana.Signature
+	if cursor, err := dbutils.Get(dbutils.GetDB(), []byte(eventCursorKey)); err == nil {
+		since, _ = solana.SignatureFromBase58(string(cursor))
+	}
+
+	for entry := range l.conn.WatchLogs(ctx, l.account, since, conn.PollInterval) {
+		instructions, events := ParseLogs(l.programID, entry.Logs)
+		if len(instructions) == 0 && len(events) == 0 {
+			continue
+		}
+		event := ProgramEvent{
+			Signature:    entry.Signature.String(),
+			Slot:         entry.Slot,
+			Time:         entry.Time,
+			Failed:       entry.Err != nil,
+			Instructions: instructions,
+			Events:       events,
+		}
+		indexed, err := indexEvent(event)
+		if err != nil {
+			logs.Error(fmt.Sprintf("Index event %v: %v", event.Signature, err))
+		}
+		if !indexed {
+			continue
+		}
+		l.publish(event)
+	}
+}
+
+func (l *Listener) publish(event ProgramEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for ch := range l.subscribers {
+		select {
+		case ch <- event:
+		default:
+			logs.Warning(fmt.Sprintf("Event subscriber is full, %v dropped", event.Signature))
+		}
+	}
+}
+
+// indexEvent stores event, reporting false when it was already indexed.
+func indexEvent(event ProgramEvent) (bool, error) {
+	db := dbutils.GetDB()
+	key := []byte(fmt.Sprintf("%s%020d/%s", eventPrefix, event.Slot, event.Signature))
+	if _, err := dbutils.Get(db, key); err == nil {
+		return false, nil
+	} else if !errors.Is(err, badger.ErrKeyNotFound) {
+		return true, fmt.Errorf("> dbutils.Get: %v", err)
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return true, fmt.Errorf("> json.Marshal: %v", err)
+	}
+	if err := dbutils.Update(db, key, data); err != nil {
+		return true, fmt.Errorf("> dbutils.Update: %v", err)
+	}
+	if err := dbutils.Update(db, []byte(eventCursorKey), []byte(event.Signature)); err != nil {
+		return true, fmt.Errorf("> dbutils.Update: %v", err)
+	}
+	return true, nil
+}
+
+// Events returns the indexed events matching filter, oldest first.
+func Events(filter EventFilter) ([]ProgramEvent, error) {
+	var start []byte
+	if filter.FromSlot > 0 {
+		start = []byte(fmt.Sprintf("%s%020d", eventPrefix, filter.FromSlot))
+	}
+
+	var events []ProgramEvent
+	err := dbutils.Scan(dbutils.GetDB(), []byte(eventPrefix), start, func(key, value []byte) (bool, error) {
+		var event ProgramEvent
+		if err := json.Unmarshal(value, &event); err != nil {
+			return false, fmt.Errorf("> json.Unmarshal %s: %v", key, err)
+		}
+		if filter.Instruction == "" || event.HasInstruction(filter.Instruction) {
+			events = append(events, event)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("> dbutils.Scan: %v", err)
+	}
+	if filter.Limit > 0 && len(events) > filter.Limit {
+		events = events[len(events)-filter.Limit:]
+	}
+	return events, nil
+}
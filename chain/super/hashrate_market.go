@@ -2,18 +2,17 @@ package super
 
 import (
 	"SuperNet-Node/chain"
+	"SuperNet-Node/chain/conn"
 	"SuperNet-Node/chain/super/distri_ai"
-	"SuperNet-Node/docker"
 	"SuperNet-Node/machine_info"
 	"SuperNet-Node/pattern"
 	"SuperNet-Node/utils"
 	logs "SuperNet-Node/utils/log_utils"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 
-	"github.com/davecgh/go-spew/spew"
-	bin "github.com/gagliardetto/binary"
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
 )
@@ -22,14 +21,9 @@ type WrapperSuper struct {
 	*chain.InfoChain
 }
 
-func (chain WrapperSuper) AddMachine(hardwareInfo machine_info.MachineInfo) (string, error) {
+func (chain WrapperSuper) AddMachine(ctx context.Context, hardwareInfo machine_info.MachineInfo) (string, error) {
 	logs.Normal(fmt.Sprintf("Extrinsic : %v", pattern.TX_HASHRATE_MARKET_REGISTER))
 
-	latest, err := chain.Conn.RpcClient.GetLatestBlockhash(context.Background(), rpc.CommitmentFinalized)
-	if err != nil {
-		return "", fmt.Errorf("GetLatestBlockhash error: %s", err)
-	}
-
 	uuid, err := utils.ParseMachineUUID(string(hardwareInfo.MachineUUID))
 	if err != nil {
 		return "", fmt.Errorf("> ParseMachineUUID: %v", err.Error())
@@ -43,172 +37,93 @@ func (chain WrapperSuper) AddMachine(hardwareInfo machine_info.MachineInfo) (str
 
 	distri_ai.SetProgramID(chain.ProgramSuperID)
 
-	// Create Solana transaction
-	tx, err := solana.NewTransaction(
-		[]solana.Instruction{
-			distri_ai.NewAddMachineInstruction(
-				uuid,
-				string(jsonData),
-				chain.ProgramSuperMachine,
-				chain.Wallet.Wallet.PublicKey(),
-				solana.SystemProgramID,
-			).Build(),
-		},
-		latest.Value.Blockhash,
-		solana.TransactionPayer(chain.Wallet.Wallet.PublicKey()),
+	return chain.execute(
+		ctx,
+		pattern.TX_HASHRATE_MARKET_REGISTER,
+		distri_ai.NewAddMachineInstruction(
+			uuid,
+			string(jsonData),
+			chain.ProgramSuperMachine,
+			chain.Wallet.PublicKey(),
+			solana.SystemProgramID,
+		).Build(),
 	)
-
-	if err != nil {
-		return "", fmt.Errorf("> NewAddMachineInstruction: %v", err.Error())
-	}
-
-	// Sign the transaction
-	_, err = tx.Sign(
-		func(key solana.PublicKey) *solana.PrivateKey {
-			if chain.Wallet.Wallet.PublicKey().Equals(key) {
-				return &chain.Wallet.Wallet.PrivateKey
-			}
-			return nil
-		},
-	)
-	if err != nil {
-		return "", fmt.Errorf("> tx.Sign: %v", err.Error())
-	}
-
-	logs.Normal("=============== AddMachine Transaction")
-	spew.Dump(tx)
-
-	// Send and confirm the transaction
-	sig, err := chain.Conn.SendAndConfirmTransaction(tx)
-	if err != nil {
-		return "", fmt.Errorf("> SendAndConfirmTransaction: %v", err.Error())
-	}
-
-	logs.Vital(fmt.Sprintf("%s completed : %v", pattern.TX_HASHRATE_MARKET_REGISTER, sig))
-
-	return sig, nil
 }
 
-func (chain WrapperSuper) RemoveMachine() (string, error) {
+func (chain WrapperSuper) RemoveMachine(ctx context.Context) (string, error) {
 	logs.Normal(fmt.Sprintf("Extrinsic : %s", pattern.TX_HASHRATE_MARKET_REMOVE_MACHINE))
 
-	latest, err := chain.Conn.RpcClient.GetLatestBlockhash(context.Background(), rpc.CommitmentFinalized)
-	if err != nil {
-		panic(err)
-	}
-
 	distri_ai.SetProgramID(chain.ProgramSuperID)
-	// Create a new Solana transaction with a single instruction to remove a machine.
-	tx, err := solana.NewTransaction(
-		[]solana.Instruction{
-			distri_ai.NewRemoveMachineInstruction(
-				chain.ProgramSuperMachine,
-				chain.Wallet.Wallet.PublicKey(),
-			).Build(),
-		},
-		latest.Value.Blockhash,
-		solana.TransactionPayer(chain.Wallet.Wallet.PublicKey()),
+	return chain.execute(
+		ctx,
+		pattern.TX_HASHRATE_MARKET_REMOVE_MACHINE,
+		distri_ai.NewRemoveMachineInstruction(
+			chain.ProgramSuperMachine,
+			chain.Wallet.PublicKey(),
+		).Build(),
 	)
+}
 
-	if err != nil {
-		return "", fmt.Errorf("error creating transaction: %v", err)
-	}
+// MakeOffer lists the machine ForRent at the given price per hour, for at most maxDuration hours
+// and with disk GB of workspace storage.
+func (chain WrapperSuper) MakeOffer(ctx context.Context, price uint64, maxDuration uint32, disk uint32) (string, error) {
+	logs.Normal(fmt.Sprintf("Extrinsic : %v", pattern.TX_HASHRATE_MARKET_MAKE_OFFER))
 
-	// Sign the transaction with the wallet's private key if the public key matches.
-	_, err = tx.Sign(
-		func(key solana.PublicKey) *solana.PrivateKey {
-			if chain.Wallet.Wallet.PublicKey().Equals(key) {
-				return &chain.Wallet.Wallet.PrivateKey
-			}
-			return nil
-		},
+	distri_ai.SetProgramID(chain.ProgramSuperID)
+	return chain.execute(
+		ctx,
+		pattern.TX_HASHRATE_MARKET_MAKE_OFFER,
+		distri_ai.NewMakeOfferInstruction(
+			price,
+			maxDuration,
+			disk,
+			chain.ProgramSuperMachine,
+			chain.Wallet.PublicKey(),
+		).Build(),
 	)
-	if err != nil {
-		return "", fmt.Errorf("error signing transaction: %v", err)
-	}
-
-	logs.Normal("=============== RemoveMachine Transaction")
-	spew.Dump(tx)
-
-	sig, err := chain.Conn.SendAndConfirmTransaction(tx)
-	if err != nil {
-		return "", fmt.Errorf("> SendAndConfirmTransaction: %v", err.Error())
-	}
-
-	logs.Vital(fmt.Sprintf("%s completed : %v", pattern.TX_HASHRATE_MARKET_REMOVE_MACHINE, sig))
-
-	return sig, nil
 }
 
-func (chain WrapperSuper) OrderStart() (string, error) {
-	logs.Normal(fmt.Sprintf("Extrinsic : %v", pattern.TX_HASHRATE_MARKET_ORDER_START))
-
-	latest, err := chain.Conn.RpcClient.GetLatestBlockhash(context.Background(), rpc.CommitmentFinalized)
-	if err != nil {
-		return "", fmt.Errorf("GetLatestBlockhash error: %s", err)
-	}
+// CancelOffer takes the machine off the market, returning it to Idle.
+func (chain WrapperSuper) CancelOffer(ctx context.Context) (string, error) {
+	logs.Normal(fmt.Sprintf("Extrinsic : %v", pattern.TX_HASHRATE_MARKET_CANCEL_OFFER))
 
 	distri_ai.SetProgramID(chain.ProgramSuperID)
-	// Create a new Solana transaction with the StartOrderInstruction and other necessary parameters.
-	tx, err := solana.NewTransaction(
-		[]solana.Instruction{
-			distri_ai.NewStartOrderInstruction(
-				chain.ProgramSuperOrder,
-				chain.Wallet.Wallet.PublicKey(),
-			).Build(),
-		},
-		latest.Value.Blockhash,
-		solana.TransactionPayer(chain.Wallet.Wallet.PublicKey()),
+	return chain.execute(
+		ctx,
+		pattern.TX_HASHRATE_MARKET_CANCEL_OFFER,
+		distri_ai.NewCancelOfferInstruction(
+			chain.ProgramSuperMachine,
+			chain.Wallet.PublicKey(),
+		).Build(),
 	)
+}
 
-	if err != nil {
-		return "", fmt.Errorf("> solana.NewTransaction: %v", err)
-	}
+func (chain WrapperSuper) OrderStart(ctx context.Context) (string, error) {
+	logs.Normal(fmt.Sprintf("Extrinsic : %v", pattern.TX_HASHRATE_MARKET_ORDER_START))
 
-	_, err = tx.Sign(
-		func(key solana.PublicKey) *solana.PrivateKey {
-			if chain.Wallet.Wallet.PublicKey().Equals(key) {
-				return &chain.Wallet.Wallet.PrivateKey
-			}
-			return nil
-		},
+	distri_ai.SetProgramID(chain.ProgramSuperID)
+	return chain.execute(
+		ctx,
+		pattern.TX_HASHRATE_MARKET_ORDER_START,
+		distri_ai.NewStartOrderInstruction(
+			chain.ProgramSuperOrder,
+			chain.Wallet.PublicKey(),
+		).Build(),
 	)
-	if err != nil {
-		return "", fmt.Errorf("> tx.Sign: %v", err)
-	}
-
-	spew.Dump(tx)
-
-	sig, err := chain.Conn.SendAndConfirmTransaction(tx)
-	if err != nil {
-		return "", fmt.Errorf("> SendAndConfirmTransaction: %v", err.Error())
-	}
-
-	logs.Vital(fmt.Sprintf("%s completed : %v", pattern.TX_HASHRATE_MARKET_ORDER_START, sig))
-
-	return sig, nil
 }
 
-func (chain WrapperSuper) OrderCompleted(orderPlacedMetadata pattern.OrderPlacedMetadata, isGPU bool) (string, error) {
+// OrderCompleted settles the order with the score of the machine measured after it.
+func (chain WrapperSuper) OrderCompleted(ctx context.Context, orderPlacedMetadata pattern.OrderPlacedMetadata, score float64) (string, error) {
 	logs.Normal(fmt.Sprintf("Extrinsic : %v", pattern.TX_HASHRATE_MARKET_ORDER_COMPLETED))
 
-	score, err := docker.RunScoreContainer(isGPU)
-	if err != nil {
-		return "", err
-	}
 	scoreUint8 := uint8(score)
 
-	latest, err := chain.Conn.RpcClient.GetLatestBlockhash(context.Background(), rpc.CommitmentFinalized)
-	if err != nil {
-		panic(err)
-	}
-
 	jsonData, err := json.Marshal(orderPlacedMetadata)
 	if err != nil {
 		return "", fmt.Errorf("error marshaling the struct to JSON: %v", err)
 	}
 
-	seller := chain.Wallet.Wallet.PublicKey()
+	seller := chain.Wallet.PublicKey()
 	ecpc := solana.MustPublicKeyFromBase58(pattern.SNT_TOKEN_ID)
 	sellerAta, _, err := solana.FindAssociatedTokenAddress(seller, ecpc)
 	if err != nil {
@@ -225,70 +140,35 @@ func (chain WrapperSuper) OrderCompleted(orderPlacedMetadata pattern.OrderPlaced
 	}
 
 	distri_ai.SetProgramID(chain.ProgramSuperID)
-	tx, err := solana.NewTransaction(
-		[]solana.Instruction{
-			distri_ai.NewOrderCompletedInstruction(
-				string(jsonData),
-				scoreUint8,
-				chain.ProgramSuperMachine,
-				chain.ProgramSuperOrder,
-				seller,
-				sellerAta,
-				vault,
-				ecpc,
-				solana.TokenProgramID,
-				solana.SPLAssociatedTokenAccountProgramID,
-				solana.SystemProgramID,
-			).Build(),
-		},
-		latest.Value.Blockhash,
-		solana.TransactionPayer(chain.Wallet.Wallet.PublicKey()),
+	return chain.execute(
+		ctx,
+		pattern.TX_HASHRATE_MARKET_ORDER_COMPLETED,
+		distri_ai.NewOrderCompletedInstruction(
+			string(jsonData),
+			scoreUint8,
+			chain.ProgramSuperMachine,
+			chain.ProgramSuperOrder,
+			seller,
+			sellerAta,
+			vault,
+			ecpc,
+			solana.TokenProgramID,
+			solana.SPLAssociatedTokenAccountProgramID,
+			solana.SystemProgramID,
+		).Build(),
 	)
-
-	if err != nil {
-		return "", fmt.Errorf("error creating transaction: %v", err)
-	}
-
-	_, err = tx.Sign(
-		func(key solana.PublicKey) *solana.PrivateKey {
-			if chain.Wallet.Wallet.PublicKey().Equals(key) {
-				return &chain.Wallet.Wallet.PrivateKey
-			}
-			return nil
-		},
-	)
-	if err != nil {
-		return "", fmt.Errorf("error signing transaction: %v", err)
-	}
-
-	logs.Normal("=============== OrderCompleted Transaction ==================")
-	spew.Dump(tx)
-
-	sig, err := chain.Conn.SendAndConfirmTransaction(tx)
-	if err != nil {
-		return "", fmt.Errorf("> SendAndConfirmTransaction: %v", err.Error())
-	}
-
-	logs.Vital(fmt.Sprintf("%s completed : %v", pattern.TX_HASHRATE_MARKET_ORDER_COMPLETED, sig))
-
-	return sig, nil
 }
 
 // OrderFailed handles the failure of an order by processing a transaction on the blockchain.
-func (chain WrapperSuper) OrderFailed(buyer solana.PublicKey, orderPlacedMetadata pattern.OrderPlacedMetadata) (string, error) {
+func (chain WrapperSuper) OrderFailed(ctx context.Context, buyer solana.PublicKey, orderPlacedMetadata pattern.OrderPlacedMetadata) (string, error) {
 	logs.Normal(fmt.Sprintf("Extrinsic : %v", pattern.TX_HASHRATE_MARKET_ORDER_FAILED))
 
-	latest, err := chain.Conn.RpcClient.GetLatestBlockhash(context.Background(), rpc.CommitmentFinalized)
-	if err != nil {
-		panic(err)
-	}
-
 	jsonData, err := json.Marshal(orderPlacedMetadata)
 	if err != nil {
 		return "", fmt.Errorf("> json.Marshal: %v", err.Error())
 	}
 
-	seller := chain.Wallet.Wallet.PublicKey()
+	seller := chain.Wallet.PublicKey()
 	ecpc := solana.MustPublicKeyFromBase58(pattern.SNT_TOKEN_ID)
 	buyerAta, _, err := solana.FindAssociatedTokenAddress(buyer, ecpc)
 	if err != nil {
@@ -305,67 +185,41 @@ func (chain WrapperSuper) OrderFailed(buyer solana.PublicKey, orderPlacedMetadat
 	}
 
 	distri_ai.SetProgramID(chain.ProgramSuperID)
-	tx, err := solana.NewTransaction(
-		[]solana.Instruction{
-			distri_ai.NewOrderFailedInstruction(
-				string(jsonData),
-				chain.ProgramSuperMachine,
-				chain.ProgramSuperOrder,
-				seller,
-				buyerAta,
-				vault,
-				ecpc,
-				solana.TokenProgramID,
-				solana.SPLAssociatedTokenAccountProgramID,
-			).Build(),
-		},
-		latest.Value.Blockhash,
-		solana.TransactionPayer(chain.Wallet.Wallet.PublicKey()),
+	return chain.execute(
+		ctx,
+		pattern.TX_HASHRATE_MARKET_ORDER_FAILED,
+		distri_ai.NewOrderFailedInstruction(
+			string(jsonData),
+			chain.ProgramSuperMachine,
+			chain.ProgramSuperOrder,
+			seller,
+			buyerAta,
+			vault,
+			ecpc,
+			solana.TokenProgramID,
+			solana.SPLAssociatedTokenAccountProgramID,
+		).Build(),
 	)
-
-	if err != nil {
-		return "", fmt.Errorf("> NewOrderFailedInstruction: %v", err.Error())
-	}
-
-	_, err = tx.Sign(
-		func(key solana.PublicKey) *solana.PrivateKey {
-			if chain.Wallet.Wallet.PublicKey().Equals(key) {
-				return &chain.Wallet.Wallet.PrivateKey
-			}
-			return nil
-		},
-	)
-	if err != nil {
-		return "", fmt.Errorf("> tx.Sign: %v", err.Error())
-	}
-
-	spew.Dump(tx)
-
-	sig, err := chain.Conn.SendAndConfirmTransaction(tx)
-	if err != nil {
-		return "", fmt.Errorf("> SendAndConfirmTransaction: %v", err.Error())
-	}
-
-	logs.Vital(fmt.Sprintf("%s completed : %v", pattern.TX_HASHRATE_MARKET_ORDER_FAILED, sig))
-
-	return sig, nil
 }
 
-func (chain WrapperSuper) GetMachine() (distri_ai.Machine, error) {
+// GetMachine returns the Machine account, zero when it does not exist.
+func (chain WrapperSuper) GetMachine(ctx context.Context) (distri_ai.Machine, error) {
 
 	var data distri_ai.Machine
 
 	resp, err := chain.Conn.RpcClient.GetAccountInfo(
-		context.TODO(),
+		ctx,
 		chain.ProgramSuperMachine,
 	)
-	if err != nil {
+	if errors.Is(err, rpc.ErrNotFound) {
 		return data, nil
 	}
+	if err != nil {
+		return data, fmt.Errorf("> GetAccountInfo: %w", err)
+	}
 
-	borshDec := bin.NewBorshDecoder(resp.GetBinary())
-
-	err = data.UnmarshalWithDecoder(borshDec)
+	// The account is decoded in either layout, before or after its migration.
+	data, err = decodeMachine(resp.GetBinary())
 	if err != nil {
 		return data, fmt.Errorf("> UnmarshalWithDecoder: %v", err)
 	}
@@ -374,21 +228,20 @@ func (chain WrapperSuper) GetMachine() (distri_ai.Machine, error) {
 }
 
 // It returns the deserialized Order struct and an error if any occurs.
-func (chain WrapperSuper) GetOrder() (distri_ai.Order, error) {
+// The error wraps rpc.ErrNotFound when the order account does not exist.
+func (chain WrapperSuper) GetOrder(ctx context.Context) (distri_ai.Order, error) {
 
 	var data distri_ai.Order
 
 	resp, err := chain.Conn.RpcClient.GetAccountInfo(
-		context.TODO(),
+		ctx,
 		chain.ProgramSuperOrder,
 	)
 	if err != nil {
-		return data, nil
+		return data, fmt.Errorf("> GetAccountInfo: %w", err)
 	}
 
-	borshDec := bin.NewBorshDecoder(resp.GetBinary())
-
-	err = data.UnmarshalWithDecoder(borshDec)
+	data, err = decodeOrder(resp.GetBinary())
 	if err != nil {
 		return data, fmt.Errorf("error unmarshaling data: %v", err)
 	}
@@ -396,25 +249,75 @@ func (chain WrapperSuper) GetOrder() (distri_ai.Order, error) {
 	return data, nil
 }
 
+// WatchMachine pushes every decoded update of the machine account into the returned channel
+// until ctx is cancelled. Undecodable updates are logged and skipped.
+func (chain WrapperSuper) WatchMachine(ctx context.Context) <-chan distri_ai.Machine {
+	out := make(chan distri_ai.Machine)
+	raw := chain.Conn.WatchAccount(ctx, chain.ProgramSuperMachine, conn.PollInterval)
+
+	go func() {
+		defer close(out)
+		for data := range raw {
+			machine, err := decodeMachine(data)
+			if err != nil {
+				logs.Error(fmt.Sprintf("WatchMachine UnmarshalWithDecoder: %v", err))
+				continue
+			}
+			select {
+			case out <- machine:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// WatchOrder pushes every decoded update of the given order account into the returned channel
+// until ctx is cancelled. Undecodable updates are logged and skipped.
+func (chain WrapperSuper) WatchOrder(ctx context.Context, orderID solana.PublicKey) <-chan distri_ai.Order {
+	return watchOrder(ctx, chain.Conn, orderID)
+}
+
+func watchOrder(ctx context.Context, c *conn.Conn, orderID solana.PublicKey) <-chan distri_ai.Order {
+	out := make(chan distri_ai.Order)
+	raw := c.WatchAccount(ctx, orderID, conn.PollInterval)
+
+	go func() {
+		defer close(out)
+		for data := range raw {
+			order, err := decodeOrder(data)
+			if err != nil {
+				logs.Error(fmt.Sprintf("WatchOrder UnmarshalWithDecoder: %v", err))
+				continue
+			}
+			select {
+			case out <- order:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
 func (chain WrapperSuper) SubmitTask(
+	ctx context.Context,
 	taskUuid pattern.TaskUUID,
 	machineUUID pattern.MachineUUID,
 	period uint32,
 	taskMetadata pattern.TaskMetadata) (string, error) {
 	logs.Normal(fmt.Sprintf("Extrinsic : %v", pattern.TX_HASHRATE_MARKET_SUBMIT_TASK))
 
-	latest, err := chain.Conn.RpcClient.GetLatestBlockhash(context.Background(), rpc.CommitmentFinalized)
-	if err != nil {
-		return "", fmt.Errorf("GetLatestBlockhash error: %s", err)
-	}
-
 	jsonData, err := json.Marshal(taskMetadata)
 	if err != nil {
 		return "", fmt.Errorf("error marshaling the struct to JSON: %v", err)
 	}
 
 	programID := solana.MustPublicKeyFromBase58(pattern.PROGRAM_SUPER_ID)
-	seedTask := utils.GenTask(chain.Wallet.Wallet.PublicKey(), taskUuid)
+	seedTask := utils.GenTask(chain.Wallet.PublicKey(), taskUuid)
 	task, _, _ := solana.FindProgramAddress(
 		seedTask,
 		programID,
@@ -424,57 +327,61 @@ func (chain WrapperSuper) SubmitTask(
 		seedReward,
 		programID,
 	)
-	seedRewardMachine := utils.GenRewardMachine(chain.Wallet.Wallet.PublicKey(), machineUUID)
+	seedRewardMachine := utils.GenRewardMachine(chain.Wallet.PublicKey(), machineUUID)
 	rewardMachine, _, _ := solana.FindProgramAddress(
 		seedRewardMachine,
 		programID,
 	)
 
 	distri_ai.SetProgramID(chain.ProgramSuperID)
-	tx, err := solana.NewTransaction(
-		[]solana.Instruction{
-			distri_ai.NewSubmitTaskInstruction(
-				taskUuid,
-				utils.CurrentPeriod(),
-				string(jsonData),
-				chain.ProgramSuperMachine,
-				task,
-				reward,
-				rewardMachine,
-				chain.Wallet.Wallet.PublicKey(),
-				solana.SystemProgramID,
-			).Build(),
-		},
-		latest.Value.Blockhash,
-		solana.TransactionPayer(chain.Wallet.Wallet.PublicKey()),
+	return chain.execute(
+		ctx,
+		pattern.TX_HASHRATE_MARKET_SUBMIT_TASK,
+		distri_ai.NewSubmitTaskInstruction(
+			taskUuid,
+			utils.CurrentPeriod(),
+			string(jsonData),
+			chain.ProgramSuperMachine,
+			task,
+			reward,
+			rewardMachine,
+			chain.Wallet.PublicKey(),
+			solana.SystemProgramID,
+		).Build(),
 	)
+}
 
-	if err != nil {
-		return "", fmt.Errorf("error creating transaction: %v", err)
+// execute sends a transaction made of the given instructions, signed by the wallet
+// and paid for by the fee payer, or by the wallet when there is none.
+func (chain WrapperSuper) execute(ctx context.Context, name string, instructions ...solana.Instruction) (string, error) {
+	tx := conn.Tx{
+		Name:         name,
+		Instructions: instructions,
+		Payer:        chain.Wallet.PublicKey(),
 	}
-
-	_, err = tx.Sign(
-		func(key solana.PublicKey) *solana.PrivateKey {
-			if chain.Wallet.Wallet.PublicKey().Equals(key) {
-				return &chain.Wallet.Wallet.PrivateKey
-			}
-			return nil
-		},
-	)
-	if err != nil {
-		return "", fmt.Errorf("error signing transaction: %v", err)
+	if !chain.Wallet.Offline() {
+		tx.Signers = append(tx.Signers, chain.Wallet.Wallet.PrivateKey)
+	}
+	if chain.FeePayer != nil {
+		tx.Payer = chain.FeePayer.PublicKey()
+		tx.Signers = append(tx.Signers, chain.FeePayer)
+	}
+	if chain.Wallet.Offline() {
+		return chain.emit(ctx, tx)
 	}
 
-	spew.Dump(tx)
-
-	sig, err := chain.Conn.SendAndConfirmTransaction(tx)
+	result, err := chain.Conn.Execute(ctx, tx)
+	record := newAuditRecord(name, result, err)
+	record.Machine, record.Order = chain.ProgramSuperMachine.String(), chain.order(name)
+	appendAudit(record)
 	if err != nil {
-		return "", fmt.Errorf("> SendAndConfirmTransaction: %v", err.Error())
+		return "", fmt.Errorf("> Execute: %w", err)
 	}
 
-	logs.Vital(fmt.Sprintf("%s completed : %v", pattern.TX_HASHRATE_MARKET_SUBMIT_TASK, sig))
+	logs.Vital(fmt.Sprintf("%s completed : %v, slot: %v, fee: %v, compute units: %v",
+		name, result.Signature, result.Slot, result.Fee, result.ComputeUnits))
 
-	return sig, nil
+	return result.Signature.String(), nil
 }
 
 func NewSuperWrapper(info *chain.InfoChain) *WrapperSuper {
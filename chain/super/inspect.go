@@ -0,0 +1,358 @@
+package super
+
+import (
+	"SuperNet-Node/chain/conn"
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/text"
+	"github.com/gagliardetto/treeout"
+)
+
+// Inspection is the decoded content and effect of a transaction.
+type Inspection struct {
+	// Signature is zero for a transaction that was simulated rather than fetched.
+	Signature    solana.Signature
+	Slot         uint64
+	BlockTime    *solana.UnixTimeSeconds
+	Fee          uint64
+	ComputeUnits uint64
+	Err          interface{}
+	ProgramError *conn.ProgramError
+	Logs         []string
+	Transaction  *solana.Transaction
+	Balances     []BalanceChange
+	States       []StateDiff
+}
+
+// BalanceChange is the lamport balance of an account before and after the transaction.
+type BalanceChange struct {
+	Account solana.PublicKey
+	Pre     uint64
+	Post    uint64
+}
+
+// StateDiff lists the fields of a Machine or Order account changed by the transaction.
+type StateDiff struct {
+	Account solana.PublicKey
+	Kind    string
+	// Note explains how the states were obtained.
+	Note string
+	// HasBefore is false when the previous state is unknown and Changes lists the whole state.
+	HasBefore bool
+	Changes   []FieldChange
+}
+
+// FieldChange is a changed account field.
+type FieldChange struct {
+	Field  string
+	Before string
+	After  string
+}
+
+// InspectSignature fetches a landed transaction and decodes it.
+// RPC nodes do not keep the history of account data, so the Machine and Order accounts are shown
+// as they are now, which is the state after the transaction unless a later one changed them.
+func InspectSignature(ctx context.Context, c *conn.Conn, sig solana.Signature) (*Inspection, error) {
+	maxVersion := uint64(0)
+	out, err := c.RpcClient.GetTransaction(ctx, sig, &rpc.GetTransactionOpts{
+		Encoding:                       solana.EncodingBase64,
+		Commitment:                     rpc.CommitmentConfirmed,
+		MaxSupportedTransactionVersion: &maxVersion,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("> GetTransaction: %v", err)
+	}
+	transaction, err := out.Transaction.GetTransaction()
+	if err != nil {
+		return nil, fmt.Errorf("> Transaction.GetTransaction: %v", err)
+	}
+
+	inspection := &Inspection{
+		Signature:   sig,
+		Slot:        out.Slot,
+		BlockTime:   out.BlockTime,
+		Transaction: transaction,
+	}
+
+	keys := transaction.Message.AccountKeys
+	if out.Meta != nil {
+		inspection.Fee = out.Meta.Fee
+		inspection.Err = out.Meta.Err
+		inspection.Logs = out.Meta.LogMessages
+		if out.Meta.ComputeUnitsConsumed != nil {
+			inspection.ComputeUnits = *out.Meta.ComputeUnitsConsumed
+		}
+		keys = slices.Concat(keys, out.Meta.LoadedAddresses.Writable, out.Meta.LoadedAddresses.ReadOnly)
+		for i, key := range keys {
+			if i < len(out.Meta.PreBalances) && i < len(out.Meta.PostBalances) && out.Meta.PreBalances[i] != out.Meta.PostBalances[i] {
+				inspection.Balances = append(inspection.Balances, BalanceChange{key, out.Meta.PreBalances[i], out.Meta.PostBalances[i]})
+			}
+		}
+	}
+	inspection.ProgramError = conn.DecodeProgramError(inspection.Err, inspection.Logs)
+
+	current, err := getAccounts(ctx, c, keys)
+	if err != nil {
+		return nil, err
+	}
+	for i, key := range keys {
+		if current[i] == nil {
+			continue
+		}
+		kind, after, ok := decodeState(current[i].Data.GetBinary())
+		if !ok {
+			continue
+		}
+
+		later, err := c.RpcClient.GetSignaturesForAddressWithOpts(ctx, key, &rpc.GetSignaturesForAddressOpts{
+			Until:      sig,
+			Commitment: rpc.CommitmentConfirmed,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("> GetSignaturesForAddress: %v", err)
+		}
+		note := "no later transaction, this is the state after it"
+		if len(later) > 0 {
+			note = fmt.Sprintf("changed by %v later transactions, this is not the state after it", len(later))
+		}
+		inspection.States = append(inspection.States, StateDiff{
+			Account: key,
+			Kind:    kind,
+			Note:    note,
+			Changes: diffState(nil, after),
+		})
+	}
+	return inspection, nil
+}
+
+// InspectEnvelope decodes the transaction of an envelope and simulates it against the current
+// state, which gives the full state diff of the accounts it writes.
+func InspectEnvelope(ctx context.Context, c *conn.Conn, envelope *Envelope) (*Inspection, error) {
+	transaction, err := envelope.DecodeTransaction()
+	if err != nil {
+		return nil, err
+	}
+
+	var writable []solana.PublicKey
+	for _, key := range transaction.Message.AccountKeys {
+		if ok, _ := transaction.IsWritable(key); ok {
+			writable = append(writable, key)
+		}
+	}
+
+	before, err := getAccounts(ctx, c, writable)
+	if err != nil {
+		return nil, err
+	}
+	sim, err := c.RpcClient.SimulateTransactionWithOpts(ctx, transaction, &rpc.SimulateTransactionOpts{
+		Commitment: rpc.CommitmentConfirmed,
+		Accounts: &rpc.SimulateTransactionAccountsOpts{
+			Encoding:  solana.EncodingBase64,
+			Addresses: writable,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("> SimulateTransaction: %v", err)
+	}
+
+	inspection := &Inspection{
+		Slot:         sim.Context.Slot,
+		Err:          sim.Value.Err,
+		ProgramError: conn.DecodeProgramError(sim.Value.Err, sim.Value.Logs),
+		Logs:         sim.Value.Logs,
+		Transaction:  transaction,
+	}
+	if sim.Value.UnitsConsumed != nil {
+		inspection.ComputeUnits = *sim.Value.UnitsConsumed
+	}
+	// A failed simulation does not return the accounts.
+	if sim.Value.Err != nil || len(sim.Value.Accounts) != len(writable) {
+		return inspection, nil
+	}
+
+	for i, key := range writable {
+		var pre, post uint64
+		var preData, postData []byte
+		if before[i] != nil {
+			pre, preData = before[i].Lamports, before[i].Data.GetBinary()
+		}
+		if after := sim.Value.Accounts[i]; after != nil {
+			post, postData = after.Lamports, after.Data.GetBinary()
+		}
+		if pre != post {
+			inspection.Balances = append(inspection.Balances, BalanceChange{key, pre, post})
+		}
+
+		kindBefore, stateBefore, okBefore := decodeState(preData)
+		kindAfter, stateAfter, okAfter := decodeState(postData)
+		switch {
+		case okBefore && okAfter:
+			if changes := diffState(stateBefore, stateAfter); len(changes) > 0 {
+				inspection.States = append(inspection.States, StateDiff{key, kindAfter, "simulated", true, changes})
+			}
+		case okAfter:
+			inspection.States = append(inspection.States, StateDiff{key, kindAfter, "created", false, diffState(nil, stateAfter)})
+		case okBefore:
+			inspection.States = append(inspection.States, StateDiff{key, kindBefore, "closed", true, nil})
+		}
+	}
+	return inspection, nil
+}
+
+// getAccounts fetches accounts in batches of the size accepted by getMultipleAccounts;
+// missing accounts are nil.
+func getAccounts(ctx context.Context, c *conn.Conn, keys []solana.PublicKey) ([]*rpc.Account, error) {
+	accounts := make([]*rpc.Account, 0, len(keys))
+	for start := 0; start < len(keys); start += 100 {
+		resp, err := c.RpcClient.GetMultipleAccounts(ctx, keys[start:min(start+100, len(keys))]...)
+		if err != nil {
+			return nil, fmt.Errorf("> GetMultipleAccounts: %v", err)
+		}
+		accounts = append(accounts, resp.Value...)
+	}
+	return accounts, nil
+}
+
+// decodeState decodes the data of a Machine or Order account, in either layout.
+func decodeState(data []byte) (string, interface{}, bool) {
+	switch layout := AccountLayout(data); layout {
+	case LayoutMachine, LayoutMachineNew:
+		if machine, err := decodeMachine(data); err == nil {
+			return layout, machine, true
+		}
+	case LayoutOrder, LayoutOrderNew:
+		if order, err := decodeOrder(data); err == nil {
+			return layout, order, true
+		}
+	}
+	return "", nil, false
+}
+
+// diffState compares two states of the same account type field by field.
+// With no previous state every field of after is listed.
+func diffState(before, after interface{}) []FieldChange {
+	afterValue := reflect.ValueOf(after)
+	var beforeValue reflect.Value
+	if before != nil {
+		beforeValue = reflect.ValueOf(before)
+	}
+
+	var changes []FieldChange
+	for i := 0; i < afterValue.NumField(); i++ {
+		change := FieldChange{
+			Field: afterValue.Type().Field(i).Name,
+			After: fmt.Sprint(afterValue.Field(i).Interface()),
+		}
+		if beforeValue.IsValid() {
+			change.Before = fmt.Sprint(beforeValue.Field(i).Interface())
+			if change.Before == change.After {
+				continue
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// String renders the inspection as a tree, with the instructions decoded by the registered programs.
+func (in *Inspection) String() string {
+	title := "Simulated transaction"
+	if !in.Signature.IsZero() {
+		title = fmt.Sprintf("Transaction %v", in.Signature)
+	}
+	tree := treeout.New(title)
+
+	status := "success"
+	if in.Err != nil {
+		status = fmt.Sprintf("failed: %v", in.Err)
+	}
+	tree.Child(fmt.Sprintf("Status: %v", status))
+	if in.ProgramError != nil {
+		tree.Child(fmt.Sprintf("Error: %v", in.ProgramError))
+	}
+	tree.Child(fmt.Sprintf("Slot: %v", in.Slot))
+	if in.BlockTime != nil {
+		tree.Child(fmt.Sprintf("Time: %v", in.BlockTime.Time().Format(time.RFC3339)))
+	}
+	if !in.Signature.IsZero() {
+		tree.Child(fmt.Sprintf("Fee: %v lamports", in.Fee))
+	}
+	tree.Child(fmt.Sprintf("Compute units: %v", in.ComputeUnits))
+
+	message := in.Transaction.Message
+	tree.Child(fmt.Sprintf("Instructions[len=%v]", len(message.Instructions))).ParentFunc(func(branch treeout.Branches) {
+		for i, instruction := range message.Instructions {
+			label := fmt.Sprintf("#%v", i)
+			if in.ProgramError != nil && in.ProgramError.Instruction == i {
+				label += " (failed)"
+			}
+			branch.Child(label).ParentFunc(func(instructionBranch treeout.Branches) {
+				encodeInstruction(instructionBranch, message, instruction)
+			})
+		}
+	})
+
+	if len(in.Balances) > 0 {
+		tree.Child("Balances").ParentFunc(func(branch treeout.Branches) {
+			for _, balance := range in.Balances {
+				branch.Child(fmt.Sprintf("%v: %v -> %v (%+d lamports)",
+					balance.Account, balance.Pre, balance.Post, int64(balance.Post)-int64(balance.Pre)))
+			}
+		})
+	}
+
+	if len(in.States) > 0 {
+		title := "State"
+		if !in.Signature.IsZero() {
+			title = "State (no before/after diff for a landed transaction, the accounts are shown as they are now; " +
+				"inspect the outbox file with --in before submitting it for the diff)"
+		}
+		tree.Child(title).ParentFunc(func(branch treeout.Branches) {
+			for _, state := range in.States {
+				branch.Child(fmt.Sprintf("%v %v (%v)", state.Kind, state.Account, state.Note)).ParentFunc(func(stateBranch treeout.Branches) {
+					for _, change := range state.Changes {
+						if !state.HasBefore {
+							stateBranch.Child(fmt.Sprintf("%v: %v", change.Field, change.After))
+						} else {
+							stateBranch.Child(fmt.Sprintf("%v: %v -> %v", change.Field, change.Before, change.After))
+						}
+					}
+				})
+			}
+		})
+	}
+
+	if len(in.Logs) > 0 {
+		tree.Child("Logs").ParentFunc(func(branch treeout.Branches) {
+			for _, line := range in.Logs {
+				branch.Child(line)
+			}
+		})
+	}
+	return tree.String()
+}
+
+// encodeInstruction decodes an instruction with the decoder registered for its program.
+func encodeInstruction(branch treeout.Branches, message solana.Message, instruction solana.CompiledInstruction) {
+	programID, err := message.ResolveProgramIDIndex(instruction.ProgramIDIndex)
+	if err != nil {
+		branch.Child(fmt.Sprintf("unknown program: %v", err))
+		return
+	}
+	accounts, err := instruction.ResolveInstructionAccounts(&message)
+	if err == nil {
+		decoded, decodeErr := solana.DecodeInstruction(programID, accounts, instruction.Data)
+		if encodable, ok := decoded.(text.EncodableToTree); ok && decodeErr == nil {
+			encodable.EncodeToTree(branch)
+			return
+		}
+	}
+	branch.Child(fmt.Sprintf("Program: %v", programID))
+	branch.Child(fmt.Sprintf("Data: %x", []byte(instruction.Data)))
+}
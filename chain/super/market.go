@@ -0,0 +1,195 @@
+package super
+
+import (
+	"SuperNet-Node/chain/conn"
+	"SuperNet-Node/chain/super/distri_ai"
+	"SuperNet-Node/machine_info"
+	"SuperNet-Node/pattern"
+	logs "SuperNet-Node/utils/log_utils"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Offsets of the fixed fields used by memcmp filters. The status of both accounts follows
+// the variable length metadata, so it is filtered after decoding.
+const (
+	// Machine layout: discriminator(8) | owner(32) | uuid(16) | metadata | status | ...
+	machineOwnerOffset = 8
+	machineUUIDOffset  = 40
+	// Order layout: discriminator(8) | orderId(16) | buyer(32) | seller(32) | machineId(16) | ...
+	orderBuyerOffset     = 24
+	orderSellerOffset    = 56
+	orderMachineIDOffset = 88
+)
+
+// MarketMachine is a Machine account with its hardware metadata decoded.
+type MarketMachine struct {
+	Pubkey solana.PublicKey
+	distri_ai.Machine
+	// Info is zero when the metadata is not valid hardware information.
+	Info machine_info.MachineInfo
+}
+
+// MarketOrder is an Order account.
+type MarketOrder struct {
+	Pubkey solana.PublicKey
+	distri_ai.Order
+}
+
+// MachineFilter selects Machine accounts; zero fields match everything.
+type MachineFilter struct {
+	Owner  solana.PublicKey
+	UUID   *pattern.MachineUUID
+	Status *distri_ai.MachineStatus
+}
+
+// OrderFilter selects Order accounts; zero fields match everything.
+type OrderFilter struct {
+	Buyer     solana.PublicKey
+	Seller    solana.PublicKey
+	MachineID *pattern.MachineUUID
+	Status    *distri_ai.OrderStatus
+}
+
+// GetMarketMachines returns the Machine accounts of the program matching filter.
+func GetMarketMachines(ctx context.Context, c *conn.Conn, programID solana.PublicKey, filter MachineFilter) ([]MarketMachine, error) {
+	filters := []rpc.RPCFilter{memcmp(0, distri_ai.MachineDiscriminator[:])}
+	if !filter.Owner.IsZero() {
+		filters = append(filters, memcmp(machineOwnerOffset, filter.Owner.Bytes()))
+	}
+	if filter.UUID != nil {
+		filters = append(filters, memcmp(machineUUIDOffset, filter.UUID[:]))
+	}
+
+	out, err := getProgramAccounts(ctx, c, programID, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	machines := make([]MarketMachine, 0, len(out))
+	for _, account := range out {
+		machine := MarketMachine{Pubkey: account.Pubkey}
+		if err := machine.UnmarshalWithDecoder(bin.NewBorshDecoder(account.Account.Data.GetBinary())); err != nil {
+			logs.Warning(fmt.Sprintf("Machine %v: %v", account.Pubkey, err))
+			continue
+		}
+		if filter.Status != nil && machine.Status != *filter.Status {
+			continue
+		}
+		// Machines registered by other clients may carry other metadata.
+		_ = json.Unmarshal([]byte(machine.Metadata), &machine.Info)
+		machines = append(machines, machine)
+	}
+	return machines, nil
+}
+
+// GetMarketOrders returns the Order accounts of the program matching filter.
+func GetMarketOrders(ctx context.Context, c *conn.Conn, programID solana.PublicKey, filter OrderFilter) ([]MarketOrder, error) {
+	filters := []rpc.RPCFilter{memcmp(0, distri_ai.OrderDiscriminator[:])}
+	if !filter.Buyer.IsZero() {
+		filters = append(filters, memcmp(orderBuyerOffset, filter.Buyer.Bytes()))
+	}
+	if !filter.Seller.IsZero() {
+		filters = append(filters, memcmp(orderSellerOffset, filter.Seller.Bytes()))
+	}
+	if filter.MachineID != nil {
+		filters = append(filters, memcmp(orderMachineIDOffset, filter.MachineID[:]))
+	}
+
+	out, err := getProgramAccounts(ctx, c, programID, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make([]MarketOrder, 0, len(out))
+	for _, account := range out {
+		order := MarketOrder{Pubkey: account.Pubkey}
+		if err := order.UnmarshalWithDecoder(bin.NewBorshDecoder(account.Account.Data.GetBinary())); err != nil {
+			logs.Warning(fmt.Sprintf("Order %v: %v", account.Pubkey, err))
+			continue
+		}
+		if filter.Status != nil && order.Status != *filter.Status {
+			continue
+		}
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+// GetMarketMachine returns the Machine account at pubkey.
+func GetMarketMachine(ctx context.Context, c *conn.Conn, pubkey solana.PublicKey) (MarketMachine, error) {
+	machine := MarketMachine{Pubkey: pubkey}
+	resp, err := c.RpcClient.GetAccountInfo(ctx, pubkey)
+	if err != nil {
+		return machine, fmt.Errorf("> GetAccountInfo: %w", err)
+	}
+	if machine.Machine, err = decodeMachine(resp.GetBinary()); err != nil {
+		return machine, fmt.Errorf("> UnmarshalWithDecoder: %v", err)
+	}
+	_ = json.Unmarshal([]byte(machine.Metadata), &machine.Info)
+	return machine, nil
+}
+
+// GetMarketOrder returns the Order account at pubkey.
+func GetMarketOrder(ctx context.Context, c *conn.Conn, pubkey solana.PublicKey) (MarketOrder, error) {
+	order := MarketOrder{Pubkey: pubkey}
+	resp, err := c.RpcClient.GetAccountInfo(ctx, pubkey)
+	if err != nil {
+		return order, fmt.Errorf("> GetAccountInfo: %w", err)
+	}
+	if order.Order, err = decodeOrder(resp.GetBinary()); err != nil {
+		return order, fmt.Errorf("> UnmarshalWithDecoder: %v", err)
+	}
+	return order, nil
+}
+
+func getProgramAccounts(ctx context.Context, c *conn.Conn, programID solana.PublicKey, filters []rpc.RPCFilter) (rpc.GetProgramAccountsResult, error) {
+	out, err := c.RpcClient.GetProgramAccountsWithOpts(
+		ctx,
+		programID,
+		&rpc.GetProgramAccountsOpts{Filters: filters},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("> GetProgramAccountsWithOpts: %v", err)
+	}
+	return out, nil
+}
+
+func memcmp(offset uint64, bytes []byte) rpc.RPCFilter {
+	return rpc.RPCFilter{Memcmp: &rpc.RPCFilterMemcmp{Offset: offset, Bytes: bytes}}
+}
+
+// ParseMachineStatus parses the name of a machine status, e.g. "ForRent".
+func ParseMachineStatus(s string) (distri_ai.MachineStatus, error) {
+	for _, status := range []distri_ai.MachineStatus{
+		distri_ai.MachineStatusIdle,
+		distri_ai.MachineStatusForRent,
+		distri_ai.MachineStatusRenting,
+	} {
+		if status.String() == s {
+			return status, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown machine status: %v", s)
+}
+
+// ParseOrderStatus parses the name of an order status, e.g. "Training".
+func ParseOrderStatus(s string) (distri_ai.OrderStatus, error) {
+	for _, status := range []distri_ai.OrderStatus{
+		distri_ai.OrderStatusPreparing,
+		distri_ai.OrderStatusTraining,
+		distri_ai.OrderStatusCompleted,
+		distri_ai.OrderStatusFailed,
+		distri_ai.OrderStatusRefunded,
+	} {
+		if status.String() == s {
+			return status, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown order status: %v", s)
+}
@@ -0,0 +1,230 @@
+package super
+
+import (
+	"SuperNet-Node/chain/super/distri_ai"
+	"SuperNet-Node/pattern"
+	"SuperNet-Node/utils"
+	logs "SuperNet-Node/utils/log_utils"
+	"bytes"
+	"context"
+	"fmt"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Account layouts, named after their account types.
+const (
+	LayoutMachine    = "Machine"
+	LayoutMachineNew = "MachineNew"
+	LayoutOrder      = "Order"
+	LayoutOrderNew   = "OrderNew"
+)
+
+// AccountLayout returns the layout of account data by its discriminator, "" when it is none of the above.
+func AccountLayout(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, distri_ai.MachineDiscriminator[:]):
+		return LayoutMachine
+	case bytes.HasPrefix(data, distri_ai.MachineNewDiscriminator[:]):
+		return LayoutMachineNew
+	case bytes.HasPrefix(data, distri_ai.OrderDiscriminator[:]):
+		return LayoutOrder
+	case bytes.HasPrefix(data, distri_ai.OrderNewDiscriminator[:]):
+		return LayoutOrderNew
+	}
+	return ""
+}
+
+// decodeMachine decodes a machine account in either layout.
+func decodeMachine(data []byte) (distri_ai.Machine, error) {
+	if AccountLayout(data) == LayoutMachineNew {
+		var machine distri_ai.MachineNew
+		err := machine.UnmarshalWithDecoder(bin.NewBorshDecoder(data))
+		return distri_ai.Machine(machine), err
+	}
+	var machine distri_ai.Machine
+	err := machine.UnmarshalWithDecoder(bin.NewBorshDecoder(data))
+	return machine, err
+}
+
+// decodeOrder decodes an order account in either layout.
+func decodeOrder(data []byte) (distri_ai.Order, error) {
+	if AccountLayout(data) == LayoutOrderNew {
+		var order distri_ai.OrderNew
+		err := order.UnmarshalWithDecoder(bin.NewBorshDecoder(data))
+		return distri_ai.Order(order), err
+	}
+	var order distri_ai.Order
+	err := order.UnmarshalWithDecoder(bin.NewBorshDecoder(data))
+	return order, err
+}
+
+// MigrationStep is one transaction of a migration.
+type MigrationStep struct {
+	Name        string
+	From        solana.PublicKey
+	To          solana.PublicKey
+	Instruction solana.Instruction
+}
+
+// MigrationPlan lists the steps left to migrate an account. The migration copies the account
+// into the new layout at an intermediate address, then renames it back to its address.
+type MigrationPlan struct {
+	Kind    string
+	Account solana.PublicKey
+	// Layout is the layout found at Account, "" when the account only exists at the intermediate address.
+	Layout string
+	Steps  []MigrationStep
+}
+
+func (plan MigrationPlan) String() string {
+	if len(plan.Steps) == 0 {
+		return fmt.Sprintf("%v %v: %v, up to date", plan.Kind, plan.Account, plan.Layout)
+	}
+	s := fmt.Sprintf("%v %v: %v, %v step(s)", plan.Kind, plan.Account, plan.Layout, len(plan.Steps))
+	for _, step := range plan.Steps {
+		s += fmt.Sprintf("\n  %v: %v -> %v", step.Name, step.From, step.To)
+	}
+	return s
+}
+
+// MachineMigration returns the migration plan of the machine account.
+func (chain WrapperSuper) MachineMigration(ctx context.Context) (MigrationPlan, error) {
+	uuid, err := utils.ParseMachineUUID(string(chain.MachineUUID))
+	if err != nil {
+		return MigrationPlan{}, fmt.Errorf("> ParseMachineUUID: %v", err)
+	}
+	owner := chain.Wallet.PublicKey()
+	intermediate, _, err := solana.FindProgramAddress(utils.GenMachineNew(owner, uuid), chain.ProgramSuperID)
+	if err != nil {
+		return MigrationPlan{}, fmt.Errorf("> FindProgramAddress: %v", err)
+	}
+
+	accounts, err := getAccounts(ctx, chain.Conn, []solana.PublicKey{chain.ProgramSuperMachine, intermediate})
+	if err != nil {
+		return MigrationPlan{}, err
+	}
+	if accounts[0] == nil && accounts[1] == nil {
+		return MigrationPlan{}, fmt.Errorf("machine %v does not exist", chain.ProgramSuperMachine)
+	}
+
+	distri_ai.SetProgramID(chain.ProgramSuperID)
+	newStep := MigrationStep{
+		Name: pattern.TX_HASHRATE_MARKET_MIGRATE_MACHINE_NEW,
+		From: chain.ProgramSuperMachine,
+		To:   intermediate,
+		Instruction: distri_ai.NewMigrateMachineNewInstruction(
+			chain.ProgramSuperMachine, intermediate, owner, solana.SystemProgramID).Build(),
+	}
+	renameStep := MigrationStep{
+		Name: pattern.TX_HASHRATE_MARKET_MIGRATE_MACHINE_RENAME,
+		From: intermediate,
+		To:   chain.ProgramSuperMachine,
+		Instruction: distri_ai.NewMigrateMachineRenameInstruction(
+			intermediate, chain.ProgramSuperMachine, owner, solana.SystemProgramID).Build(),
+	}
+	return migrationPlan("Machine", chain.ProgramSuperMachine, accounts[0], accounts[1], newStep, renameStep,
+		func(data []byte) error { _, err := decodeMachine(data); return err }), nil
+}
+
+// OrderMigrations returns the migration plans of the orders placed on the machine that need one.
+func (chain WrapperSuper) OrderMigrations(ctx context.Context) ([]MigrationPlan, error) {
+	uuid, err := utils.ParseMachineUUID(string(chain.MachineUUID))
+	if err != nil {
+		return nil, fmt.Errorf("> ParseMachineUUID: %v", err)
+	}
+	owner := chain.Wallet.PublicKey()
+
+	// Raw accounts, since an order in the old layout may not decode.
+	type orderKey struct {
+		buyer solana.PublicKey
+		id    [16]byte
+	}
+	found := map[orderKey]bool{}
+	var keys []orderKey
+	for _, discriminator := range [][]byte{distri_ai.OrderDiscriminator[:], distri_ai.OrderNewDiscriminator[:]} {
+		out, err := getProgramAccounts(ctx, chain.Conn, chain.ProgramSuperID, []rpc.RPCFilter{
+			memcmp(0, discriminator),
+			memcmp(orderSellerOffset, owner.Bytes()),
+			memcmp(orderMachineIDOffset, uuid[:]),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, account := range out {
+			data := account.Account.Data.GetBinary()
+			if len(data) < orderSellerOffset {
+				continue
+			}
+			var key orderKey
+			copy(key.id[:], data[8:orderBuyerOffset])
+			key.buyer = solana.PublicKeyFromBytes(data[orderBuyerOffset:orderSellerOffset])
+			if !found[key] {
+				found[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	distri_ai.SetProgramID(chain.ProgramSuperID)
+	var plans []MigrationPlan
+	for _, key := range keys {
+		order, _, err := solana.FindProgramAddress(utils.GenOrder(key.buyer, key.id), chain.ProgramSuperID)
+		if err != nil {
+			return nil, fmt.Errorf("> FindProgramAddress: %v", err)
+		}
+		intermediate, _, err := solana.FindProgramAddress(utils.GenOrderNew(key.buyer, key.id), chain.ProgramSuperID)
+		if err != nil {
+			return nil, fmt.Errorf("> FindProgramAddress: %v", err)
+		}
+		accounts, err := getAccounts(ctx, chain.Conn, []solana.PublicKey{order, intermediate})
+		if err != nil {
+			return nil, err
+		}
+
+		newStep := MigrationStep{
+			Name:        pattern.TX_HASHRATE_MARKET_MIGRATE_ORDER_NEW,
+			From:        order,
+			To:          intermediate,
+			Instruction: distri_ai.NewMigrateOrderNewInstruction(order, intermediate, owner, solana.SystemProgramID).Build(),
+		}
+		renameStep := MigrationStep{
+			Name:        pattern.TX_HASHRATE_MARKET_MIGRATE_ORDER_RENAME,
+			From:        intermediate,
+			To:          order,
+			Instruction: distri_ai.NewMigrateOrderRenameInstruction(intermediate, order, owner, solana.SystemProgramID).Build(),
+		}
+		plan := migrationPlan("Order", order, accounts[0], accounts[1], newStep, renameStep,
+			func(data []byte) error { _, err := decodeOrder(data); return err })
+		if len(plan.Steps) > 0 {
+			plans = append(plans, plan)
+		}
+	}
+	return plans, nil
+}
+
+// migrationPlan returns the steps left given the accounts at the address and at the intermediate address.
+// An account that decodes in the current layout is up to date.
+func migrationPlan(kind string, address solana.PublicKey, current, intermediate *rpc.Account,
+	newStep, renameStep MigrationStep, decode func(data []byte) error) MigrationPlan {
+	plan := MigrationPlan{Kind: kind, Account: address}
+	if current != nil {
+		plan.Layout = AccountLayout(current.Data.GetBinary())
+	}
+	switch {
+	case intermediate != nil:
+		// Interrupted after the first step.
+		plan.Steps = []MigrationStep{renameStep}
+	case current != nil && decode(current.Data.GetBinary()) != nil:
+		plan.Steps = []MigrationStep{newStep, renameStep}
+	}
+	return plan
+}
+
+// Migrate sends a step of a migration, signed by the owner.
+func (chain WrapperSuper) Migrate(ctx context.Context, step MigrationStep) (string, error) {
+	logs.Normal(fmt.Sprintf("Extrinsic : %v", step.Name))
+	return chain.execute(ctx, step.Name, step.Instruction)
+}
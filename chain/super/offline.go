@@ -0,0 +1,205 @@
+package super
+
+import (
+	"SuperNet-Node/chain/conn"
+	"SuperNet-Node/pattern"
+	logs "SuperNet-Node/utils/log_utils"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+// ErrAwaitingSignature is returned for an owner transaction that was emitted unsigned in
+// offline mode; the order flow continues once the signed transaction has been submitted.
+var ErrAwaitingSignature = errors.New("transaction awaiting offline signature")
+
+// offlineTx are the owner transactions that can be signed offline.
+var offlineTx = map[string]bool{
+	pattern.TX_HASHRATE_MARKET_REGISTER:        true,
+	pattern.TX_HASHRATE_MARKET_ORDER_START:     true,
+	pattern.TX_HASHRATE_MARKET_ORDER_COMPLETED: true,
+	pattern.TX_HASHRATE_MARKET_ORDER_FAILED:    true,
+	pattern.TX_HASHRATE_MARKET_REMOVE_MACHINE:  true,
+	pattern.TX_HASHRATE_MARKET_MAKE_OFFER:      true,
+	pattern.TX_HASHRATE_MARKET_CANCEL_OFFER:    true,
+
+	pattern.TX_HASHRATE_MARKET_MIGRATE_MACHINE_NEW:    true,
+	pattern.TX_HASHRATE_MARKET_MIGRATE_MACHINE_RENAME: true,
+	pattern.TX_HASHRATE_MARKET_MIGRATE_ORDER_NEW:      true,
+	pattern.TX_HASHRATE_MARKET_MIGRATE_ORDER_RENAME:   true,
+}
+
+// Envelope carries a durable nonce transaction between the node and the offline signer.
+type Envelope struct {
+	Name         string    `json:"name"`
+	CreatedAt    time.Time `json:"createdAt"`
+	Owner        string    `json:"owner"`
+	Machine      string    `json:"machine"`
+	Order        string    `json:"order,omitempty"`
+	NonceAccount string    `json:"nonceAccount"`
+	// Transaction is the base64 encoded wire transaction.
+	Transaction string `json:"transaction"`
+	// Missing lists the signers that still have to sign the transaction.
+	Missing []string `json:"missing"`
+}
+
+// ReadEnvelope reads an envelope from a JSON file.
+func ReadEnvelope(path string) (*Envelope, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var envelope Envelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("> json.Unmarshal: %v", err)
+	}
+	return &envelope, nil
+}
+
+// Save writes the envelope to path, replacing it atomically.
+func (e *Envelope) Save(path string) error {
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// DecodeTransaction decodes the transaction carried by the envelope.
+func (e *Envelope) DecodeTransaction() (*solana.Transaction, error) {
+	data, err := base64.StdEncoding.DecodeString(e.Transaction)
+	if err != nil {
+		return nil, fmt.Errorf("> base64.DecodeString: %v", err)
+	}
+	transaction, err := solana.TransactionFromDecoder(bin.NewBinDecoder(data))
+	if err != nil {
+		return nil, fmt.Errorf("> TransactionFromDecoder: %v", err)
+	}
+	return transaction, nil
+}
+
+// SetTransaction stores transaction in the envelope together with its missing signers.
+func (e *Envelope) SetTransaction(transaction *solana.Transaction, missing []solana.PublicKey) error {
+	encoded, err := transaction.ToBase64()
+	if err != nil {
+		return fmt.Errorf("> ToBase64: %v", err)
+	}
+	e.Transaction = encoded
+	e.Missing = e.Missing[:0]
+	for _, signer := range missing {
+		e.Missing = append(e.Missing, signer.String())
+	}
+	return nil
+}
+
+// emit builds an owner transaction on the durable nonce account, signs it with the fee payer
+// when there is one and hands it to the offline signer through the outbox.
+func (chain WrapperSuper) emit(ctx context.Context, tx conn.Tx) (string, error) {
+	if !offlineTx[tx.Name] {
+		return "", fmt.Errorf("%v needs the owner key, which is offline", tx.Name)
+	}
+	nonceAccount, err := solana.PublicKeyFromBase58(chain.Offline.NonceAccount)
+	if err != nil {
+		return "", fmt.Errorf("> PublicKeyFromBase58 offline.nonceAccount: %v", err)
+	}
+
+	transaction, missing, err := chain.Conn.BuildOnNonce(ctx, tx, nonceAccount)
+	if err != nil {
+		return "", fmt.Errorf("> BuildOnNonce: %v", err)
+	}
+
+	envelope := &Envelope{
+		Name:         tx.Name,
+		CreatedAt:    time.Now(),
+		Owner:        chain.Wallet.PublicKey().String(),
+		Machine:      chain.ProgramSuperMachine.String(),
+		Order:        chain.order(tx.Name),
+		NonceAccount: nonceAccount.String(),
+	}
+	if err := envelope.SetTransaction(transaction, missing); err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(chain.Offline.Outbox, 0755); err != nil {
+		return "", fmt.Errorf("> MkdirAll: %v", err)
+	}
+	path := filepath.Join(chain.Offline.Outbox, fmt.Sprintf("%v-%v.json", envelope.CreatedAt.UnixNano(), tx.Name))
+	if err := envelope.Save(path); err != nil {
+		return "", fmt.Errorf("> Save: %v", err)
+	}
+
+	if chain.Offline.OutboxURL != "" {
+		if err := postEnvelope(chain.Offline.OutboxURL, envelope); err != nil {
+			// The file in the outbox is still there to be signed by hand.
+			logs.Warning(fmt.Sprintf("Post %v to outbox url: %v", tx.Name, err))
+		}
+	}
+
+	appendAudit(AuditRecord{
+		Time:    time.Now(),
+		Name:    tx.Name,
+		Machine: envelope.Machine,
+		Order:   envelope.Order,
+		Status:  AuditAwaitingSignature,
+	})
+	logs.Vital(fmt.Sprintf("%s waiting for the owner signature : %v", tx.Name, path))
+	return "", fmt.Errorf("%w: %v", ErrAwaitingSignature, path)
+}
+
+func postEnvelope(url string, envelope *Envelope) error {
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("status %v", resp.Status)
+	}
+	return nil
+}
+
+// SubmitEnvelope broadcasts the signed transaction of an envelope and waits for its confirmation.
+func SubmitEnvelope(ctx context.Context, c *conn.Conn, envelope *Envelope) (string, error) {
+	logs.Normal(fmt.Sprintf("Extrinsic : %v", envelope.Name))
+
+	transaction, err := envelope.DecodeTransaction()
+	if err != nil {
+		return "", err
+	}
+
+	result, err := c.Submit(ctx, envelope.Name, transaction)
+	record := newAuditRecord(envelope.Name, result, err)
+	record.Machine, record.Order = envelope.Machine, envelope.Order
+	if record.Signature == "" && len(transaction.Signatures) > 0 {
+		// The signature of a signed transaction is known even when it never landed.
+		record.Signature = transaction.Signatures[0].String()
+	}
+	appendAudit(record)
+	if err != nil {
+		return "", fmt.Errorf("> Submit: %w", err)
+	}
+
+	logs.Vital(fmt.Sprintf("%s completed : %v, slot: %v, fee: %v, compute units: %v",
+		envelope.Name, result.Signature, result.Slot, result.Fee, result.ComputeUnits))
+
+	return result.Signature.String(), nil
+}
@@ -0,0 +1,194 @@
+package super
+
+import (
+	"SuperNet-Node/chain/super/distri_ai"
+	"SuperNet-Node/pattern"
+	"SuperNet-Node/utils"
+	logs "SuperNet-Node/utils/log_utils"
+	"context"
+	"fmt"
+	"sort"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Claim transfers the periodic and task rewards earned by the machine in the given period to the owner.
+func (chain WrapperSuper) Claim(ctx context.Context, period uint32) (string, error) {
+	logs.Normal(fmt.Sprintf("Extrinsic : %v, period: %v", pattern.TX_HASHRATE_MARKET_CLAIM, period))
+
+	reward, err := chain.RewardAccount(period)
+	if err != nil {
+		return "", err
+	}
+	rewardMachine, err := chain.RewardMachineAccount(period)
+	if err != nil {
+		return "", err
+	}
+	rewardPool, _, err := solana.FindProgramAddress(
+		utils.GenRewardPool(),
+		chain.ProgramSuperID,
+	)
+	if err != nil {
+		return "", fmt.Errorf("> FindProgramAddress: %v", err)
+	}
+
+	owner := chain.Wallet.PublicKey()
+	ecpc := solana.MustPublicKeyFromBase58(pattern.SNT_TOKEN_ID)
+	ownerAta, _, err := solana.FindAssociatedTokenAddress(owner, ecpc)
+	if err != nil {
+		return "", fmt.Errorf("> FindAssociatedTokenAddress: %v", err)
+	}
+
+	distri_ai.SetProgramID(chain.ProgramSuperID)
+	return chain.execute(
+		ctx,
+		pattern.TX_HASHRATE_MARKET_CLAIM,
+		distri_ai.NewClaimInstruction(
+			period,
+			chain.ProgramSuperMachine,
+			reward,
+			rewardMachine,
+			owner,
+			ownerAta,
+			rewardPool,
+			ecpc,
+			solana.TokenProgramID,
+			solana.SPLAssociatedTokenAccountProgramID,
+			solana.SystemProgramID,
+		).Build(),
+	)
+}
+
+// RewardAccount returns the address of the Reward account of a period.
+func (chain WrapperSuper) RewardAccount(period uint32) (solana.PublicKey, error) {
+	reward, _, err := solana.FindProgramAddress(
+		utils.GenRewardByPeriod(period),
+		chain.ProgramSuperID,
+	)
+	if err != nil {
+		return reward, fmt.Errorf("> FindProgramAddress: %v", err)
+	}
+	return reward, nil
+}
+
+// RewardMachineAccount returns the address of the RewardMachine account of this machine for a period.
+func (chain WrapperSuper) RewardMachineAccount(period uint32) (solana.PublicKey, error) {
+	machineUUID, err := utils.ParseMachineUUID(string(chain.MachineUUID))
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("> ParseMachineUUID: %v", err)
+	}
+	rewardMachine, _, err := solana.FindProgramAddress(
+		utils.GenRewardMachineByPeriod(period, chain.Wallet.PublicKey(), machineUUID),
+		chain.ProgramSuperID,
+	)
+	if err != nil {
+		return rewardMachine, fmt.Errorf("> FindProgramAddress: %v", err)
+	}
+	return rewardMachine, nil
+}
+
+// GetReward returns the Reward account of a period.
+// The error wraps rpc.ErrNotFound when nothing was rewarded in that period.
+func (chain WrapperSuper) GetReward(ctx context.Context, period uint32) (distri_ai.Reward, error) {
+	var data distri_ai.Reward
+
+	reward, err := chain.RewardAccount(period)
+	if err != nil {
+		return data, err
+	}
+
+	resp, err := chain.Conn.RpcClient.GetAccountInfo(ctx, reward)
+	if err != nil {
+		return data, fmt.Errorf("> GetAccountInfo: %w", err)
+	}
+
+	err = data.UnmarshalWithDecoder(bin.NewBorshDecoder(resp.GetBinary()))
+	if err != nil {
+		return data, fmt.Errorf("> UnmarshalWithDecoder: %v", err)
+	}
+	return data, nil
+}
+
+// GetRewardMachine returns the RewardMachine account of this machine for a period.
+// The error wraps rpc.ErrNotFound when the machine submitted no task in that period.
+func (chain WrapperSuper) GetRewardMachine(ctx context.Context, period uint32) (distri_ai.RewardMachine, error) {
+	var data distri_ai.RewardMachine
+
+	rewardMachine, err := chain.RewardMachineAccount(period)
+	if err != nil {
+		return data, err
+	}
+
+	resp, err := chain.Conn.RpcClient.GetAccountInfo(ctx, rewardMachine)
+	if err != nil {
+		return data, fmt.Errorf("> GetAccountInfo: %w", err)
+	}
+
+	err = data.UnmarshalWithDecoder(bin.NewBorshDecoder(resp.GetBinary()))
+	if err != nil {
+		return data, fmt.Errorf("> UnmarshalWithDecoder: %v", err)
+	}
+	return data, nil
+}
+
+// GetRewardMachines returns every RewardMachine account of this machine, ordered by period.
+func (chain WrapperSuper) GetRewardMachines(ctx context.Context) ([]distri_ai.RewardMachine, error) {
+	machineUUID, err := utils.ParseMachineUUID(string(chain.MachineUUID))
+	if err != nil {
+		return nil, fmt.Errorf("> ParseMachineUUID: %v", err)
+	}
+
+	// RewardMachine layout: discriminator(8) | period(4) | owner(32) | machineId(16) | ...
+	out, err := chain.Conn.RpcClient.GetProgramAccountsWithOpts(
+		ctx,
+		chain.ProgramSuperID,
+		&rpc.GetProgramAccountsOpts{
+			Filters: []rpc.RPCFilter{
+				{Memcmp: &rpc.RPCFilterMemcmp{Offset: 0, Bytes: distri_ai.RewardMachineDiscriminator[:]}},
+				{Memcmp: &rpc.RPCFilterMemcmp{Offset: 12, Bytes: chain.Wallet.PublicKey().Bytes()}},
+				{Memcmp: &rpc.RPCFilterMemcmp{Offset: 44, Bytes: machineUUID[:]}},
+			},
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("> GetProgramAccountsWithOpts: %v", err)
+	}
+
+	rewardMachines := make([]distri_ai.RewardMachine, 0, len(out))
+	for _, account := range out {
+		var data distri_ai.RewardMachine
+		if err := data.UnmarshalWithDecoder(bin.NewBorshDecoder(account.Account.Data.GetBinary())); err != nil {
+			logs.Warning(fmt.Sprintf("RewardMachine %v: %v", account.Pubkey, err))
+			continue
+		}
+		rewardMachines = append(rewardMachines, data)
+	}
+	sort.Slice(rewardMachines, func(i, j int) bool {
+		return rewardMachines[i].Period < rewardMachines[j].Period
+	})
+	return rewardMachines, nil
+}
+
+// GetMachineOrders returns every Order account placed on this machine.
+func (chain WrapperSuper) GetMachineOrders(ctx context.Context) ([]distri_ai.Order, error) {
+	machineUUID, err := utils.ParseMachineUUID(string(chain.MachineUUID))
+	if err != nil {
+		return nil, fmt.Errorf("> ParseMachineUUID: %v", err)
+	}
+
+	marketOrders, err := GetMarketOrders(ctx, chain.Conn, chain.ProgramSuperID, OrderFilter{
+		Seller:    chain.Wallet.PublicKey(),
+		MachineID: &machineUUID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make([]distri_ai.Order, 0, len(marketOrders))
+	for _, order := range marketOrders {
+		orders = append(orders, order.Order)
+	}
+	return orders, nil
+}
@@ -0,0 +1,36 @@
+package wallet
+
+import (
+	"SuperNet-Node/chain/conn"
+	"SuperNet-Node/config"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// NewFeePayer returns the signer paying the fees of node transactions,
+// or nil when the owner key pays them.
+func NewFeePayer(cfg *config.SolanaConfig) (conn.Signer, error) {
+	feePayer := cfg.FeePayer
+	switch {
+	case feePayer.Remote.URL != "":
+		key, err := solana.PublicKeyFromBase58(feePayer.Remote.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("> PublicKeyFromBase58 feePayer.remote.publicKey: %v", err)
+		}
+		return NewRemoteSigner(feePayer.Remote.URL, key, feePayer.Remote.Token), nil
+	case feePayer.Keystore != "":
+		key, err := Unlock(feePayer.Keystore, cfg.PasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("> Unlock feePayer.keystore: %v", err)
+		}
+		return key, nil
+	case feePayer.Keypair != "":
+		key, err := ReadKeypairFile(feePayer.Keypair)
+		if err != nil {
+			return nil, fmt.Errorf("> ReadKeypairFile feePayer.keypair: %v", err)
+		}
+		return key, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,234 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	keystoreVersion = 1
+	kdfScrypt       = "scrypt"
+	cipherAESGCM    = "aes-256-gcm"
+
+	// scrypt parameters, 64 MiB of memory per derivation.
+	scryptN      = 1 << 16
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+	saltLen      = 32
+)
+
+// ErrWrongPassphrase is returned when a keystore cannot be decrypted with the given passphrase.
+var ErrWrongPassphrase = errors.New("wrong passphrase")
+
+// Keystore is a private key encrypted with a passphrase, as stored on disk.
+// The public key is kept in clear so it can be read without unlocking.
+type Keystore struct {
+	Version   int       `json:"version"`
+	PublicKey string    `json:"publicKey"`
+	CreatedAt time.Time `json:"createdAt"`
+	Crypto    struct {
+		KDF       string `json:"kdf"`
+		KDFParams struct {
+			N    int    `json:"n"`
+			R    int    `json:"r"`
+			P    int    `json:"p"`
+			Salt string `json:"salt"`
+		} `json:"kdfparams"`
+		Cipher     string `json:"cipher"`
+		Nonce      string `json:"nonce"`
+		Ciphertext string `json:"ciphertext"`
+	} `json:"crypto"`
+}
+
+// EncryptKey encrypts key with passphrase, deriving the AES-256-GCM key with scrypt.
+func EncryptKey(key solana.PrivateKey, passphrase []byte) (*Keystore, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("> rand.Read: %v", err)
+	}
+	derived, err := scrypt.Key(passphrase, salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("> scrypt.Key: %v", err)
+	}
+
+	aead, err := newGCM(derived)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("> rand.Read: %v", err)
+	}
+
+	ks := &Keystore{
+		Version:   keystoreVersion,
+		PublicKey: key.PublicKey().String(),
+		CreatedAt: time.Now().UTC(),
+	}
+	ks.Crypto.KDF = kdfScrypt
+	ks.Crypto.KDFParams.N = scryptN
+	ks.Crypto.KDFParams.R = scryptR
+	ks.Crypto.KDFParams.P = scryptP
+	ks.Crypto.KDFParams.Salt = hex.EncodeToString(salt)
+	ks.Crypto.Cipher = cipherAESGCM
+	ks.Crypto.Nonce = hex.EncodeToString(nonce)
+	// The public key is authenticated as additional data so it cannot be swapped.
+	ks.Crypto.Ciphertext = hex.EncodeToString(aead.Seal(nil, nonce, key, []byte(ks.PublicKey)))
+	return ks, nil
+}
+
+// Decrypt returns the private key of the keystore.
+func (ks *Keystore) Decrypt(passphrase []byte) (solana.PrivateKey, error) {
+	if ks.Version != keystoreVersion {
+		return nil, fmt.Errorf("unsupported keystore version: %v", ks.Version)
+	}
+	if ks.Crypto.KDF != kdfScrypt || ks.Crypto.Cipher != cipherAESGCM {
+		return nil, fmt.Errorf("unsupported keystore kdf %v or cipher %v", ks.Crypto.KDF, ks.Crypto.Cipher)
+	}
+
+	salt, err := hex.DecodeString(ks.Crypto.KDFParams.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("> decode salt: %v", err)
+	}
+	nonce, err := hex.DecodeString(ks.Crypto.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("> decode nonce: %v", err)
+	}
+	ciphertext, err := hex.DecodeString(ks.Crypto.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("> decode ciphertext: %v", err)
+	}
+
+	params := ks.Crypto.KDFParams
+	derived, err := scrypt.Key(passphrase, salt, params.N, params.R, params.P, scryptKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("> scrypt.Key: %v", err)
+	}
+	aead, err := newGCM(derived)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(ks.PublicKey))
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+
+	key := solana.PrivateKey(plaintext)
+	if checkKey(key) != nil || key.PublicKey().String() != ks.PublicKey {
+		return nil, fmt.Errorf("keystore private key does not match public key %v", ks.PublicKey)
+	}
+	return key, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("> aes.NewCipher: %v", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("> cipher.NewGCM: %v", err)
+	}
+	return aead, nil
+}
+
+// LoadKeystore reads a keystore file.
+func LoadKeystore(path string) (*Keystore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("> os.ReadFile: %w", err)
+	}
+	var ks Keystore
+	if err := json.Unmarshal(data, &ks); err != nil {
+		return nil, fmt.Errorf("> json.Unmarshal %v: %v", path, err)
+	}
+	return &ks, nil
+}
+
+// Save writes the keystore to path, readable by the owner only.
+// The file is replaced atomically so a crash never leaves a truncated keystore.
+func (ks *Keystore) Save(path string) error {
+	data, err := json.MarshalIndent(ks, "", "  ")
+	if err != nil {
+		return fmt.Errorf("> json.MarshalIndent: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".keystore-*")
+	if err != nil {
+		return fmt.Errorf("> os.CreateTemp: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("> Chmod: %v", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("> Write: %v", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("> Sync: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("> Close: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("> os.Rename: %v", err)
+	}
+	return nil
+}
+
+// ParsePrivateKey decodes a base58 private key.
+func ParsePrivateKey(s string) (solana.PrivateKey, error) {
+	key, err := solana.PrivateKeyFromBase58(s)
+	if err != nil {
+		return nil, fmt.Errorf("> PrivateKeyFromBase58: %v", err)
+	}
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// ReadKeypairFile reads a private key from a Solana CLI keypair file (id.json).
+func ReadKeypairFile(path string) (solana.PrivateKey, error) {
+	key, err := solana.PrivateKeyFromSolanaKeygenFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("> PrivateKeyFromSolanaKeygenFile: %v", err)
+	}
+	if err := checkKey(key); err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	return key, nil
+}
+
+// checkKey verifies that key is a 64 byte ed25519 key whose public half matches its seed.
+func checkKey(key solana.PrivateKey) error {
+	if len(key) != ed25519.PrivateKeySize {
+		return fmt.Errorf("invalid private key length: %v", len(key))
+	}
+	if !bytes.Equal(ed25519.NewKeyFromSeed(key[:ed25519.SeedSize])[ed25519.SeedSize:], key[ed25519.SeedSize:]) {
+		return fmt.Errorf("invalid private key: public key does not match seed")
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package wallet
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// PassphraseEnv is the environment variable a keystore passphrase can be read from.
+const PassphraseEnv = "SUPERNET_KEYSTORE_PASSPHRASE"
+
+// ReadPassphrase returns the keystore passphrase, taken in order from the PassphraseEnv
+// environment variable, from passwordFile if set, or from an interactive prompt.
+// When confirm is set the prompt asks for the passphrase twice.
+func ReadPassphrase(passwordFile string, confirm bool) ([]byte, error) {
+	if passphrase, ok := os.LookupEnv(PassphraseEnv); ok {
+		return []byte(passphrase), nil
+	}
+
+	if passwordFile != "" {
+		data, err := os.ReadFile(passwordFile)
+		if err != nil {
+			return nil, fmt.Errorf("> os.ReadFile: %v", err)
+		}
+		return []byte(strings.TrimRight(string(data), "\r\n")), nil
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil, fmt.Errorf("no passphrase: set %v, base.passwordFile or run interactively", PassphraseEnv)
+	}
+
+	passphrase, err := prompt("Keystore passphrase: ")
+	if err != nil {
+		return nil, err
+	}
+	if confirm {
+		again, err := prompt("Repeat passphrase: ")
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(passphrase, again) {
+			return nil, fmt.Errorf("passphrases do not match")
+		}
+		if len(passphrase) == 0 {
+			return nil, fmt.Errorf("empty passphrase")
+		}
+	}
+	return passphrase, nil
+}
+
+// ReadSecret reads a secret, such as a private key, from a no-echo prompt, or from the first line
+// of stdin when it is not a terminal, so that it never appears on the command line.
+func ReadSecret(message string) ([]byte, error) {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		return prompt(message)
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("> ReadString: %v", err)
+	}
+	return []byte(strings.TrimRight(line, "\r\n")), nil
+}
+
+func prompt(message string) ([]byte, error) {
+	fmt.Fprint(os.Stderr, message)
+	passphrase, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("> term.ReadPassword: %v", err)
+	}
+	return passphrase, nil
+}
@@ -0,0 +1,91 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// RemoteSignerTimeout bounds a signing request to a RemoteSigner.
+const RemoteSignerTimeout = 30 * time.Second
+
+// RemoteSigner signs transaction messages through an HTTP signing service, so the key never
+// lives on the node. The service receives a POST of {"publicKey": base58, "message": base64}
+// and answers {"signature": base58}.
+type RemoteSigner struct {
+	URL   string
+	Key   solana.PublicKey
+	Token string
+	http  *http.Client
+}
+
+// NewRemoteSigner returns a RemoteSigner signing for key at url.
+// A non empty token is sent as a bearer token.
+func NewRemoteSigner(url string, key solana.PublicKey, token string) *RemoteSigner {
+	return &RemoteSigner{
+		URL:   url,
+		Key:   key,
+		Token: token,
+		http:  &http.Client{Timeout: RemoteSignerTimeout},
+	}
+}
+
+func (s *RemoteSigner) PublicKey() solana.PublicKey {
+	return s.Key
+}
+
+// Sign asks the service to sign message and verifies the returned signature against the public key.
+func (s *RemoteSigner) Sign(message []byte) (solana.Signature, error) {
+	body, err := json.Marshal(map[string]string{
+		"publicKey": s.Key.String(),
+		"message":   base64.StdEncoding.EncodeToString(message),
+	})
+	if err != nil {
+		return solana.Signature{}, fmt.Errorf("> json.Marshal: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return solana.Signature{}, fmt.Errorf("> http.NewRequest: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return solana.Signature{}, fmt.Errorf("> http.Do: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return solana.Signature{}, fmt.Errorf("> io.ReadAll: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return solana.Signature{}, fmt.Errorf("remote signer status %v: %s", resp.StatusCode, data)
+	}
+
+	var out struct {
+		Signature string `json:"signature"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return solana.Signature{}, fmt.Errorf("> json.Unmarshal: %v", err)
+	}
+	signature, err := solana.SignatureFromBase58(out.Signature)
+	if err != nil {
+		return solana.Signature{}, fmt.Errorf("> SignatureFromBase58: %v", err)
+	}
+	if !ed25519.Verify(s.Key[:], message, signature[:]) {
+		return solana.Signature{}, fmt.Errorf("remote signer returned an invalid signature for %v", s.Key)
+	}
+	return signature, nil
+}
@@ -2,28 +2,92 @@ package wallet
 
 import (
 	"SuperNet-Node/config"
+	logs "SuperNet-Node/utils/log_utils"
 	"fmt"
 
 	"github.com/gagliardetto/solana-go"
 )
 
+// Wallet is the machine owner. Wallet is nil in offline mode, where the owner key
+// never reaches the node and only its public key is known.
 type Wallet struct {
 	Wallet *solana.Wallet
+	owner  solana.PublicKey
 }
 
-// InitWallet initialize solana wallet1
-// returns *Wallet and error when the public and private keys failed to be decoded
+// PublicKey returns the public key of the machine owner.
+func (w *Wallet) PublicKey() solana.PublicKey {
+	return w.owner
+}
+
+// Offline reports whether the owner key is kept off the node.
+func (w *Wallet) Offline() bool {
+	return w.Wallet == nil
+}
+
+// InitWallet initialize solana wallet
+// The key is unlocked from the keystore, or taken from the plaintext cfg.Key when it is still set.
+// In offline mode only the owner public key is loaded.
+// returns *Wallet and error when the key cannot be read or decoded
 func InitWallet(cfg *config.SolanaConfig) (*Wallet, error) {
-	payerPrivateKey := solana.MustPrivateKeyFromBase58(cfg.Key)
-	payerAccount, err := solana.WalletFromPrivateKeyBase58(payerPrivateKey.String())
+	var key solana.PrivateKey
+	var err error
 
-	if err != nil {
-		return nil, fmt.Errorf("> WalletFromPrivateKeyBase58: %v", err)
+	if cfg.Offline.Enabled {
+		owner, err := solana.PublicKeyFromBase58(cfg.Offline.Owner)
+		if err != nil {
+			return nil, fmt.Errorf("> PublicKeyFromBase58 offline.owner: %v", err)
+		}
+		return &Wallet{owner: owner}, nil
+	}
+
+	if cfg.Key != "" {
+		logs.Warning("base.privateKey is stored in plaintext, move it to the keystore with `wallet import --from-config`")
+		key, err = ParsePrivateKey(cfg.Key)
+		if err != nil {
+			return nil, fmt.Errorf("> ParsePrivateKey: %v", err)
+		}
+	} else {
+		key, err = Unlock(cfg.Keystore, cfg.PasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("> Unlock: %v", err)
+		}
 	}
 
 	wallet := &Wallet{
-		Wallet: payerAccount,
+		Wallet: &solana.Wallet{PrivateKey: key},
+		owner:  key.PublicKey(),
 	}
 
 	return wallet, nil
 }
+
+// lastPassphrase is the passphrase of the last unlocked keystore, tried first so that
+// keystores sharing a passphrase are unlocked with a single prompt.
+var lastPassphrase []byte
+
+// Unlock reads the keystore at path and decrypts it with the passphrase from ReadPassphrase.
+func Unlock(path string, passwordFile string) (solana.PrivateKey, error) {
+	ks, err := LoadKeystore(path)
+	if err != nil {
+		return nil, fmt.Errorf("> LoadKeystore: %v", err)
+	}
+
+	if lastPassphrase != nil {
+		if key, err := ks.Decrypt(lastPassphrase); err == nil {
+			return key, nil
+		}
+	}
+
+	passphrase, err := ReadPassphrase(passwordFile, false)
+	if err != nil {
+		return nil, fmt.Errorf("> ReadPassphrase: %v", err)
+	}
+
+	key, err := ks.Decrypt(passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("> Decrypt: %v", err)
+	}
+	lastPassphrase = passphrase
+	return key, nil
+}
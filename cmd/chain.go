@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"SuperNet-Node/chain/super"
+	"SuperNet-Node/control"
+	logs "SuperNet-Node/utils/log_utils"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+var ChainCommand = cli.Command{
+	Name:  "chain",
+	Usage: "Maintain the accounts of the machine on chain.",
+	Subcommands: []cli.Command{
+		{
+			Name: "migrate",
+			Usage: "Migrate the machine account and the accounts of its orders to the new layout. " +
+				"Each account is copied to a new account, then renamed back to its address.",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "Print the migration steps without sending them.",
+				},
+				&cli.BoolFlag{
+					Name:  "yes",
+					Usage: "Send every step without asking for confirmation.",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				superWrapper, _, err := control.GetSuper(false)
+				if err != nil {
+					logs.Error(err.Error())
+					return nil
+				}
+
+				machine, err := superWrapper.MachineMigration(context.Background())
+				if err != nil {
+					logs.Error(fmt.Sprintf("MachineMigration: %v", err))
+					return nil
+				}
+				orders, err := superWrapper.OrderMigrations(context.Background())
+				if err != nil {
+					logs.Error(fmt.Sprintf("OrderMigrations: %v", err))
+					return nil
+				}
+
+				plans := append([]super.MigrationPlan{machine}, orders...)
+				for _, plan := range plans {
+					logs.Normal(plan.String())
+				}
+				if c.Bool("dry-run") {
+					return nil
+				}
+
+				for _, plan := range plans {
+					for _, step := range plan.Steps {
+						if !c.Bool("yes") && !confirm(fmt.Sprintf("Send %v for %v %v?", step.Name, plan.Kind, plan.Account)) {
+							logs.Normal(fmt.Sprintf("%v %v skipped", plan.Kind, plan.Account))
+							break
+						}
+						hash, err := superWrapper.Migrate(context.Background(), step)
+						if errors.Is(err, super.ErrAwaitingSignature) {
+							// The rename needs the copy on chain, run migrate again once it is submitted.
+							logs.Normal(fmt.Sprintf("Sign the %v transaction, submit it with `tx submit`, then run `chain migrate` again", step.Name))
+							return nil
+						}
+						if err != nil {
+							logs.Error(fmt.Sprintf("Error block : %v, msg : %v\n", hash, err))
+							return nil
+						}
+					}
+				}
+				return nil
+			},
+		},
+	},
+}
+
+// confirm asks a yes/no question on the terminal, no being the default.
+func confirm(question string) bool {
+	fmt.Printf("%v [y/N] ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
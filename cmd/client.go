@@ -1,21 +1,24 @@
 package cmd
 
 import (
+	"SuperNet-Node/chain/super"
 	"SuperNet-Node/config"
 	"SuperNet-Node/control"
+	"SuperNet-Node/daemon"
 	"SuperNet-Node/docker"
 	"SuperNet-Node/nginx"
 	"SuperNet-Node/pattern"
 	"SuperNet-Node/server"
-	"SuperNet-Node/utils"
 	dbutils "SuperNet-Node/utils/db_utils"
 	logs "SuperNet-Node/utils/log_utils"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
-	"github.com/gagliardetto/solana-go"
 	"github.com/urfave/cli"
 )
 
@@ -23,6 +26,16 @@ var ClientCommand = cli.Command{
 	Name:  "node",
 	Usage: "Starting or terminating a node program.",
 	Subcommands: []cli.Command{
+		offerCommand,
+		rewardsCommand,
+		earningsCommand,
+		historyCommand,
+		simulateCommand,
+		statusCommand,
+		pauseCommand,
+		resumeCommand,
+		reloadCommand,
+		installServiceCommand,
 		{
 			Name:  "start",
 			Usage: "Upload hardware configuration and initiate listening events.",
@@ -34,308 +47,198 @@ var ClientCommand = cli.Command{
 				},
 			},
 			Action: func(c *cli.Context) error {
+				// Deferred first, so that the log file is flushed after the shutdown is logged.
+				defer logs.Logger.Sync()
 
 				logs.Normal(pattern.LOGO)
 
+				// Deferred next, so that the database is closed once everything else has stopped.
 				defer dbutils.CloseDB()
 
+				ctx, stop := shutdownContext()
+				defer stop()
+				// node stop shuts the node down through the control socket, as a signal does.
+				ctx, cancel := context.WithCancel(ctx)
+				defer cancel()
+
+				// Closed last, so that node stop waits until the node is about to exit.
+				ln, err := daemon.Listen(config.GlobalConfig.Console.ControlSocket)
+				if err != nil {
+					return startError(fmt.Sprintf("Control socket: %v", err))
+				}
+				defer ln.Close()
+
+				// Held by the node, the other commands append to it through the control socket.
+				if err := super.OpenAuditLog(); err != nil {
+					return startError(fmt.Sprintf("OpenAuditLog: %v", err))
+				}
+				defer super.CloseAuditLog()
+				if err := super.MigrateAuditLog(); err != nil {
+					logs.Error(fmt.Sprintf("MigrateAuditLog: %v", err))
+				}
+
+				started := time.Now()
+				rt, err := docker.NewRuntime(config.GlobalConfig.Runtime)
+				if err != nil {
+					return startError(fmt.Sprintf("NewRuntime: %v", err))
+				}
+				defer rt.Close()
+				control.SetRuntime(rt)
+				logs.Normal(fmt.Sprintf("Container engine: %v", config.GlobalConfig.Runtime.Engine))
+
 				superWrapper, hwInfo, err := control.GetSuper(true)
 				if err != nil {
-					logs.Error(fmt.Sprintf("GetSuper: %v", err))
-					return nil
+					return startError(fmt.Sprintf("GetSuper: %v", err))
 				}
+				defer superWrapper.Conn.Close()
 
 				if err = nginx.StartNginx(
 					config.GlobalConfig.Console.SuperPort,
 					config.GlobalConfig.Console.WorkPort,
 					config.GlobalConfig.Console.ServerPort); err != nil {
-					logs.Error(fmt.Sprintf("StartNginx error: %v", err))
-					return nil
+					return startError(fmt.Sprintf("StartNginx error: %v", err))
 				}
 
-				machine, err := superWrapper.GetMachine()
+				machine, err := superWrapper.GetMachine(ctx)
 				if err != nil {
-					logs.Error(fmt.Sprintf("GetMachine: %v", err))
-					return nil
+					return startError(fmt.Sprintf("GetMachine: %v", err))
 				}
 
 				if machine.Metadata == "" {
 					logs.Normal("Machine does not exist")
-					_, err := superWrapper.AddMachine(*hwInfo)
-					if err != nil {
-						logs.Error(fmt.Sprintf("AddMachine: %v", err))
-						return nil
+					_, err := superWrapper.AddMachine(ctx, *hwInfo)
+					if errors.Is(err, super.ErrAwaitingSignature) {
+						logs.Normal("The machine is registered once the signed AddMachine is submitted")
+					} else if err != nil {
+						return startError(fmt.Sprintf("AddMachine: %v", err))
 					}
 				} else {
 					logs.Normal("Machine already exists")
 				}
 
-				go server.StartServer(config.GlobalConfig.Console.ServerPort)
-
-				control.StartHeartbeatTask(superWrapper, hwInfo.MachineUUID)
-
-				for {
-					time.Sleep(1 * time.Minute)
-
-					machine, err = superWrapper.GetMachine()
-					if err != nil {
-						logs.Error(fmt.Sprintf("GetMachine: %v", err))
-						continue
-					}
-
-				ListenLoop:
-					switch machine.Status.String() {
-					case "Idle":
-						// TODO: Add the logic of the Idle status.
-						break ListenLoop
-					case "ForRent":
-						// TODO: Add the logic of the ForRent status.
-						break ListenLoop
-					case "Renting":
-
-						logs.Normal(fmt.Sprintf("Machine is Renting, Details: %v", machine))
-
-						orderID := machine.OrderPda
-						if orderID.Equals(solana.SystemProgramID) {
-							logs.Error(fmt.Sprintf("machine OrderPda error, OrderPda: %v", orderID))
-							break ListenLoop
-						}
-
-						superWrapper.ProgramSuperOrder = orderID
-						newOrder, err := superWrapper.GetOrder()
-						if err != nil {
-							logs.Error(fmt.Sprintf("GetOrder Error: %v", err))
-							break ListenLoop
-						}
-
-						var orderPlacedMetadata pattern.OrderPlacedMetadata
-
-						err = json.Unmarshal([]byte(newOrder.Metadata), &orderPlacedMetadata)
-						if err != nil {
-							logs.Error(fmt.Sprintf("json.Unmarshal: %v", err))
-							break ListenLoop
-						}
-
-						isGPU := false
-						if hwInfo.GPUInfo.Number > 0 {
-							isGPU = true
-						}
-
-						var containerID string
-
-						switch orderPlacedMetadata.OrderInfo.Intent {
-						case "train":
-							mlToken, err := dbutils.GenToken(newOrder.Buyer.String())
-							if err != nil {
-								logs.Error(fmt.Sprintf("GenToken: %v", err))
-								break ListenLoop
-							}
-							logs.Normal(fmt.Sprintf("From buyer: %v ; mlToken: %v", newOrder.Buyer, mlToken))
-
-							containerID, err = docker.TestRunWorkspaceContainer(isGPU, mlToken)
-							if err != nil {
-								logs.Error(fmt.Sprintln("RunWorkspaceContainer error: ", err))
-								orderPlacedMetadata.OrderInfo.Message = err.Error()
-								if err = control.OrderFailed(superWrapper, orderPlacedMetadata, newOrder.Buyer); err != nil {
-									logs.Error(fmt.Sprintf("control.OrderFailed: %v", err))
-								}
-								break ListenLoop
-							}
-
-							url := orderPlacedMetadata.OrderInfo.DownloadURL
-							if len(url) > 0 {
-								modelDir := config.GlobalConfig.Console.WorkDirectory + "/ml-workspace"
-								var modelURL []utils.DownloadURL
-
-								// Easy debugging
-								for _, u := range url {
-									modelURL = append(modelURL, utils.DownloadURL{
-										URL: config.GlobalConfig.Console.IpfsNodeUrl + "/ipfs" + utils.EnsureLeadingSlash(u),
-										// URL:      u,
-										Checksum: "",
-										Name:     "CID.json",
-									})
-								}
-
-								logs.Normal("Downloading CID.json ...")
-								err = utils.DownloadFiles(modelDir, modelURL)
-								if err != nil {
-									logs.Error(fmt.Sprintf("DownloadFiles %v", err))
-								}
-
-								items, err := utils.GetCidItemsFromFile(modelDir + "/CID.json")
-								if err != nil {
-									logs.Error(fmt.Sprintf("GetCidItemsFromFile %v", err))
-								}
-
-								modelURL = nil
-								for _, item := range items {
-									modelURL = append(modelURL, utils.DownloadURL{
-										URL:      config.GlobalConfig.Console.IpfsNodeUrl + "/ipfs" + utils.EnsureLeadingSlash(item.Cid),
-										Checksum: "",
-										Name:     item.Name,
-									})
-								}
-
-								logs.Normal("Downloading the following files...")
-								for _, url := range modelURL {
-									logs.Normal(url.Name)
-								}
-
-								err = utils.DownloadFiles(modelDir, modelURL)
-								if err != nil {
-									logs.Error(fmt.Sprintf("DownloadFiles %v", err))
-								}
-							}
-						case "deploy":
-							_, err := dbutils.GenToken(newOrder.Buyer.String())
-							if err != nil {
-								logs.Error(fmt.Sprintf("GenToken: %v", err))
-								break ListenLoop
-							}
-
-							// Easy debugging
-							var downloadDeployURL []string
-
-							url := orderPlacedMetadata.OrderInfo.DownloadURL
-							if len(url) > 0 {
-								deployDir := config.GlobalConfig.Console.WorkDirectory
-								var deployURL []utils.DownloadURL
-								deployURL = append(deployURL, utils.DownloadURL{
-									URL:      config.GlobalConfig.Console.IpfsNodeUrl + "/ipfs" + utils.EnsureLeadingSlash(url[0]),
-									Checksum: "",
-									Name:     "CID.json",
-								})
-
-								logs.Normal("Downloading CID.json ...")
-								err = utils.DownloadFiles(deployDir, deployURL)
-								if err != nil {
-									logs.Error(fmt.Sprintf("DownloadFiles: %v", err))
-								}
-
-								items, err := utils.GetCidItemsFromFile(deployDir + "/CID.json")
-								if err != nil {
-									logs.Error(fmt.Sprintf("GetCidItemsFromFile: %v", err))
-								}
-
-								err = os.Remove(deployDir + "/CID.json")
-								if err != nil {
-									logs.Error(fmt.Sprintf("Remove CID.json: %v", err))
-								}
-
-								for _, item := range items {
-									downloadDeployURL = append(downloadDeployURL, config.GlobalConfig.Console.IpfsNodeUrl+utils.EnsureLeadingSlash(item.Cid))
-								}
-							}
-
-							logs.Normal("Run deploy container ...")
-							logs.Normal(fmt.Sprintf("DownloadDeployURL: %v", downloadDeployURL))
-
-							containerID, err = docker.RunDeployContainer(isGPU, downloadDeployURL)
-							if err != nil {
-								logs.Error(fmt.Sprintln("RunDeployContainer error ", err))
-								orderPlacedMetadata.OrderInfo.Message = err.Error()
-								if err = control.OrderFailed(superWrapper, orderPlacedMetadata, newOrder.Buyer); err != nil {
-									logs.Error(fmt.Sprintf("control.OrderFailed: %v", err))
-								}
-								break ListenLoop
-							}
-						default:
-							logs.Error(fmt.Sprintf("OrderInfo.Intent error, Intent: %v", orderPlacedMetadata.OrderInfo.Intent))
-							break ListenLoop
-						}
-
-						_, err = superWrapper.OrderStart()
-						if err != nil {
-							logs.Error(fmt.Sprintf("OrderStart: %v", err))
-							if err := docker.StopWorkspaceContainer(containerID); err != nil {
-								logs.Error(fmt.Sprintf("> StopWorkspaceContainer, containerID: %s, err: %v", containerID, err))
-							}
-							break ListenLoop
-						}
-
-						for {
-							time.Sleep(1 * time.Minute)
-
-							newOrder, err = superWrapper.GetOrder()
-							if err != nil {
-								logs.Error(fmt.Sprintf("GetOrder Error: %v", err))
-								break ListenLoop
-							}
-
-							switch newOrder.Status.String() {
-							case "Preparing":
-								logs.Error(fmt.Sprintf("Order error, ID: %v\norder: %v", superWrapper.ProgramSuperOrder, newOrder))
-								break ListenLoop
-							case "Training":
-								orderEndTime := time.Unix(newOrder.StartTime, 0).Add(time.Hour * time.Duration(newOrder.Duration))
+				// The tasks and the server use the database, they are stopped before it is closed.
+				defer control.Shutdown()
+				control.StartEventListener(ctx, superWrapper)
+				served := make(chan struct{})
+				go func() {
+					defer close(served)
+					server.StartServer(ctx, config.GlobalConfig.Console.ServerPort, superWrapper)
+				}()
+				defer func() { <-served }()
+
+				if superWrapper.Wallet.Offline() {
+					// Heartbeats, claims and repricing send a transaction on every run, which the single durable nonce,
+					// shared with the order transactions, cannot carry. Without heartbeats the machine earns no rewards.
+					logs.Warning("Owner key is offline: no heartbeats are sent, so the machine earns no rewards; " +
+						"reward auto claim and pricing are disabled, offers are signed through the outbox")
+					control.StartInboxTask(ctx, superWrapper)
+				} else {
+					control.StartHeartbeatTask(ctx, superWrapper, hwInfo.MachineUUID)
+				}
+				// Reward auto claim and pricing, restarted by node reload.
+				control.StartConfiguredTasks(ctx, superWrapper)
 
-								db := dbutils.GetDB()
-								dbutils.Update(db, []byte("orderEndTime"), []byte(orderEndTime.Format(time.RFC3339)))
+				isGPU := false
+				if hwInfo.GPUInfo.Number > 0 {
+					isGPU = true
+				}
 
-								timeNow := time.Now()
-								if timeNow.After(orderEndTime) {
+				if err = control.ResumeWorkspaceGrace(); err != nil {
+					logs.Error(fmt.Sprintf("Resume workspace grace period: %v", err))
+				}
 
-									logs.Normal(fmt.Sprintf("Order completed, Details: %v", newOrder))
+				// Pick up an order interrupted by a restart before listening for new ones.
+				orders := control.NewOrderMachine(superWrapper, isGPU)
+				if err = orders.Resume(ctx); err != nil {
+					logs.Error(fmt.Sprintf("Resume order: %v", err))
+				}
 
-									if err = control.OrderComplete(superWrapper, newOrder.Metadata, isGPU, containerID); err != nil {
-										logs.Error(fmt.Sprintf("OrderComplete: %v", err))
-									}
-									break ListenLoop
-								}
-								continue
-							case "Completed":
-								logs.Error(fmt.Sprintf("Order error, ID: %v\norder: %v", superWrapper.ProgramSuperOrder, newOrder))
-								break ListenLoop
-							case "Failed":
-								logs.Error(fmt.Sprintf("Order error, ID: %v\norder: %v", superWrapper.ProgramSuperOrder, newOrder))
-								break ListenLoop
-							case "Refunded":
-								err = control.OrderRefunded(containerID)
-								if err != nil {
-									logs.Error(fmt.Sprintf("OrderRefunded: %v", err))
-								}
-								break ListenLoop
-							}
-						}
-					default:
-						logs.Error(fmt.Sprintf("machine status error, Status: %v", machine.Status))
-						break ListenLoop
+				go func() {
+					if err := daemon.Serve(ln, &daemon.Node{Super: superWrapper, Started: started, Stop: cancel}); err != nil {
+						logs.Error(fmt.Sprintf("Control socket: %v", err))
 					}
-				}
+				}()
+
+				orders.Serve(ctx)
+				logs.Normal("Shutting down")
+				return nil
 			},
 		},
 		{
 			Name:  "stop",
-			Usage: "Stop the client.",
+			Usage: "Remove the machine from the market and stop the running node.",
 			Action: func(c *cli.Context) error {
-				nginx.StopNginx()
+				client := daemon.NewClient(config.GlobalConfig.Console.ControlSocket)
+				err := client.Stop()
+				if err == nil {
+					logs.Normal("Machine removed, waiting for the node to exit")
+					if err := waitStopped(client, 3*time.Minute); err != nil {
+						logs.Error(err.Error())
+						return nil
+					}
+					logs.Normal("Node stopped")
+					return nil
+				}
+				if !errors.Is(err, daemon.ErrNotRunning) {
+					logs.Error(fmt.Sprintf("Stop: %v, the node keeps running", err))
+					return nil
+				}
+
+				// No node is running, the machine is removed from here.
+				if err := nginx.StopNginx(); err != nil {
+					logs.Error(fmt.Sprintf("StopNginx: %v", err))
+				}
 
 				superWrapper, _, err := control.GetSuper(false)
 				if err != nil {
 					logs.Error(err.Error())
 					return nil
 				}
-
-				hash, err := superWrapper.RemoveMachine()
-				if err != nil {
-					logs.Error(fmt.Sprintf("Error block : %v, msg : %v\n", hash, err))
-				}
-
-				db := dbutils.GetDB()
 				defer dbutils.CloseDB()
-				dbutils.Delete(db, []byte("buyer"))
-				dbutils.Delete(db, []byte("token"))
-				dbutils.Delete(db, []byte("orderEndTime"))
-				dbutils.CloseDB()
-
-				err = os.RemoveAll(pattern.ModleCreatePath)
-				if err != nil {
-					logs.Error(fmt.Sprintf("RemoveAll: %v", err))
+				if err := control.RemoveMachine(context.Background(), superWrapper); err != nil {
+					logs.Error(err.Error())
 				}
-
 				return nil
 			},
 		},
 	},
 }
+
+// startError logs an error that keeps the node from starting and exits 1, so that systemd restarts it.
+func startError(message string) error {
+	logs.Error(message)
+	return cli.NewExitError("", 1)
+}
+
+// shutdownContext returns a context cancelled by the first SIGINT or SIGTERM, letting the node finish
+// the step in progress before it exits; a second signal exits at once.
+func shutdownContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		logs.Warning(fmt.Sprintf("Received %v, stopping once the step in progress is done; send it again to exit at once", sig))
+		cancel()
+		sig = <-signals
+		logs.Error(fmt.Sprintf("Received %v again, exiting", sig))
+		logs.Logger.Sync()
+		os.Exit(1)
+	}()
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
+
+// waitStopped waits until the node no longer answers on its control socket.
+func waitStopped(client *daemon.Client, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if _, err := client.Status(); errors.Is(err, daemon.ErrNotRunning) {
+			return nil
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	return fmt.Errorf("node still running after %v", timeout)
+}
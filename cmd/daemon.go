@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"SuperNet-Node/chain/super"
+	"SuperNet-Node/config"
+	"SuperNet-Node/daemon"
+	"SuperNet-Node/utils"
+	logs "SuperNet-Node/utils/log_utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+func init() {
+	// The running node holds the audit log, the transactions sent by the other commands are audited through it.
+	super.ForwardAudit = func(record super.AuditRecord) error {
+		return daemonClient().AppendAudit(record)
+	}
+}
+
+// daemonClient returns the client of the control socket of the running node.
+func daemonClient() *daemon.Client {
+	return daemon.NewClient(config.GlobalConfig.Console.ControlSocket)
+}
+
+// daemonError logs the error of a call to the running node, exiting 1 for scripts and systemd.
+func daemonError(action string, err error) error {
+	if errors.Is(err, daemon.ErrNotRunning) {
+		logs.Error(fmt.Sprintf("%v: node is not running, no control socket at %v", action, config.GlobalConfig.Console.ControlSocket))
+	} else {
+		logs.Error(fmt.Sprintf("%v: %v", action, err))
+	}
+	return cli.NewExitError("", 1)
+}
+
+var statusCommand = cli.Command{
+	Name:  "status",
+	Usage: "Show the state of the running node.",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "Print the status as JSON.",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		status, err := daemonClient().Status()
+		if err != nil {
+			return daemonError("Status", err)
+		}
+		if c.Bool("json") {
+			data, err := json.MarshalIndent(status, "", "  ")
+			if err != nil {
+				logs.Error(fmt.Sprintf("json.Marshal: %v", err))
+				return nil
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+
+		fmt.Printf("Node:    running, pid %v, up %v\n", status.Pid, time.Since(status.Started).Round(time.Second))
+		fmt.Printf("Engine:  %v\n", status.Engine)
+		fmt.Printf("Owner:   %v\n", status.Owner)
+		if status.MachineStatus == "" {
+			fmt.Printf("Machine: %v, not registered\n", status.Machine)
+		} else {
+			fmt.Printf("Machine: %v, %v, %v SNT/h, MaxDuration: %vh, Disk: %vGB\n", status.Machine, status.MachineStatus,
+				utils.UnitsToSnt(status.Price), status.MaxDuration, status.Disk)
+		}
+		if status.Pause != nil {
+			fmt.Printf("Pause:   %v\n", status.Pause)
+		}
+		if order := status.Order; order != nil {
+			fmt.Printf("Order:   %v, %v", order.OrderPda, order.State)
+			if !order.EndTime.IsZero() {
+				fmt.Printf(", ends %v", order.EndTime.Local().Format(time.DateTime))
+			}
+			if order.PendingTx != "" {
+				fmt.Printf(", pending %v", order.PendingTx)
+			}
+			fmt.Println()
+		}
+		if grace := status.Grace; grace != nil {
+			fmt.Printf("Workspace: order %v kept until %v\n", grace.Order, grace.Until.Local().Format(time.DateTime))
+		}
+		return nil
+	},
+}
+
+var pauseCommand = cli.Command{
+	Name: "pause",
+	Usage: "Stop taking orders: the offer is cancelled now, or when the order in progress ends. " +
+		"`node resume` lists the machine again with the same offer.",
+	Action: func(c *cli.Context) error {
+		pause, err := daemonClient().Pause()
+		if err != nil {
+			return daemonError("Pause", err)
+		}
+		logs.Normal(fmt.Sprintf("Node %v", pause))
+		return nil
+	},
+}
+
+var resumeCommand = cli.Command{
+	Name:  "resume",
+	Usage: "Take orders again after `node pause`, listing the machine with the offer it had.",
+	Action: func(c *cli.Context) error {
+		if err := daemonClient().Resume(); err != nil {
+			return daemonError("Resume", err)
+		}
+		logs.Normal("Node resumed")
+		return nil
+	},
+}
+
+var reloadCommand = cli.Command{
+	Name: "reload",
+	Usage: "Apply config.yml to the running node. The order, pricing and reward sections apply at once, " +
+		"the others on the next start.",
+	Action: func(c *cli.Context) error {
+		report, err := daemonClient().Reload()
+		if err != nil {
+			return daemonError("Reload", err)
+		}
+		logs.Normal(report.String())
+		return nil
+	},
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"SuperNet-Node/control"
+	logs "SuperNet-Node/utils/log_utils"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/urfave/cli"
+)
+
+var earningsCommand = cli.Command{
+	Name:  "earnings",
+	Usage: "Report the rewards and order revenue earned by the machine per period.",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "First day of the report (YYYY-MM-DD, UTC). Defaults to 30 days before --to.",
+		},
+		&cli.StringFlag{
+			Name:  "to",
+			Usage: "Last day of the report (YYYY-MM-DD, UTC). Defaults to today.",
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Value: "table",
+			Usage: "Output format: table, json or csv.",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		from, to, err := control.EarningsRange(c.String("from"), c.String("to"))
+		if err != nil {
+			logs.Error(err.Error())
+			return nil
+		}
+
+		superWrapper, _, err := control.GetSuper(false)
+		if err != nil {
+			logs.Error(err.Error())
+			return nil
+		}
+
+		report, err := control.GetEarnings(context.Background(), superWrapper, from, to)
+		if err != nil {
+			logs.Error(err.Error())
+			return nil
+		}
+
+		switch c.String("format") {
+		case "table":
+			err = printEarningsTable(report)
+		case "json":
+			err = printEarningsJSON(report)
+		case "csv":
+			err = printEarningsCSV(report)
+		default:
+			err = fmt.Errorf("unknown format: %v", c.String("format"))
+		}
+		if err != nil {
+			logs.Error(err.Error())
+		}
+		return nil
+	},
+}
+
+func printEarningsTable(report *control.EarningsReport) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "PERIOD\tDATE\tPOOL\tTASKS\tPERIODIC\tTASK\tCLAIMED\tCLAIMABLE\tORDERS\tREVENUE\t")
+	for _, p := range report.Periods {
+		fmt.Fprintf(w, "%v\t%v\t%.4f\t%v\t%.4f\t%.4f\t%.4f\t%.4f\t%v\t%.4f\t\n",
+			p.Period, p.Date, p.Pool, p.TaskNum, p.PeriodicReward, p.TaskReward,
+			p.ClaimedReward, p.ClaimableReward, p.CompletedOrders, p.OrderRevenue)
+	}
+	fmt.Fprintf(w, "TOTAL\t\t\t\t\t\t%.4f\t%.4f\t\t%.4f\t\n",
+		report.TotalClaimed, report.TotalClaimable, report.TotalOrderRevenue)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\nMachine %v, all time claimed: periodic %.4f SNT, task %.4f SNT\n",
+		report.Machine, report.ClaimedPeriodicRewards, report.ClaimedTaskRewards)
+	return nil
+}
+
+func printEarningsJSON(report *control.EarningsReport) error {
+	return printJSON(report)
+}
+
+func printEarningsCSV(report *control.EarningsReport) error {
+	w := csv.NewWriter(os.Stdout)
+	w.Write([]string{
+		"period", "date", "finished", "pool", "unit_periodic_reward", "unit_task_reward", "task_num",
+		"periodic_reward", "task_reward", "claimed", "claimed_reward", "claimable_reward",
+		"completed_orders", "order_revenue",
+	})
+	snt := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	for _, p := range report.Periods {
+		w.Write([]string{
+			strconv.FormatUint(uint64(p.Period), 10), p.Date, strconv.FormatBool(p.Finished),
+			snt(p.Pool), snt(p.UnitPeriodicReward), snt(p.UnitTaskReward), strconv.FormatUint(uint64(p.TaskNum), 10),
+			snt(p.PeriodicReward), snt(p.TaskReward), strconv.FormatBool(p.Claimed), snt(p.ClaimedReward), snt(p.ClaimableReward),
+			strconv.Itoa(p.CompletedOrders), snt(p.OrderRevenue),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"SuperNet-Node/chain/fakerpc"
+	"SuperNet-Node/pattern"
+	logs "SuperNet-Node/utils/log_utils"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/urfave/cli"
+)
+
+var FakeRPCCommand = cli.Command{
+	Name: "fakerpc",
+	Usage: "Serve an in-memory Solana JSON-RPC emulating the DistriAi program, to run the node offline. " +
+		"Point base.rpc to it, e.g. http://127.0.0.1:8899. Its state is lost when it stops.",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "listen",
+			Value: "127.0.0.1:8899",
+			Usage: "Address to serve on.",
+		},
+		&cli.StringFlag{
+			Name:  "program",
+			Value: pattern.PROGRAM_SUPER_ID,
+			Usage: "Address of the emulated program.",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		programID, err := solana.PublicKeyFromBase58(c.String("program"))
+		if err != nil {
+			logs.Error(fmt.Sprintf("invalid program: %v", err))
+			return nil
+		}
+		logs.Normal(fmt.Sprintf("Fake RPC of program %v listening on %v", programID, c.String("listen")))
+		if err := fakerpc.NewServer(programID).ListenAndServe(c.String("listen")); err != nil {
+			logs.Error(fmt.Sprintf("ListenAndServe: %v", err))
+		}
+		return nil
+	},
+}
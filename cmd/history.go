@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"SuperNet-Node/chain/super"
+	"SuperNet-Node/control"
+	"SuperNet-Node/daemon"
+	logs "SuperNet-Node/utils/log_utils"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+var historyCommand = cli.Command{
+	Name:  "history",
+	Usage: "List the transactions sent by the node, from the local audit log.",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "order",
+			Usage: "Only the transactions of this order account.",
+		},
+		&cli.StringFlag{
+			Name:  "type",
+			Usage: "Only this instruction, e.g. order_start or hashrate_market.order_start.",
+		},
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "Start of the range (YYYY-MM-DD, UTC, or RFC 3339).",
+		},
+		&cli.StringFlag{
+			Name:  "to",
+			Usage: "End of the range, included (YYYY-MM-DD, UTC, or RFC 3339).",
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Value: "table",
+			Usage: "Output format: table or jsonl.",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		filter, err := control.NewHistoryFilter(c.String("order"), c.String("type"), c.String("from"), c.String("to"))
+		if err != nil {
+			logs.Error(err.Error())
+			return nil
+		}
+
+		// The running node holds the audit log.
+		records, err := daemonClient().History(c.String("order"), c.String("type"), c.String("from"), c.String("to"))
+		if errors.Is(err, daemon.ErrNotRunning) {
+			records, err = super.History(filter)
+		}
+		if err != nil {
+			logs.Error(err.Error())
+			return nil
+		}
+
+		switch c.String("format") {
+		case "table":
+			err = printHistoryTable(records)
+		case "jsonl":
+			err = control.WriteHistoryJSONL(os.Stdout, records)
+		default:
+			err = fmt.Errorf("unknown format: %v", c.String("format"))
+		}
+		if err != nil {
+			logs.Error(err.Error())
+		}
+		return nil
+	},
+}
+
+func printHistoryTable(records []super.AuditRecord) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tTYPE\tSTATUS\tORDER\tSIGNATURE\tSLOT\tFEE\tERROR")
+	for _, r := range records {
+		// Errors carry the program logs on the following lines; the full error is in the jsonl output.
+		errLine, _, _ := strings.Cut(r.Error, "\n")
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
+			r.Time.UTC().Format(time.DateTime), r.Name, r.Status, r.Order, r.Signature, r.Slot, r.Fee, errLine)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,322 @@
+package cmd
+
+import (
+	"SuperNet-Node/chain/conn"
+	"SuperNet-Node/chain/super"
+	"SuperNet-Node/control"
+	"SuperNet-Node/pattern"
+	"SuperNet-Node/utils"
+	logs "SuperNet-Node/utils/log_utils"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/urfave/cli"
+)
+
+var MarketCommand = cli.Command{
+	Name:  "market",
+	Usage: "Explore the machines and orders of the market.",
+	Subcommands: []cli.Command{
+		{
+			Name:  "machines",
+			Usage: "List the machines of the market.",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "owner",
+					Usage: "Only the machines of this owner.",
+				},
+				&cli.StringFlag{
+					Name:  "status",
+					Usage: "Only the machines in this status: Idle, ForRent or Renting.",
+				},
+				&cli.StringFlag{
+					Name:  "gpu",
+					Usage: "Only the machines whose GPU model contains this text, e.g. 4090.",
+				},
+				&cli.StringFlag{
+					Name:  "sort",
+					Value: "price",
+					Usage: "Sort by price, score or gpu.",
+				},
+				&cli.BoolFlag{
+					Name:  "desc",
+					Usage: "Sort in descending order.",
+				},
+				&cli.IntFlag{
+					Name:  "limit",
+					Usage: "Show at most this many machines.",
+				},
+				&cli.StringFlag{
+					Name:  "format",
+					Value: "table",
+					Usage: "Output format: table or json.",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				if err := listMachines(c); err != nil {
+					logs.Error(err.Error())
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "orders",
+			Usage: "List the orders of the market.",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "buyer",
+					Usage: "Only the orders of this buyer.",
+				},
+				&cli.StringFlag{
+					Name:  "seller",
+					Usage: "Only the orders served by the machines of this owner.",
+				},
+				&cli.StringFlag{
+					Name:  "status",
+					Usage: "Only the orders in this status: Preparing, Training, Completed, Failed or Refunded.",
+				},
+				&cli.StringFlag{
+					Name:  "sort",
+					Value: "time",
+					Usage: "Sort by time, price or total.",
+				},
+				&cli.BoolFlag{
+					Name:  "desc",
+					Usage: "Sort in descending order.",
+				},
+				&cli.IntFlag{
+					Name:  "limit",
+					Usage: "Show at most this many orders.",
+				},
+				&cli.StringFlag{
+					Name:  "format",
+					Value: "table",
+					Usage: "Output format: table or json.",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				if err := listOrders(c); err != nil {
+					logs.Error(err.Error())
+				}
+				return nil
+			},
+		},
+	},
+}
+
+// MarketMachineView is the output of `market machines`.
+type MarketMachineView struct {
+	Machine     string  `json:"Machine"`
+	Owner       string  `json:"Owner"`
+	Status      string  `json:"Status"`
+	Price       float64 `json:"Price"`
+	MaxDuration uint32  `json:"MaxDuration"`
+	Disk        uint32  `json:"Disk"`
+	Score       uint8   `json:"Score"`
+	GPU         string  `json:"GPU"`
+	GPUNumber   int     `json:"GPUNumber"`
+	CPU         string  `json:"CPU"`
+	RAM         float64 `json:"RAM"`
+	TFLOPS      float32 `json:"TFLOPS"`
+	Country     string  `json:"Country"`
+	Completed   uint32  `json:"CompletedCount"`
+	Failed      uint32  `json:"FailedCount"`
+}
+
+// MarketOrderView is the output of `market orders`.
+type MarketOrderView struct {
+	Order     string    `json:"Order"`
+	Buyer     string    `json:"Buyer"`
+	Seller    string    `json:"Seller"`
+	Status    string    `json:"Status"`
+	Price     float64   `json:"Price"`
+	Duration  uint32    `json:"Duration"`
+	Total     float64   `json:"Total"`
+	OrderTime time.Time `json:"OrderTime"`
+}
+
+func listMachines(c *cli.Context) error {
+	var filter super.MachineFilter
+	var err error
+	if c.String("owner") != "" {
+		if filter.Owner, err = solana.PublicKeyFromBase58(c.String("owner")); err != nil {
+			return fmt.Errorf("invalid owner: %v", err)
+		}
+	}
+	if c.String("status") != "" {
+		status, err := super.ParseMachineStatus(c.String("status"))
+		if err != nil {
+			return err
+		}
+		filter.Status = &status
+	}
+
+	newConn, err := conn.NewConn(control.NewSolanaConfig())
+	if err != nil {
+		return fmt.Errorf("> NewConn: %v", err)
+	}
+	defer newConn.Close()
+
+	machines, err := super.GetMarketMachines(context.Background(), newConn, solana.MustPublicKeyFromBase58(pattern.PROGRAM_SUPER_ID), filter)
+	if err != nil {
+		return err
+	}
+
+	views := make([]MarketMachineView, 0, len(machines))
+	gpu := strings.ToLower(c.String("gpu"))
+	for _, m := range machines {
+		if gpu != "" && !strings.Contains(strings.ToLower(m.Info.GPUInfo.Model), gpu) {
+			continue
+		}
+		views = append(views, MarketMachineView{
+			Machine:     m.Pubkey.String(),
+			Owner:       m.Owner.String(),
+			Status:      m.Status.String(),
+			Price:       utils.UnitsToSnt(m.Price),
+			MaxDuration: m.MaxDuration,
+			Disk:        m.Disk,
+			Score:       m.Score,
+			GPU:         m.Info.GPUInfo.Model,
+			GPUNumber:   m.Info.GPUInfo.Number,
+			CPU:         m.Info.CPUInfo.ModelName,
+			RAM:         m.Info.MemoryInfo.RAM,
+			TFLOPS:      m.Info.TFLOPSInfo.TFLOPS,
+			Country:     m.Info.LocationInfo.Country,
+			Completed:   m.CompletedCount,
+			Failed:      m.FailedCount,
+		})
+	}
+
+	var compare func(a, b MarketMachineView) int
+	switch c.String("sort") {
+	case "price":
+		compare = func(a, b MarketMachineView) int { return cmp.Compare(a.Price, b.Price) }
+	case "score":
+		compare = func(a, b MarketMachineView) int { return cmp.Compare(a.Score, b.Score) }
+	case "gpu":
+		compare = func(a, b MarketMachineView) int {
+			return cmp.Or(cmp.Compare(a.GPU, b.GPU), cmp.Compare(a.GPUNumber, b.GPUNumber))
+		}
+	default:
+		return fmt.Errorf("unknown sort: %v", c.String("sort"))
+	}
+	views = sortAndLimit(views, compare, c.Bool("desc"), c.Int("limit"))
+
+	switch c.String("format") {
+	case "table":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "MACHINE\tSTATUS\tPRICE\tMAX DURATION\tDISK\tSCORE\tGPU\tCPU\tRAM\tCOUNTRY\tCOMPLETED\tFAILED")
+		for _, v := range views {
+			fmt.Fprintf(w, "%v\t%v\t%.4f\t%vh\t%vGB\t%v\t%v x%v\t%v\t%.0fGB\t%v\t%v\t%v\n",
+				v.Machine, v.Status, v.Price, v.MaxDuration, v.Disk, v.Score, v.GPU, v.GPUNumber,
+				v.CPU, v.RAM, v.Country, v.Completed, v.Failed)
+		}
+		return w.Flush()
+	case "json":
+		return printJSON(views)
+	default:
+		return fmt.Errorf("unknown format: %v", c.String("format"))
+	}
+}
+
+func listOrders(c *cli.Context) error {
+	var filter super.OrderFilter
+	var err error
+	if c.String("buyer") != "" {
+		if filter.Buyer, err = solana.PublicKeyFromBase58(c.String("buyer")); err != nil {
+			return fmt.Errorf("invalid buyer: %v", err)
+		}
+	}
+	if c.String("seller") != "" {
+		if filter.Seller, err = solana.PublicKeyFromBase58(c.String("seller")); err != nil {
+			return fmt.Errorf("invalid seller: %v", err)
+		}
+	}
+	if c.String("status") != "" {
+		status, err := super.ParseOrderStatus(c.String("status"))
+		if err != nil {
+			return err
+		}
+		filter.Status = &status
+	}
+
+	newConn, err := conn.NewConn(control.NewSolanaConfig())
+	if err != nil {
+		return fmt.Errorf("> NewConn: %v", err)
+	}
+	defer newConn.Close()
+
+	orders, err := super.GetMarketOrders(context.Background(), newConn, solana.MustPublicKeyFromBase58(pattern.PROGRAM_SUPER_ID), filter)
+	if err != nil {
+		return err
+	}
+
+	views := make([]MarketOrderView, 0, len(orders))
+	for _, o := range orders {
+		views = append(views, MarketOrderView{
+			Order:     o.Pubkey.String(),
+			Buyer:     o.Buyer.String(),
+			Seller:    o.Seller.String(),
+			Status:    o.Status.String(),
+			Price:     utils.UnitsToSnt(o.Price),
+			Duration:  o.Duration,
+			Total:     utils.UnitsToSnt(o.Total),
+			OrderTime: time.Unix(o.OrderTime, 0).UTC(),
+		})
+	}
+
+	var compare func(a, b MarketOrderView) int
+	switch c.String("sort") {
+	case "time":
+		compare = func(a, b MarketOrderView) int { return a.OrderTime.Compare(b.OrderTime) }
+	case "price":
+		compare = func(a, b MarketOrderView) int { return cmp.Compare(a.Price, b.Price) }
+	case "total":
+		compare = func(a, b MarketOrderView) int { return cmp.Compare(a.Total, b.Total) }
+	default:
+		return fmt.Errorf("unknown sort: %v", c.String("sort"))
+	}
+	views = sortAndLimit(views, compare, c.Bool("desc"), c.Int("limit"))
+
+	switch c.String("format") {
+	case "table":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ORDER\tSTATUS\tBUYER\tSELLER\tPRICE\tDURATION\tTOTAL\tORDER TIME")
+		for _, v := range views {
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%.4f\t%vh\t%.4f\t%v\n",
+				v.Order, v.Status, v.Buyer, v.Seller, v.Price, v.Duration, v.Total, v.OrderTime.Format(time.DateTime))
+		}
+		return w.Flush()
+	case "json":
+		return printJSON(views)
+	default:
+		return fmt.Errorf("unknown format: %v", c.String("format"))
+	}
+}
+
+func sortAndLimit[T any](views []T, compare func(a, b T) int, desc bool, limit int) []T {
+	slices.SortStableFunc(views, func(a, b T) int {
+		if desc {
+			return compare(b, a)
+		}
+		return compare(a, b)
+	})
+	if limit > 0 && len(views) > limit {
+		views = views[:limit]
+	}
+	return views
+}
+
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
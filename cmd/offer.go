@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"SuperNet-Node/chain/super"
+	"SuperNet-Node/chain/super/distri_ai"
+	"SuperNet-Node/config"
+	"SuperNet-Node/control"
+	"SuperNet-Node/utils"
+	logs "SuperNet-Node/utils/log_utils"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+var offerCommand = cli.Command{
+	Name:  "offer",
+	Usage: "List the machine for rent or take it off the market.",
+	Subcommands: []cli.Command{
+		{
+			Name:  "set",
+			Usage: "Offer the machine for rent, or update the current offer.",
+			Flags: []cli.Flag{
+				&cli.Float64Flag{
+					Name:     "price",
+					Usage:    "Price in SNT per hour.",
+					Required: true,
+				},
+				&cli.UintFlag{
+					Name:     "max-duration",
+					Usage:    "Maximum rental duration in hours.",
+					Required: true,
+				},
+				&cli.UintFlag{
+					Name:  "disk",
+					Usage: "Workspace storage offered in GB. default: free space of the work directory",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				price := c.Float64("price")
+				if price <= 0 {
+					logs.Error(fmt.Sprintf("invalid price: %v", price))
+					return nil
+				}
+				maxDuration := c.Uint("max-duration")
+				if maxDuration == 0 {
+					logs.Error("max-duration must be at least 1 hour")
+					return nil
+				}
+
+				superWrapper, hwInfo, err := control.GetSuper(false)
+				if err != nil {
+					logs.Error(err.Error())
+					return nil
+				}
+
+				disk := c.Uint("disk")
+				if disk == 0 {
+					disk = uint(hwInfo.DiskInfo.TotalSpace)
+				}
+
+				machine, err := superWrapper.GetMachine(context.Background())
+				if err != nil {
+					logs.Error(fmt.Sprintf("GetMachine: %v", err))
+					return nil
+				}
+				if machine.Metadata == "" {
+					logs.Error("Machine does not exist, run `node start` first")
+					return nil
+				}
+				if machine.Status == distri_ai.MachineStatusRenting {
+					logs.Error("Machine is Renting, the offer can be changed once the order ends")
+					return nil
+				}
+
+				logs.Normal(fmt.Sprintf("Offer: %v SNT/h, MaxDuration: %vh, Disk: %vGB", price, maxDuration, disk))
+
+				hash, err := superWrapper.MakeOffer(context.Background(), utils.SntToUnits(price), uint32(maxDuration), uint32(disk))
+				if errors.Is(err, super.ErrAwaitingSignature) {
+					logs.Normal("The offer is set once the signed MakeOffer is submitted")
+				} else if err != nil {
+					logs.Error(fmt.Sprintf("Error block : %v, msg : %v\n", hash, err))
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "cancel",
+			Usage: "Take the machine off the market.",
+			Action: func(c *cli.Context) error {
+				superWrapper, _, err := control.GetSuper(false)
+				if err != nil {
+					logs.Error(err.Error())
+					return nil
+				}
+
+				machine, err := superWrapper.GetMachine(context.Background())
+				if err != nil {
+					logs.Error(fmt.Sprintf("GetMachine: %v", err))
+					return nil
+				}
+				if machine.Status != distri_ai.MachineStatusForRent {
+					logs.Error(fmt.Sprintf("Machine is %v, only a ForRent machine can cancel its offer", machine.Status))
+					return nil
+				}
+
+				hash, err := superWrapper.CancelOffer(context.Background())
+				if errors.Is(err, super.ErrAwaitingSignature) {
+					logs.Normal("The machine is off the market once the signed CancelOffer is submitted")
+				} else if err != nil {
+					logs.Error(fmt.Sprintf("Error block : %v, msg : %v\n", hash, err))
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "reprice",
+			Usage: "Reprice the offer from the offers of comparable machines, as configured under pricing.",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "Show the decision without updating the offer.",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				superWrapper, _, err := control.GetSuper(false)
+				if err != nil {
+					logs.Error(err.Error())
+					return nil
+				}
+
+				decision, err := control.Reprice(context.Background(), superWrapper, config.GlobalConfig.Pricing, time.Now(), c.Bool("dry-run"))
+				if decision != nil {
+					logs.Normal(decision.String())
+				}
+				if err != nil {
+					logs.Error(err.Error())
+				}
+				return nil
+			},
+		},
+	},
+}
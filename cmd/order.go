@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"SuperNet-Node/chain/conn"
+	"SuperNet-Node/chain/super"
+	"SuperNet-Node/chain/super/distri_ai"
+	"SuperNet-Node/config"
+	"SuperNet-Node/control"
+	"SuperNet-Node/utils"
+	logs "SuperNet-Node/utils/log_utils"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/urfave/cli"
+)
+
+// buyerFlags select the key of the buyer.
+var buyerFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "keypair",
+		Usage: "Sign with a Solana CLI keypair file.",
+	},
+	&cli.StringFlag{
+		Name:  "keystore",
+		Usage: "Sign with this keystore. default: base.keystore",
+	},
+}
+
+var OrderCommand = cli.Command{
+	Name:  "order",
+	Usage: "Rent machines of the market as a buyer.",
+	Subcommands: []cli.Command{
+		{
+			Name:      "place",
+			Usage:     "Place an order on a ForRent machine.",
+			ArgsUsage: "<machine account>",
+			Flags: append([]cli.Flag{
+				&cli.UintFlag{
+					Name:     "duration",
+					Usage:    "Rental duration in hours.",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:  "intent",
+					Value: "train",
+					Usage: "train or deploy.",
+				},
+				&cli.StringFlag{
+					Name:  "task-name",
+					Value: "Computing Task",
+					Usage: "Name of the task shown with the order.",
+				},
+				&cli.StringSliceFlag{
+					Name:  "cid",
+					Usage: "IPFS CID of the CID.json listing the files to download. Can be repeated.",
+				},
+			}, buyerFlags...),
+			Action: func(c *cli.Context) error {
+				machine, err := accountArg(c)
+				if err != nil {
+					logs.Error(err.Error())
+					return nil
+				}
+				buyer, err := newBuyer(c)
+				if err != nil {
+					logs.Error(err.Error())
+					return nil
+				}
+				defer buyer.Conn.Close()
+
+				order, _, err := control.PlaceOrder(context.Background(), buyer, machine, uint32(c.Uint("duration")),
+					c.String("intent"), c.String("task-name"), c.StringSlice("cid"))
+				if err != nil {
+					logs.Error(err.Error())
+					return nil
+				}
+				logs.Normal(fmt.Sprintf("Order placed : %v, follow it with `order watch %v`", order, order))
+				return nil
+			},
+		},
+		{
+			Name:      "renew",
+			Usage:     "Extend a Training order.",
+			ArgsUsage: "<order account>",
+			Flags: append([]cli.Flag{
+				&cli.UintFlag{
+					Name:     "duration",
+					Usage:    "Additional hours.",
+					Required: true,
+				},
+			}, buyerFlags...),
+			Action: func(c *cli.Context) error {
+				if c.Uint("duration") == 0 {
+					logs.Error("duration must be at least 1 hour")
+					return nil
+				}
+				return withBuyerOrder(c, func(buyer *super.WrapperBuyer, order super.MarketOrder) error {
+					if order.Status != distri_ai.OrderStatusTraining {
+						return fmt.Errorf("order is %v, only a Training order can be renewed", order.Status)
+					}
+					_, err := buyer.RenewOrder(context.Background(), order.Pubkey, order.Order, uint32(c.Uint("duration")))
+					return err
+				})
+			},
+		},
+		{
+			Name:      "refund",
+			Usage:     "End a Training order early and get the unused hours refunded.",
+			ArgsUsage: "<order account>",
+			Flags:     buyerFlags,
+			Action: func(c *cli.Context) error {
+				return withBuyerOrder(c, func(buyer *super.WrapperBuyer, order super.MarketOrder) error {
+					if order.Status != distri_ai.OrderStatusTraining {
+						return fmt.Errorf("order is %v, only a Training order can be refunded", order.Status)
+					}
+					_, err := buyer.RefundOrder(context.Background(), order.Pubkey, order.Order)
+					return err
+				})
+			},
+		},
+		{
+			Name:      "remove",
+			Usage:     "Close the account of a finished order and get its rent back.",
+			ArgsUsage: "<order account>",
+			Flags:     buyerFlags,
+			Action: func(c *cli.Context) error {
+				return withBuyerOrder(c, func(buyer *super.WrapperBuyer, order super.MarketOrder) error {
+					if !orderFinished(order.Status) {
+						return fmt.Errorf("order is %v, only a finished order can be removed", order.Status)
+					}
+					_, err := buyer.RemoveOrder(context.Background(), order.Pubkey)
+					return err
+				})
+			},
+		},
+		{
+			Name:      "watch",
+			Usage:     "Follow the status of an order until it ends.",
+			ArgsUsage: "<order account>",
+			Action: func(c *cli.Context) error {
+				if err := watchOrder(c); err != nil {
+					logs.Error(err.Error())
+				}
+				return nil
+			},
+		},
+	},
+}
+
+func newBuyer(c *cli.Context) (*super.WrapperBuyer, error) {
+	keystore := c.String("keystore")
+	if keystore == "" {
+		keystore = config.GlobalConfig.Base.Keystore
+	}
+	return control.NewBuyer(c.String("keypair"), keystore)
+}
+
+func accountArg(c *cli.Context) (solana.PublicKey, error) {
+	if c.NArg() != 1 {
+		return solana.PublicKey{}, fmt.Errorf("specify the account")
+	}
+	account, err := solana.PublicKeyFromBase58(c.Args().First())
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("invalid account: %v", err)
+	}
+	return account, nil
+}
+
+// withBuyerOrder runs fn with the order given as argument, after checking it belongs to the buyer.
+func withBuyerOrder(c *cli.Context, fn func(buyer *super.WrapperBuyer, order super.MarketOrder) error) error {
+	account, err := accountArg(c)
+	if err != nil {
+		logs.Error(err.Error())
+		return nil
+	}
+	buyer, err := newBuyer(c)
+	if err != nil {
+		logs.Error(err.Error())
+		return nil
+	}
+	defer buyer.Conn.Close()
+
+	order, err := super.GetMarketOrder(context.Background(), buyer.Conn, account)
+	if err != nil {
+		if errors.Is(err, rpc.ErrNotFound) {
+			logs.Error(fmt.Sprintf("Order %v does not exist", account))
+		} else {
+			logs.Error(fmt.Sprintf("GetMarketOrder: %v", err))
+		}
+		return nil
+	}
+	if !order.Buyer.Equals(buyer.Buyer.PublicKey()) {
+		logs.Error(fmt.Sprintf("Order %v was placed by %v, not %v", account, order.Buyer, buyer.Buyer.PublicKey()))
+		return nil
+	}
+	if err := fn(buyer, order); err != nil {
+		logs.Error(err.Error())
+	}
+	return nil
+}
+
+func watchOrder(c *cli.Context) error {
+	account, err := accountArg(c)
+	if err != nil {
+		return err
+	}
+	newConn, err := conn.NewConn(control.NewSolanaConfig())
+	if err != nil {
+		return fmt.Errorf("> NewConn: %v", err)
+	}
+	defer newConn.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Watching needs no key.
+	watcher := super.NewBuyerWrapper(newConn, nil, nil)
+	var last *distri_ai.Order
+	for order := range watcher.WatchOrder(ctx, account) {
+		if last == nil || last.Status != order.Status || last.Duration != order.Duration {
+			logs.Normal(orderSummary(order))
+		}
+		last = &order
+		if orderFinished(order.Status) {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
+
+func orderSummary(order distri_ai.Order) string {
+	summary := fmt.Sprintf("Order is %v, %vh at %.4f SNT/h, total %.4f SNT",
+		order.Status, order.Duration, utils.UnitsToSnt(order.Price), utils.UnitsToSnt(order.Total))
+	if order.StartTime > 0 {
+		start := time.Unix(order.StartTime, 0)
+		summary += fmt.Sprintf(", started %v, ends %v",
+			start.Format(time.DateTime), start.Add(time.Duration(order.Duration)*time.Hour).Format(time.DateTime))
+	}
+	return summary
+}
+
+func orderFinished(status distri_ai.OrderStatus) bool {
+	return status == distri_ai.OrderStatusCompleted ||
+		status == distri_ai.OrderStatusFailed ||
+		status == distri_ai.OrderStatusRefunded
+}
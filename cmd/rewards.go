@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"SuperNet-Node/control"
+	"SuperNet-Node/daemon"
+	dbutils "SuperNet-Node/utils/db_utils"
+	logs "SuperNet-Node/utils/log_utils"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+var rewardsCommand = cli.Command{
+	Name:  "rewards",
+	Usage: "Claim the periodic and task rewards earned by the machine.",
+	Subcommands: []cli.Command{
+		{
+			Name:  "claim",
+			Usage: "Claim the rewards of one finished period, or of every unclaimed one.",
+			Flags: []cli.Flag{
+				&cli.UintFlag{
+					Name:  "period",
+					Usage: "Period to claim.",
+				},
+				&cli.BoolFlag{
+					Name:  "all",
+					Usage: "Claim every finished period that is not claimed yet.",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				if c.IsSet("period") == c.Bool("all") {
+					logs.Error("specify either --period or --all")
+					return nil
+				}
+
+				period := uint32(c.Uint("period"))
+
+				// The running node holds the database the claims are recorded in, so it sends them.
+				var records []control.ClaimRecord
+				var err error
+				if c.Bool("all") {
+					records, err = daemonClient().ClaimAll()
+				} else {
+					var record control.ClaimRecord
+					record, err = daemonClient().Claim(period)
+					records = []control.ClaimRecord{record}
+				}
+				if errors.Is(err, daemon.ErrNotRunning) {
+					records, err = claimRewards(c.Bool("all"), period)
+				}
+				if err != nil {
+					logs.Error(err.Error())
+					return nil
+				}
+
+				if len(records) == 0 {
+					logs.Normal("No unclaimed rewards")
+				}
+				for _, record := range records {
+					if record.Error != "" {
+						logs.Error(fmt.Sprintf("Period %v : %v", record.Period, record.Error))
+					} else {
+						logs.Normal(fmt.Sprintf("Period %v : %v", record.Period, record.Signature))
+					}
+				}
+				return nil
+			},
+		},
+	},
+}
+
+// claimRewards claims the rewards when no node is running, opening the database the claims are
+// recorded in before any transaction is sent.
+func claimRewards(all bool, period uint32) ([]control.ClaimRecord, error) {
+	if _, err := dbutils.OpenDB(); err != nil {
+		return nil, fmt.Errorf("> OpenDB: %v", err)
+	}
+	defer dbutils.CloseDB()
+
+	superWrapper, _, err := control.GetSuper(false)
+	if err != nil {
+		return nil, err
+	}
+	if all {
+		return control.ClaimUnclaimed(context.Background(), superWrapper)
+	}
+
+	sig, err := control.ClaimPeriod(context.Background(), superWrapper, period)
+	if err != nil {
+		return nil, fmt.Errorf("Error block : %v, msg : %v", sig, err)
+	}
+	return []control.ClaimRecord{{Period: period, Signature: sig, Time: time.Now()}}, nil
+}
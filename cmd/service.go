@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"SuperNet-Node/chain/wallet"
+	"SuperNet-Node/config"
+	logs "SuperNet-Node/utils/log_utils"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/urfave/cli"
+)
+
+// serviceUnit runs node start in the directory of config.yml, where the database, the logs
+// and the control socket live. SIGTERM lets the node finish the transaction in progress.
+// The node runs as root by default: it writes the nginx configuration and restarts nginx.
+var serviceUnit = template.Must(template.New("unit").Parse(`[Unit]
+Description=SuperNet node
+Wants=network-online.target
+After=network-online.target docker.service podman.socket
+
+[Service]
+Type=simple
+User={{.User}}
+WorkingDirectory={{.Dir}}
+{{- if .EnvironmentFile}}
+EnvironmentFile={{.EnvironmentFile}}
+{{- end}}
+ExecStart={{.Executable}} node start
+ExecReload={{.Executable}} node reload
+KillSignal=SIGTERM
+TimeoutStopSec={{.StopTimeout}}
+Restart=on-failure
+RestartSec=10
+
+[Install]
+WantedBy=multi-user.target
+`))
+
+var installServiceCommand = cli.Command{
+	Name:  "install-service",
+	Usage: "Write a systemd unit running `node start` from this directory.",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "name",
+			Value: "supernet-node",
+			Usage: "Name of the service.",
+		},
+		&cli.StringFlag{
+			Name:  "user",
+			Value: "root",
+			Usage: "User running the node, which needs root to configure nginx and the model directory.",
+		},
+		&cli.StringFlag{
+			Name: "environment-file",
+			Usage: "File of environment variables for the node, such as " + wallet.PassphraseEnv +
+				" to unlock an encrypted keystore without a prompt.",
+		},
+		&cli.StringFlag{
+			Name:  "output",
+			Usage: "Path of the unit, - prints it. default: /etc/systemd/system/<name>.service",
+		},
+		&cli.UintFlag{
+			Name:  "stop-timeout",
+			Value: 180,
+			Usage: "Seconds systemd waits for the node to stop before killing it, covering a completion on exit.",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		executable, err := os.Executable()
+		if err == nil {
+			executable, err = filepath.EvalSymlinks(executable)
+		}
+		if err != nil {
+			logs.Error(fmt.Sprintf("Executable: %v", err))
+			return nil
+		}
+		dir, err := os.Getwd()
+		if err != nil {
+			logs.Error(fmt.Sprintf("Getwd: %v", err))
+			return nil
+		}
+		if _, err := os.Stat(filepath.Join(dir, "config.yml")); err != nil {
+			logs.Warning(fmt.Sprintf("No config.yml in %v, the node reads it from its working directory", dir))
+		}
+
+		environmentFile := c.String("environment-file")
+		if environmentFile != "" {
+			if environmentFile, err = filepath.Abs(environmentFile); err != nil {
+				logs.Error(fmt.Sprintf("Abs: %v", err))
+				return nil
+			}
+		} else if base := config.GlobalConfig.Base; !config.GlobalConfig.Offline.Enabled && base.PrivateKey == "" && base.PasswordFile == "" {
+			// The owner key is read from the keystore, there is no terminal to prompt for its passphrase.
+			logs.Warning(fmt.Sprintf("The keystore cannot be unlocked without a prompt: set base.passwordFile, "+
+				"or %v in a file passed with --environment-file", wallet.PassphraseEnv))
+		}
+
+		var unit bytes.Buffer
+		err = serviceUnit.Execute(&unit, struct {
+			User            string
+			Dir             string
+			Executable      string
+			EnvironmentFile string
+			StopTimeout     uint
+		}{c.String("user"), dir, executable, environmentFile, c.Uint("stop-timeout")})
+		if err != nil {
+			logs.Error(fmt.Sprintf("Execute unit template: %v", err))
+			return nil
+		}
+
+		name := c.String("name")
+		output := c.String("output")
+		if output == "-" {
+			fmt.Print(unit.String())
+			return nil
+		}
+		if output == "" {
+			output = fmt.Sprintf("/etc/systemd/system/%v.service", name)
+		}
+		if err := os.WriteFile(output, unit.Bytes(), 0644); err != nil {
+			logs.Error(fmt.Sprintf("Write unit: %v", err))
+			return nil
+		}
+		logs.Normal(fmt.Sprintf("Wrote %v, start the node with:", output))
+		logs.Normal(fmt.Sprintf("  sudo systemctl daemon-reload && sudo systemctl enable --now %v", name))
+		return nil
+	},
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"SuperNet-Node/chain/super"
+	"SuperNet-Node/config"
+	"SuperNet-Node/simulate"
+	dbutils "SuperNet-Node/utils/db_utils"
+	logs "SuperNet-Node/utils/log_utils"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+var simulateCommand = cli.Command{
+	Name: "simulate",
+	Usage: "Rehearse the order lifecycle against a fake chain, a fake container runtime and a local IPFS, " +
+		"with accelerated time, and report the transitions and transactions of every scenario.",
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "scenario",
+			Usage: "Scenario to run, repeated or comma separated. default: all",
+		},
+		&cli.DurationFlag{
+			Name:  "hour",
+			Value: 10 * time.Second,
+			Usage: "Real time standing for an hour of the orders.",
+		},
+		&cli.DurationFlag{
+			Name:  "outage",
+			Value: 10 * time.Second,
+			Usage: "How long the RPC stays down in the rpc-outage scenario.",
+		},
+		&cli.StringFlag{
+			Name:  "dir",
+			Usage: "Directory of the workspaces, the database and the node log. default: a temporary directory",
+		},
+		&cli.BoolFlag{
+			Name:  "verbose",
+			Usage: "Print the node log instead of writing it to node.log.",
+		},
+		&cli.BoolFlag{
+			Name:  "list",
+			Usage: "List the scenarios and exit.",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.Bool("list") {
+			for _, scenario := range simulate.Scenarios {
+				fmt.Printf("%-18v %v\n", scenario.Name, scenario.Description)
+			}
+			return nil
+		}
+
+		var names []string
+		for _, value := range c.StringSlice("scenario") {
+			for _, name := range strings.Split(value, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					names = append(names, name)
+				}
+			}
+		}
+
+		dir := c.String("dir")
+		if dir == "" {
+			var err error
+			dir, err = os.MkdirTemp("", "supernet-simulate-")
+			if err != nil {
+				logs.Error(fmt.Sprintf("MkdirTemp: %v", err))
+				return nil
+			}
+		} else if err := os.MkdirAll(dir, 0755); err != nil {
+			logs.Error(fmt.Sprintf("MkdirAll: %v", err))
+			return nil
+		}
+
+		// The node keeps its orders in the database, which must not be the one of a real node.
+		dbutils.SetPath(filepath.Join(dir, "badger"))
+		defer dbutils.CloseDB()
+		config.GlobalConfig.Console.AuditLog = filepath.Join(dir, "audit")
+		if err := super.OpenAuditLog(); err != nil {
+			logs.Error(fmt.Sprintf("OpenAuditLog: %v", err))
+			return nil
+		}
+		defer super.CloseAuditLog()
+
+		var warnings []time.Duration
+		for _, s := range config.GlobalConfig.Order.Warnings {
+			if warning, err := time.ParseDuration(s); err == nil {
+				warnings = append(warnings, warning)
+			}
+		}
+
+		out := os.Stdout
+		if !c.Bool("verbose") {
+			// The node logs to stdout, a node log keeps the report readable.
+			logFile, err := os.Create(filepath.Join(dir, "node.log"))
+			if err != nil {
+				logs.Error(fmt.Sprintf("Create node log: %v", err))
+				return nil
+			}
+			defer logFile.Close()
+			os.Stdout = logFile
+			defer func() { os.Stdout = out }()
+		}
+
+		fmt.Fprintf(out, "Simulating in %v, an hour lasts %v\n", dir, c.Duration("hour"))
+		reports, err := simulate.Run(names, simulate.Options{
+			Hour:     c.Duration("hour"),
+			Warnings: warnings,
+			Outage:   c.Duration("outage"),
+			Dir:      dir,
+			Out:      out,
+		})
+		if err != nil {
+			return err
+		}
+
+		failed := 0
+		for _, report := range reports {
+			if !report.Passed {
+				failed++
+			}
+		}
+		fmt.Fprintf(out, "%v of %v scenarios passed\n", len(reports)-failed, len(reports))
+		if failed > 0 {
+			// A failed rehearsal must fail the release script running it.
+			return cli.NewExitError("", 1)
+		}
+		return nil
+	},
+}
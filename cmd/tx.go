@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"SuperNet-Node/chain/conn"
+	"SuperNet-Node/chain/super"
+	"SuperNet-Node/chain/super/distri_ai"
+	"SuperNet-Node/chain/wallet"
+	"SuperNet-Node/config"
+	"SuperNet-Node/control"
+	"SuperNet-Node/pattern"
+	logs "SuperNet-Node/utils/log_utils"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/urfave/cli"
+)
+
+var TxCommand = cli.Command{
+	Name:  "tx",
+	Usage: "Inspect transactions, and sign and submit the ones emitted by a node running in offline mode.",
+	Subcommands: []cli.Command{
+		{
+			Name: "inspect",
+			Usage: "Decode a transaction, its program error, its balance changes and the Machine and Order accounts it " +
+				"writes. A landed transaction shows those accounts as they are now, only --in gives the state diff.",
+			ArgsUsage: "<signature>",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "in",
+					Usage: "Inspect a transaction file from the outbox instead, simulating it against the current state.",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				var sig solana.Signature
+				var envelope *super.Envelope
+				var err error
+				if c.String("in") != "" {
+					envelope, err = super.ReadEnvelope(c.String("in"))
+				} else if c.NArg() == 1 {
+					sig, err = solana.SignatureFromBase58(c.Args().First())
+				} else {
+					err = fmt.Errorf("specify a signature or --in")
+				}
+				if err != nil {
+					logs.Error(err.Error())
+					return nil
+				}
+
+				newConn, err := conn.NewConn(control.NewSolanaConfig())
+				if err != nil {
+					logs.Error(fmt.Sprintf("NewConn: %v", err))
+					return nil
+				}
+				defer newConn.Close()
+
+				distri_ai.SetProgramID(solana.MustPublicKeyFromBase58(pattern.PROGRAM_SUPER_ID))
+				var inspection *super.Inspection
+				if envelope != nil {
+					inspection, err = super.InspectEnvelope(context.Background(), newConn, envelope)
+				} else {
+					inspection, err = super.InspectSignature(context.Background(), newConn, sig)
+				}
+				if err != nil {
+					logs.Error(err.Error())
+					return nil
+				}
+				fmt.Println(inspection)
+				return nil
+			},
+		},
+		{
+			Name: "sign",
+			Usage: "Sign a transaction from the outbox with the owner key. " +
+				"Needs no network access, so it can run on an air-gapped machine.",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "in",
+					Usage: "Unsigned transaction file written to the outbox.",
+				},
+				&cli.StringFlag{
+					Name:  "out",
+					Usage: "Where to write the signed transaction. Defaults to --in.",
+				},
+				&cli.StringFlag{
+					Name:  "keypair",
+					Usage: "Sign with a Solana CLI keypair file instead of the keystore.",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				out := c.String("out")
+				if out == "" {
+					out = c.String("in")
+				}
+				if err := signEnvelope(c.String("in"), out, c.String("keypair")); err != nil {
+					logs.Error(err.Error())
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "submit",
+			Usage: "Broadcast a signed transaction and wait for its confirmation.",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "in",
+					Usage: "Signed transaction file.",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				envelope, err := super.ReadEnvelope(c.String("in"))
+				if err != nil {
+					logs.Error(fmt.Sprintf("ReadEnvelope: %v", err))
+					return nil
+				}
+
+				newConn, err := conn.NewConn(control.NewSolanaConfig())
+				if err != nil {
+					logs.Error(fmt.Sprintf("NewConn: %v", err))
+					return nil
+				}
+				defer newConn.Close()
+
+				if _, err := super.SubmitEnvelope(context.Background(), newConn, envelope); err != nil {
+					logs.Error(err.Error())
+				}
+				return nil
+			},
+		},
+	},
+}
+
+// signEnvelope prints the transaction of the envelope in path and signs it with the owner key.
+func signEnvelope(in, out, keypair string) error {
+	envelope, err := super.ReadEnvelope(in)
+	if err != nil {
+		return fmt.Errorf("> ReadEnvelope: %v", err)
+	}
+	transaction, err := envelope.DecodeTransaction()
+	if err != nil {
+		return err
+	}
+
+	// Show what is about to be signed, with the market instructions decoded.
+	distri_ai.SetProgramID(solana.MustPublicKeyFromBase58(pattern.PROGRAM_SUPER_ID))
+	fmt.Printf("%v for machine %v, owner %v, created %v\n",
+		envelope.Name, envelope.Machine, envelope.Owner, envelope.CreatedAt.Format("2006-01-02 15:04:05"))
+	fmt.Println(transaction.String())
+
+	var key solana.PrivateKey
+	if keypair != "" {
+		key, err = wallet.ReadKeypairFile(keypair)
+	} else {
+		key, err = wallet.Unlock(config.GlobalConfig.Base.Keystore, config.GlobalConfig.Base.PasswordFile)
+	}
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(envelope.Missing, key.PublicKey().String()) {
+		return fmt.Errorf("%v is not a missing signer of %v", key.PublicKey(), envelope.Name)
+	}
+
+	missing, err := conn.SignPartial(transaction, []conn.Signer{key})
+	if err != nil {
+		return fmt.Errorf("> SignPartial: %v", err)
+	}
+	if err := envelope.SetTransaction(transaction, missing); err != nil {
+		return err
+	}
+	if err := envelope.Save(out); err != nil {
+		return fmt.Errorf("> Save: %v", err)
+	}
+
+	if len(missing) > 0 {
+		logs.Warning(fmt.Sprintf("%v still needs the signatures of %v", envelope.Name, missing))
+	}
+	logs.Normal(fmt.Sprintf("Signed transaction written to %v", out))
+	return nil
+}
@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"SuperNet-Node/chain/wallet"
+	"SuperNet-Node/config"
+	"SuperNet-Node/server"
+	logs "SuperNet-Node/utils/log_utils"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/urfave/cli"
+)
+
+var WalletCommand = cli.Command{
+	Name:  "wallet",
+	Usage: "Manage the encrypted keystore holding the machine owner key.",
+	Subcommands: []cli.Command{
+		{
+			Name:  "create",
+			Usage: "Generate a new key and store it encrypted in the keystore.",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "force",
+					Usage: "Overwrite an existing keystore.",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				key, err := solana.NewRandomPrivateKey()
+				if err != nil {
+					logs.Error(fmt.Sprintf("NewRandomPrivateKey: %v", err))
+					return nil
+				}
+				if err := saveKeystore(key, c.Bool("force")); err != nil {
+					logs.Error(err.Error())
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "import",
+			Usage: "Import an existing key into the keystore.",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name: "base58",
+					Usage: "Read a private key encoded in base58 from a hidden prompt, or from stdin. " +
+						"With the key on stdin, the passphrase is read from " + wallet.PassphraseEnv + " or base.passwordFile.",
+				},
+				&cli.StringFlag{
+					Name:  "keypair",
+					Usage: "Path of a Solana CLI keypair file (id.json).",
+				},
+				&cli.BoolFlag{
+					Name:  "from-config",
+					Usage: "Import base.privateKey from config.yml.",
+				},
+				&cli.BoolFlag{
+					Name:  "force",
+					Usage: "Overwrite an existing keystore.",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				if c.NArg() > 0 {
+					logs.Error("A key on the command line ends up in the shell history, enter it at the --base58 prompt instead")
+					return nil
+				}
+				var key solana.PrivateKey
+				var err error
+				switch {
+				case c.Bool("base58"):
+					var secret []byte
+					if secret, err = wallet.ReadSecret("Private key (base58): "); err == nil {
+						key, err = wallet.ParsePrivateKey(string(secret))
+					}
+				case c.String("keypair") != "":
+					key, err = wallet.ReadKeypairFile(c.String("keypair"))
+				case c.Bool("from-config"):
+					key, err = wallet.ParsePrivateKey(config.GlobalConfig.Base.PrivateKey)
+				default:
+					err = fmt.Errorf("specify --base58, --keypair or --from-config")
+				}
+				if err != nil {
+					logs.Error(err.Error())
+					return nil
+				}
+
+				if err := saveKeystore(key, c.Bool("force")); err != nil {
+					logs.Error(err.Error())
+					return nil
+				}
+				if config.GlobalConfig.Base.PrivateKey != "" {
+					logs.Warning("Remove base.privateKey from config.yml, it takes precedence over the keystore")
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "export-pubkey",
+			Usage: "Print the public key of the keystore.",
+			Action: func(c *cli.Context) error {
+				ks, err := wallet.LoadKeystore(config.GlobalConfig.Base.Keystore)
+				if err != nil {
+					logs.Error(err.Error())
+					return nil
+				}
+				fmt.Println(ks.PublicKey)
+				return nil
+			},
+		},
+		{
+			Name: "sign-api",
+			Usage: "Print a signature of the owner key for the owner endpoints of the node API, such as /earnings and /history, " +
+				"passed as the signature query parameter. It is valid for 15 to 30 minutes; with an offline owner key, run it where the keystore is.",
+			Action: func(c *cli.Context) error {
+				var key solana.PrivateKey
+				var err error
+				if config.GlobalConfig.Base.PrivateKey != "" {
+					key, err = wallet.ParsePrivateKey(config.GlobalConfig.Base.PrivateKey)
+				} else {
+					key, err = wallet.Unlock(config.GlobalConfig.Base.Keystore, config.GlobalConfig.Base.PasswordFile)
+				}
+				if err != nil {
+					logs.Error(err.Error())
+					return nil
+				}
+
+				signature, err := key.Sign([]byte(server.OwnerMessage(key.PublicKey(), time.Now())))
+				if err != nil {
+					logs.Error(fmt.Sprintf("Sign: %v", err))
+					return nil
+				}
+				fmt.Println(signature)
+				return nil
+			},
+		},
+		{
+			Name: "rotate",
+			Usage: "Replace the key of the keystore with a new one, keeping a backup of the old keystore. " +
+				"The machine is registered under the owner key, so remove it from the market before rotating.",
+			Action: func(c *cli.Context) error {
+				path := config.GlobalConfig.Base.Keystore
+				old, err := wallet.Unlock(path, config.GlobalConfig.Base.PasswordFile)
+				if err != nil {
+					logs.Error(err.Error())
+					return nil
+				}
+
+				key, err := solana.NewRandomPrivateKey()
+				if err != nil {
+					logs.Error(fmt.Sprintf("NewRandomPrivateKey: %v", err))
+					return nil
+				}
+				ks, err := encryptKeystore(key)
+				if err != nil {
+					logs.Error(err.Error())
+					return nil
+				}
+				// The new keystore is written before the old one is moved, so that a failure
+				// never leaves the node without a keystore.
+				pending := path + ".new"
+				if err := ks.Save(pending); err != nil {
+					logs.Error(fmt.Sprintf("Save new keystore: %v", err))
+					return nil
+				}
+
+				backup := fmt.Sprintf("%v.%v.bak", path, time.Now().Unix())
+				if err := os.Rename(path, backup); err != nil {
+					logs.Error(fmt.Sprintf("Backup keystore: %v, the new keystore is left in %v", err, pending))
+					return nil
+				}
+				if err := os.Rename(pending, path); err != nil {
+					logs.Error(fmt.Sprintf("Install new keystore: %v", err))
+					if err := os.Rename(backup, path); err != nil {
+						logs.Error(fmt.Sprintf("Restore keystore: %v, the old keystore is in %v and the new one in %v", err, backup, pending))
+					}
+					return nil
+				}
+				logs.Normal(fmt.Sprintf("Old keystore of %v kept in %v", old.PublicKey(), backup))
+				logs.Normal(fmt.Sprintf("Keystore %v saved, public key: %v", path, ks.PublicKey))
+				return nil
+			},
+		},
+	},
+}
+
+// saveKeystore encrypts key with a passphrase read from the user and writes it to the configured keystore.
+func saveKeystore(key solana.PrivateKey, force bool) error {
+	path := config.GlobalConfig.Base.Keystore
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("keystore %v already exists, use --force to overwrite it", path)
+	}
+
+	ks, err := encryptKeystore(key)
+	if err != nil {
+		return err
+	}
+	if err := ks.Save(path); err != nil {
+		return fmt.Errorf("> Save: %v", err)
+	}
+
+	logs.Normal(fmt.Sprintf("Keystore %v saved, public key: %v", path, ks.PublicKey))
+	return nil
+}
+
+// encryptKeystore encrypts key with a passphrase read from the user.
+func encryptKeystore(key solana.PrivateKey) (*wallet.Keystore, error) {
+	passphrase, err := wallet.ReadPassphrase(config.GlobalConfig.Base.PasswordFile, true)
+	if err != nil {
+		return nil, fmt.Errorf("> ReadPassphrase: %v", err)
+	}
+	ks, err := wallet.EncryptKey(key, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("> EncryptKey: %v", err)
+	}
+	return ks, nil
+}
@@ -6,15 +6,21 @@ import (
 	logs "SuperNet-Node/utils/log_utils"
 	"fmt"
 	"os"
+	"sync"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
 	Base struct {
-		Rpc           string `yaml:"rpc"`
-		PrivateKey    string `yaml:"privateKey"`
-		SecurityLevel string `yaml:"securityLevel"`
+		Rpc           string   `yaml:"rpc"`
+		Rpcs          []string `yaml:"rpcs"`
+		Broadcast     int      `yaml:"broadcast"`
+		Ws            string   `yaml:"ws"`
+		PrivateKey    string   `yaml:"privateKey"`
+		Keystore      string   `yaml:"keystore"`
+		PasswordFile  string   `yaml:"passwordFile"`
+		SecurityLevel string   `yaml:"securityLevel"`
 	} `yaml:"base"`
 	Console struct {
 		WorkDirectory string `yaml:"workDirectory"`
@@ -26,53 +32,262 @@ type Config struct {
 		ExpandPort1   string `yaml:"publicPortExpand1"`
 		ExpandPort2   string `yaml:"publicPortExpand2"`
 		ExpandPort3   string `yaml:"publicPortExpand3"`
+		// ControlSocket is the Unix socket of the running node, for node stop, status, pause and reload.
+		ControlSocket string `yaml:"controlSocket"`
+		// AuditLog is the directory of the audit log of the transactions sent by the node.
+		AuditLog string `yaml:"auditLog"`
 	} `yaml:"console"`
+	Reward      RewardConfig      `yaml:"reward"`
+	PriorityFee PriorityFeeConfig `yaml:"priorityFee"`
+	FeePayer    FeePayerConfig    `yaml:"feePayer"`
+	Offline     OfflineConfig     `yaml:"offline"`
+	Pricing     PricingConfig     `yaml:"pricing"`
+	Order       OrderConfig       `yaml:"order"`
+	Runtime     RuntimeConfig     `yaml:"runtime"`
+}
+
+// RewardConfig configures the claim of the rewards earned by the machine.
+type RewardConfig struct {
+	AutoClaim bool `yaml:"autoClaim"`
+}
+
+// RuntimeConfig selects the container engine running the score, workspace and deploy containers.
+type RuntimeConfig struct {
+	// Engine is docker, podman or fake, the latter running nothing.
+	Engine string `yaml:"engine"`
+	// Host is the API socket of the engine, e.g. "unix:///run/user/1000/podman/podman.sock";
+	// empty uses DOCKER_HOST for docker and the rootful socket for podman.
+	Host string `yaml:"host"`
+}
+
+// OrderConfig configures the notifications sent while an order is served.
+type OrderConfig struct {
+	// Warnings are the durations before the end of an order at which the buyer is warned, e.g. "30m".
+	Warnings []string `yaml:"warnings"`
+	// Webhook receives every order event as a JSON POST; empty disables it.
+	Webhook string `yaml:"webhook"`
+	// GracePeriod keeps the workspace after an order, with its container stopped, for the buyer
+	// to retrieve the results, e.g. "1h"; "0", the default, wipes it as soon as the order ends.
+	GracePeriod string `yaml:"gracePeriod"`
+	// CompleteOnExit completes a running order when the node is stopped, instead of leaving
+	// its container running for the next start to resume it.
+	CompleteOnExit bool `yaml:"completeOnExit"`
+}
+
+// PricingConfig drives the automatic repricing of the offer from the prices of comparable machines.
+type PricingConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Interval between two repricings, in minutes.
+	Interval int `yaml:"interval"`
+	// Undercut is the percentage below the median price of the comparable offers.
+	Undercut float64 `yaml:"undercut"`
+	// Floor and Ceiling bound the price, in SNT per hour; 0 means no bound.
+	Floor   float64 `yaml:"floor"`
+	Ceiling float64 `yaml:"ceiling"`
+	// TFLOPSTolerance is the percentage of TFLOPS difference for machines with another GPU to be comparable.
+	TFLOPSTolerance float64 `yaml:"tflopsTolerance"`
+	// MinComparables is the number of comparable offers needed to reprice.
+	MinComparables int `yaml:"minComparables"`
+	// MinChange is the percentage of price change below which the offer is left as is.
+	MinChange float64 `yaml:"minChange"`
+	// TimeOfDay multiplies the price during hours of the local day; From > To wraps around midnight.
+	TimeOfDay []struct {
+		From       int     `yaml:"from"`
+		To         int     `yaml:"to"`
+		Multiplier float64 `yaml:"multiplier"`
+	} `yaml:"timeOfDay"`
+	// Utilization multiplies the price by the share of comparable machines that are Renting.
+	Utilization struct {
+		High           float64 `yaml:"high"`
+		HighMultiplier float64 `yaml:"highMultiplier"`
+		Low            float64 `yaml:"low"`
+		LowMultiplier  float64 `yaml:"lowMultiplier"`
+	} `yaml:"utilization"`
+}
+
+// OfflineConfig keeps the owner key off the node: owner transactions are built on a durable
+// nonce, written unsigned to the outbox, signed elsewhere and submitted back through the inbox.
+type OfflineConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Owner is the public key of the machine owner.
+	Owner string `yaml:"owner"`
+	// NonceAccount is the durable nonce account the transactions are built on.
+	NonceAccount string `yaml:"nonceAccount"`
+	// Outbox is the directory unsigned transactions are written to.
+	Outbox string `yaml:"outbox"`
+	// OutboxURL, when set, also receives every unsigned transaction as a JSON POST.
+	OutboxURL string `yaml:"outboxUrl"`
+	// Inbox is the directory polled for signed transactions to submit.
+	Inbox string `yaml:"inbox"`
+}
+
+// FeePayerConfig selects the account paying transaction fees instead of the owner key.
+// At most one of Remote, Keystore and Keypair is used, in that order.
+type FeePayerConfig struct {
+	Remote struct {
+		URL       string `yaml:"url"`
+		PublicKey string `yaml:"publicKey"`
+		Token     string `yaml:"token"`
+	} `yaml:"remote"`
+	// Keystore is unlocked with the same passphrase as the owner keystore.
+	Keystore string `yaml:"keystore"`
+	// Keypair is a Solana CLI keypair file (id.json).
+	Keypair string `yaml:"keypair"`
+}
+
+// PriorityFeeConfig controls the ComputeBudget instructions prepended to node transactions.
+type PriorityFeeConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// ComputeUnitLimit of every transaction; 0 derives it from the simulated units.
+	ComputeUnitLimit uint32 `yaml:"computeUnitLimit"`
+	// ComputeUnitPrice in micro-lamports; 0 derives it from getRecentPrioritizationFees.
+	ComputeUnitPrice uint64 `yaml:"computeUnitPrice"`
+	// Percentile of the recent prioritization fees used when the price is derived.
+	Percentile int `yaml:"percentile"`
+	// MaxFee caps the priority fee of a transaction, in lamports; 0 means no cap.
+	MaxFee uint64 `yaml:"maxFee"`
 }
 
 var GlobalConfig Config
 
+// liveMu guards the order, reward and pricing sections of GlobalConfig, which node reload
+// replaces while the node runs; the node reads them with CurrentOrder, CurrentReward and CurrentPricing.
+var liveMu sync.RWMutex
+
+// CurrentOrder returns the order section of the running configuration.
+func CurrentOrder() OrderConfig {
+	liveMu.RLock()
+	defer liveMu.RUnlock()
+	return GlobalConfig.Order
+}
+
+// CurrentReward returns the reward section of the running configuration.
+func CurrentReward() RewardConfig {
+	liveMu.RLock()
+	defer liveMu.RUnlock()
+	return GlobalConfig.Reward
+}
+
+// CurrentPricing returns the pricing section of the running configuration.
+func CurrentPricing() PricingConfig {
+	liveMu.RLock()
+	defer liveMu.RUnlock()
+	return GlobalConfig.Pricing
+}
+
+// SetLive replaces the sections of the running configuration that node reload applies at once.
+func SetLive(cfg Config) {
+	liveMu.Lock()
+	defer liveMu.Unlock()
+	GlobalConfig.Order = cfg.Order
+	GlobalConfig.Reward = cfg.Reward
+	GlobalConfig.Pricing = cfg.Pricing
+}
+
 func InitializeConfig() {
-	data, err := os.ReadFile("config.yml")
+	cfg, err := LoadConfig()
 	if err != nil {
 		logs.Error(fmt.Sprintf("Error reading config file: %v", err))
 	}
-	err = yaml.Unmarshal([]byte(data), &GlobalConfig)
-	if err != nil {
-		logs.Error(fmt.Sprintf("Error reading config file: %v", err))
+	GlobalConfig = cfg
+}
+
+// LoadConfig reads config.yml and fills in the defaults. The defaults are returned
+// along with the error when the file cannot be read.
+func LoadConfig() (Config, error) {
+	var cfg Config
+	data, err := os.ReadFile("config.yml")
+	if err == nil {
+		err = yaml.Unmarshal(data, &cfg)
 	}
+	setDefaults(&cfg)
+	return cfg, err
+}
 
-	if GlobalConfig.Console.WorkDirectory != "" {
-		GlobalConfig.Console.WorkDirectory = utils.RemoveTrailingSlash(GlobalConfig.Console.WorkDirectory)
-		GlobalConfig.Console.WorkDirectory = utils.EnsureLeadingSlash(GlobalConfig.Console.WorkDirectory)
+func setDefaults(cfg *Config) {
+	if cfg.Console.WorkDirectory != "" {
+		cfg.Console.WorkDirectory = utils.RemoveTrailingSlash(cfg.Console.WorkDirectory)
+		cfg.Console.WorkDirectory = utils.EnsureLeadingSlash(cfg.Console.WorkDirectory)
 	}
 
-	if GlobalConfig.Console.IpfsNodeUrl == "" {
-		GlobalConfig.Console.IpfsNodeUrl = pattern.DefaultIpfsNode
+	if cfg.Console.IpfsNodeUrl == "" {
+		cfg.Console.IpfsNodeUrl = pattern.DefaultIpfsNode
 	} else {
-		GlobalConfig.Console.IpfsNodeUrl = utils.RemoveTrailingSlash(GlobalConfig.Console.IpfsNodeUrl)
+		cfg.Console.IpfsNodeUrl = utils.RemoveTrailingSlash(cfg.Console.IpfsNodeUrl)
+	}
+	if cfg.Console.ServerPort == "" {
+		cfg.Console.ServerPort = "13012"
+	}
+	if cfg.Console.WorkPort == "" {
+		cfg.Console.WorkPort = "13011"
+	}
+	if cfg.Console.SuperPort == "" {
+		cfg.Console.SuperPort = "13010"
+	}
+	if cfg.Console.ControlSocket == "" {
+		cfg.Console.ControlSocket = "node.sock"
+	}
+	if cfg.Console.AuditLog == "" {
+		cfg.Console.AuditLog = "audit"
+	}
+	if cfg.Base.Rpc == "" {
+		cfg.Base.Rpc = pattern.RPC
+	}
+	if cfg.Base.Keystore == "" {
+		cfg.Base.Keystore = "keystore.json"
+	}
+	if cfg.Offline.Outbox == "" {
+		cfg.Offline.Outbox = "outbox"
+	}
+	if cfg.Offline.Inbox == "" {
+		cfg.Offline.Inbox = "inbox"
+	}
+	if cfg.PriorityFee.Percentile <= 0 || cfg.PriorityFee.Percentile > 100 {
+		cfg.PriorityFee.Percentile = 75
+	}
+	if cfg.Order.GracePeriod == "" {
+		cfg.Order.GracePeriod = "0"
+	}
+	if len(cfg.Order.Warnings) == 0 {
+		cfg.Order.Warnings = []string{"30m", "5m"}
+	}
+	if cfg.Runtime.Engine == "" {
+		cfg.Runtime.Engine = "docker"
 	}
-	if GlobalConfig.Console.ServerPort == "" {
-		GlobalConfig.Console.ServerPort = "13012"
+	if cfg.Pricing.Interval <= 0 {
+		cfg.Pricing.Interval = 60
 	}
-	if GlobalConfig.Console.WorkPort == "" {
-		GlobalConfig.Console.WorkPort = "13011"
+	if cfg.Pricing.TFLOPSTolerance <= 0 {
+		cfg.Pricing.TFLOPSTolerance = 25
 	}
-	if GlobalConfig.Console.SuperPort == "" {
-		GlobalConfig.Console.SuperPort = "13010"
+	if cfg.Pricing.MinComparables <= 0 {
+		cfg.Pricing.MinComparables = 3
 	}
-	if GlobalConfig.Base.Rpc == "" {
-		GlobalConfig.Base.Rpc = pattern.RPC
+	if cfg.Pricing.MinChange <= 0 {
+		cfg.Pricing.MinChange = 2
 	}
 }
 
 type SolanaConfig struct {
-	Key string
-	RPC string
+	// Key is the legacy plaintext private key; the keystore is used when it is empty.
+	Key          string
+	Keystore     string
+	PasswordFile string
+	RPC          string
+	// Endpoints are fallback RPC endpoints used alongside RPC.
+	Endpoints []string
+	// Broadcast is how many endpoints each transaction is sent to.
+	Broadcast   int
+	WS          string
+	PriorityFee PriorityFeeConfig
+	FeePayer    FeePayerConfig
+	Offline     OfflineConfig
 }
 
-func NewConfig(key string, rpc string) *SolanaConfig {
+func NewConfig(key string, rpc string, ws string) *SolanaConfig {
 	return &SolanaConfig{
 		Key: key,
 		RPC: rpc,
+		WS:  ws,
 	}
 }
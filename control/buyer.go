@@ -0,0 +1,126 @@
+package control
+
+import (
+	"SuperNet-Node/chain/conn"
+	"SuperNet-Node/chain/super"
+	"SuperNet-Node/chain/super/distri_ai"
+	"SuperNet-Node/chain/wallet"
+	"SuperNet-Node/config"
+	"SuperNet-Node/pattern"
+	"SuperNet-Node/utils"
+	"context"
+	"crypto/rand"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// NewBuyer returns the buyer signing with the Solana CLI keypair file, or with the keystore when keypair is empty.
+// The fee payer of the configuration pays the transaction fees, if any.
+func NewBuyer(keypair string, keystore string) (*super.WrapperBuyer, error) {
+	var key solana.PrivateKey
+	var err error
+	if keypair != "" {
+		key, err = wallet.ReadKeypairFile(keypair)
+	} else {
+		key, err = wallet.Unlock(keystore, config.GlobalConfig.Base.PasswordFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := NewSolanaConfig()
+	feePayer, err := wallet.NewFeePayer(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("> wallet.NewFeePayer: %v", err)
+	}
+	newConn, err := conn.NewConn(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("> conn.NewConn: %v", err)
+	}
+	return super.NewBuyerWrapper(newConn, key, feePayer), nil
+}
+
+// PlaceOrder rents the machine at machineAccount for duration hours.
+// cids are the IPFS CIDs of the CID.json files listing the model or the deployment.
+func PlaceOrder(
+	ctx context.Context,
+	buyer *super.WrapperBuyer,
+	machineAccount solana.PublicKey,
+	duration uint32,
+	intent string,
+	taskName string,
+	cids []string) (solana.PublicKey, string, error) {
+	if intent != "train" && intent != "deploy" {
+		return solana.PublicKey{}, "", fmt.Errorf("unknown intent: %v, use train or deploy", intent)
+	}
+
+	machine, err := super.GetMarketMachine(ctx, buyer.Conn, machineAccount)
+	if err != nil {
+		return solana.PublicKey{}, "", fmt.Errorf("> GetMarketMachine: %v", err)
+	}
+	if machine.Status != distri_ai.MachineStatusForRent {
+		return solana.PublicKey{}, "", fmt.Errorf("machine is %v, only a ForRent machine can be ordered", machine.Status)
+	}
+	if duration == 0 || duration > machine.MaxDuration {
+		return solana.PublicKey{}, "", fmt.Errorf("duration must be between 1 and %v hours", machine.MaxDuration)
+	}
+
+	var orderID [16]byte
+	if _, err := rand.Read(orderID[:]); err != nil {
+		return solana.PublicKey{}, "", fmt.Errorf("> rand.Read: %v", err)
+	}
+	return buyer.PlaceOrder(ctx, machineAccount, orderID, duration, NewOrderPlacedMetadata(machine, duration, intent, taskName, cids))
+}
+
+// NewOrderPlacedMetadata returns the metadata of an order, with the machine described as
+// in the web UI so that the order shows the same there.
+func NewOrderPlacedMetadata(
+	machine super.MarketMachine,
+	duration uint32,
+	intent string,
+	taskName string,
+	cids []string) pattern.OrderPlacedMetadata {
+	info := machine.Info
+	gpu := info.GPUInfo.Model
+	if info.GPUInfo.Number > 0 {
+		gpu = fmt.Sprintf("%vx%v", info.GPUInfo.Number, info.GPUInfo.Model)
+	}
+	return pattern.OrderPlacedMetadata{
+		FormData: pattern.FormData{
+			TaskName: taskName,
+			Duration: int(duration),
+		},
+		MachineInfo: pattern.MachineInfo{
+			UUID:             "0x" + string(utils.ByteUUIDToStrUUID(machine.Uuid)),
+			Provider:         machine.Owner.String(),
+			Region:           info.LocationInfo.Country,
+			GPU:              gpu,
+			CPU:              info.CPUInfo.ModelName,
+			TFLOPS:           info.TFLOPSInfo.TFLOPS,
+			RAM:              fmt.Sprintf("%.0fGB", info.MemoryInfo.RAM),
+			AvailDiskStorage: machine.Disk,
+			Reliability:      reliability(machine.CompletedCount, machine.FailedCount),
+			CPS:              fmt.Sprintf("%v", info.Score),
+			Speed: pattern.SpeedInfo{
+				Upload:   info.SpeedInfo.Upload,
+				Download: info.SpeedInfo.Download,
+			},
+			MaxDuration: uint16(machine.MaxDuration),
+			Price:       float32(utils.UnitsToSnt(machine.Price)),
+		},
+		OrderInfo: pattern.OrderInfo{
+			Intent:      intent,
+			DownloadURL: cids,
+		},
+		MachineAccounts: machine.Pubkey.String(),
+	}
+}
+
+// reliability returns the share of the orders of a machine that completed, as a percentage.
+func reliability(completed, failed uint32) string {
+	if completed+failed == 0 {
+		return "100%"
+	}
+	return fmt.Sprintf("%.0f%%", float64(completed)*100/float64(completed+failed))
+}
@@ -10,20 +10,51 @@ import (
 	"SuperNet-Node/machine_info/machine_uuid"
 	"SuperNet-Node/pattern"
 	"SuperNet-Node/utils"
+	dbutils "SuperNet-Node/utils/db_utils"
 	logs "SuperNet-Node/utils/log_utils"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/gagliardetto/solana-go"
 )
 
+var (
+	runtimeMu sync.Mutex
+	// containers runs the containers of the node, see SetRuntime.
+	containers docker.Runtime
+)
+
+// SetRuntime sets the container runtime of the node.
+func SetRuntime(rt docker.Runtime) {
+	runtimeMu.Lock()
+	defer runtimeMu.Unlock()
+	containers = rt
+}
+
+// containerRuntime returns the runtime set by SetRuntime, the configured one when none was set.
+func containerRuntime() (docker.Runtime, error) {
+	runtimeMu.Lock()
+	defer runtimeMu.Unlock()
+	if containers == nil {
+		rt, err := docker.NewRuntime(config.GlobalConfig.Runtime)
+		if err != nil {
+			return nil, fmt.Errorf("> NewRuntime: %v", err)
+		}
+		containers = rt
+	}
+	return containers, nil
+}
+
 // OrderComplete marks the completion of an order process.
-func OrderComplete(super *super.WrapperSuper, metadata string, isGPU bool, containerID string) error {
+func OrderComplete(ctx context.Context, super *super.WrapperSuper, metadata string, isGPU bool, containerID string) error {
 	logs.Normal("Order is complete")
-	// Stop the workspace container associated with the order.
-	if err := docker.StopWorkspaceContainer(containerID); err != nil {
+	// Stop the workspace container associated with the order, keeping the workspace for the grace period.
+	if err := releaseWorkspace(containerID, super.ProgramSuperOrder.String()); err != nil {
 		return err
 	}
 	// Unmarshal the order placement metadata JSON string into a structured object.
@@ -34,7 +65,15 @@ func OrderComplete(super *super.WrapperSuper, metadata string, isGPU bool, conta
 		return err
 	}
 	orderPlacedMetadata.MachineAccounts = super.ProgramSuperMachine.String()
-	_, err = super.OrderCompleted(orderPlacedMetadata, isGPU)
+	rt, err := containerRuntime()
+	if err != nil {
+		return err
+	}
+	score, err := docker.RunScoreContainer(ctx, rt, isGPU)
+	if err != nil {
+		return err
+	}
+	_, err = super.OrderCompleted(ctx, orderPlacedMetadata, score)
 	if err != nil {
 		return err
 	}
@@ -42,17 +81,39 @@ func OrderComplete(super *super.WrapperSuper, metadata string, isGPU bool, conta
 }
 
 // OrderFailed Handles the scenario where an order has failed.
-func OrderFailed(super *super.WrapperSuper, orderPlacedMetadata pattern.OrderPlacedMetadata, buyer solana.PublicKey) error {
+func OrderFailed(ctx context.Context, super *super.WrapperSuper, orderPlacedMetadata pattern.OrderPlacedMetadata, buyer solana.PublicKey) error {
 	logs.Normal("Order is failed")
 	orderPlacedMetadata.MachineAccounts = super.ProgramSuperMachine.String()
-	_, err := super.OrderFailed(buyer, orderPlacedMetadata)
+	_, err := super.OrderFailed(ctx, buyer, orderPlacedMetadata)
 	if err != nil {
 		// Return a formatted error if the order fail processing encounters an issue
-		return fmt.Errorf("> super.OrderFailed: %v", err.Error())
+		return fmt.Errorf("> super.OrderFailed: %w", err)
 	}
 	return nil
 }
 
+// RemoveMachine takes the machine off the chain and forgets the buyer of the last order, its token
+// and the nginx configuration. The local state is cleared even when the transaction fails.
+func RemoveMachine(ctx context.Context, superWrapper *super.WrapperSuper) error {
+	hash, err := superWrapper.RemoveMachine(ctx)
+	if errors.Is(err, super.ErrAwaitingSignature) {
+		logs.Normal("Sign the RemoveMachine transaction and submit it with `tx submit`")
+		err = nil
+	} else if err != nil {
+		err = fmt.Errorf("> RemoveMachine: block: %v, msg: %v", hash, err)
+	}
+
+	db := dbutils.GetDB()
+	dbutils.Delete(db, []byte("buyer"))
+	dbutils.Delete(db, []byte("token"))
+	dbutils.Delete(db, []byte("orderEndTime"))
+
+	if rmErr := os.RemoveAll(pattern.ModleCreatePath); rmErr != nil {
+		logs.Error(fmt.Sprintf("RemoveAll: %v", rmErr))
+	}
+	return err
+}
+
 func GetSuper(longTime bool) (*super.WrapperSuper, *machine_info.MachineInfo, error) {
 
 	var hwInfo machine_info.MachineInfo
@@ -77,7 +138,11 @@ func GetSuper(longTime bool) (*super.WrapperSuper, *machine_info.MachineInfo, er
 		if hwInfo.GPUInfo.Number > 0 {
 			isGPU = true
 		}
-		score, err := docker.RunScoreContainer(isGPU)
+		rt, err := containerRuntime()
+		if err != nil {
+			return nil, nil, err
+		}
+		score, err := docker.RunScoreContainer(context.TODO(), rt, isGPU)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -86,15 +151,13 @@ func GetSuper(longTime bool) (*super.WrapperSuper, *machine_info.MachineInfo, er
 		if isGPU {
 			imageWorkspace = pattern.ML_WORKSPACE_GPU_NAME
 		}
-		if err = docker.ImageExistOrPull(imageWorkspace); err != nil {
+		if err = rt.Pull(context.TODO(), imageWorkspace); err != nil {
 			return nil, nil, err
 		}
 
 		hwInfo.Score = score
 	}
 
-	key := config.GlobalConfig.Base.PrivateKey
-
 	// Derive a unique machine UUID considering various hardware specifics; return an error if unable to do so.
 	machineUUID, err := machine_uuid.GetInfoMachineUUID(
 		hwInfo.CPUInfo.ModelName,
@@ -108,19 +171,15 @@ func GetSuper(longTime bool) (*super.WrapperSuper, *machine_info.MachineInfo, er
 	}
 
 	// Initialize a new configuration and fetch chain information using the machine UUID.
-	newConfig := config.NewConfig(
-		key,
-		config.GlobalConfig.Base.Rpc)
-
 	var chainInfo *chain.InfoChain
-	chainInfo, err = chain.GetChainInfo(newConfig, machineUUID)
+	chainInfo, err = chain.GetChainInfo(NewSolanaConfig(), machineUUID)
 	if err != nil {
 		return nil, nil, fmt.Errorf("> GetChainInfo: %v", err)
 	}
 
 	// Update hardware info with chain details and UUID.
 	hwInfo.MachineAccounts = chainInfo.ProgramSuperMachine.String()
-	hwInfo.Addr = chainInfo.Wallet.Wallet.PublicKey().String()
+	hwInfo.Addr = chainInfo.Wallet.PublicKey().String()
 	hwInfo.MachineUUID = machineUUID
 
 	// Log the hardware information in a human-readable format.
@@ -152,24 +211,77 @@ func GetSuper(longTime bool) (*super.WrapperSuper, *machine_info.MachineInfo, er
 	return super.NewSuperWrapper(chainInfo), &hwInfo, nil
 }
 
+// NewSolanaConfig returns the chain configuration taken from the global configuration.
+func NewSolanaConfig() *config.SolanaConfig {
+	newConfig := config.NewConfig(
+		config.GlobalConfig.Base.PrivateKey,
+		config.GlobalConfig.Base.Rpc,
+		config.GlobalConfig.Base.Ws)
+	newConfig.Keystore = config.GlobalConfig.Base.Keystore
+	newConfig.PasswordFile = config.GlobalConfig.Base.PasswordFile
+	newConfig.Endpoints = config.GlobalConfig.Base.Rpcs
+	newConfig.Broadcast = config.GlobalConfig.Base.Broadcast
+	newConfig.PriorityFee = config.GlobalConfig.PriorityFee
+	newConfig.FeePayer = config.GlobalConfig.FeePayer
+	newConfig.Offline = config.GlobalConfig.Offline
+	return newConfig
+}
+
 // var oldDuration time.Time
 // var orderTimer *time.Timer
 
 // OrderRefunded Handles the logic for processing a refunded order.
-func OrderRefunded(containerID string) error {
+func OrderRefunded(containerID string, order string) error {
 	logs.Normal("Order is refunded")
-	if err := docker.StopWorkspaceContainer(containerID); err != nil {
+	if err := releaseWorkspace(containerID, order); err != nil {
 		return err
 	}
 	return nil
 }
 
-// StartHeartbeatTask starts a ticker to periodically submit heartbeat tasks.
-func StartHeartbeatTask(super *super.WrapperSuper, machineID machine_uuid.MachineUUID) {
+// stopContainerIfExists stops and removes a container, tolerating one that is already gone,
+// e.g. when a completion is retried after a restart. With wipe the workspace is wiped as well.
+func stopContainerIfExists(containerID string, wipe bool) error {
+	rt, err := containerRuntime()
+	if err != nil {
+		return err
+	}
+	exists, _, err := rt.Exists(context.TODO(), containerID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		logs.Normal(fmt.Sprintf("Container %s no longer exists", containerID))
+	} else if err := rt.Stop(context.TODO(), containerID); err != nil {
+		return err
+	}
+	if wipe {
+		_, err = WipeWorkspace("order aborted")
+	}
+	return err
+}
+
+// tasks tracks the background tasks, so that a shutdown waits for the step they are running.
+var tasks sync.WaitGroup
+
+// Shutdown waits for the background tasks to return once their context is cancelled,
+// and stops the scheduled wipe of the workspace, which is resumed on the next start.
+func Shutdown() {
+	tasks.Wait()
+	stopGrace()
+}
+
+// StartHeartbeatTask starts a ticker to periodically submit heartbeat tasks until ctx is cancelled.
+func StartHeartbeatTask(ctx context.Context, super *super.WrapperSuper, machineID machine_uuid.MachineUUID) {
 	ticker := time.NewTicker(6 * time.Hour)
+	tasks.Add(1)
 	go func() {
+		defer tasks.Done()
+		defer ticker.Stop()
 		for {
 			select {
+			case <-ctx.Done():
+				return
 			case <-ticker.C:
 				taskID, err := utils.GenerateRandomString(16)
 				if err != nil {
@@ -185,7 +297,7 @@ func StartHeartbeatTask(super *super.WrapperSuper, machineID machine_uuid.Machin
 					logs.Error(fmt.Sprintf("error parsing machineUuid: %v", err))
 				}
 
-				hash, err := super.SubmitTask(taskUuid, machineUuid, utils.CurrentPeriod(), pattern.TaskMetadata{})
+				hash, err := super.SubmitTask(context.WithoutCancel(ctx), taskUuid, machineUuid, utils.CurrentPeriod(), pattern.TaskMetadata{})
 				if err != nil {
 					logs.Error(fmt.Sprintf("Error block : %v, msg : %v\n", hash, err))
 				}
@@ -0,0 +1,157 @@
+package control
+
+import (
+	"SuperNet-Node/chain/super"
+	"SuperNet-Node/chain/super/distri_ai"
+	"SuperNet-Node/utils"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// PeriodEarnings is what the machine earned in one reward period. Amounts are in SNT.
+type PeriodEarnings struct {
+	Period             uint32  `json:"Period"`
+	Date               string  `json:"Date"`
+	Finished           bool    `json:"Finished"`
+	Pool               float64 `json:"Pool"`
+	UnitPeriodicReward float64 `json:"UnitPeriodicReward"`
+	UnitTaskReward     float64 `json:"UnitTaskReward"`
+	TaskNum            uint32  `json:"TaskNum"`
+	PeriodicReward     float64 `json:"PeriodicReward"`
+	TaskReward         float64 `json:"TaskReward"`
+	Claimed            bool    `json:"Claimed"`
+	ClaimedReward      float64 `json:"ClaimedReward"`
+	ClaimableReward    float64 `json:"ClaimableReward"`
+	CompletedOrders    int     `json:"CompletedOrders"`
+	OrderRevenue       float64 `json:"OrderRevenue"`
+}
+
+// EarningsReport sums the earnings of the machine over a range of periods. Amounts are in SNT.
+type EarningsReport struct {
+	Machine                string           `json:"Machine"`
+	FromPeriod             uint32           `json:"FromPeriod"`
+	ToPeriod               uint32           `json:"ToPeriod"`
+	Periods                []PeriodEarnings `json:"Periods"`
+	TotalClaimed           float64          `json:"TotalClaimed"`
+	TotalClaimable         float64          `json:"TotalClaimable"`
+	TotalOrderRevenue      float64          `json:"TotalOrderRevenue"`
+	ClaimedPeriodicRewards float64          `json:"ClaimedPeriodicRewards"`
+	ClaimedTaskRewards     float64          `json:"ClaimedTaskRewards"`
+}
+
+// MaxEarningsPeriods bounds the periods of an earnings report, each of which costs two RPC calls.
+const MaxEarningsPeriods = 366
+
+// GetEarnings builds the earnings report of the machine for the periods from..to, both included.
+// to is clamped to the current period, the later ones have no rewards yet.
+func GetEarnings(ctx context.Context, super *super.WrapperSuper, from, to uint32) (*EarningsReport, error) {
+	currentPeriod := utils.CurrentPeriod()
+	to = min(to, currentPeriod)
+	if from > to {
+		return nil, fmt.Errorf("invalid period range: %v > %v", from, to)
+	}
+	if to-from >= MaxEarningsPeriods {
+		return nil, fmt.Errorf("period range %v..%v exceeds %v periods", from, to, MaxEarningsPeriods)
+	}
+
+	machine, err := super.GetMachine(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("> GetMachine: %v", err)
+	}
+
+	orders, err := super.GetMachineOrders(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("> GetMachineOrders: %v", err)
+	}
+
+	report := &EarningsReport{
+		Machine:                super.ProgramSuperMachine.String(),
+		FromPeriod:             from,
+		ToPeriod:               to,
+		ClaimedPeriodicRewards: utils.UnitsToSnt(machine.ClaimedPeriodicRewards),
+		ClaimedTaskRewards:     utils.UnitsToSnt(machine.ClaimedTaskRewards),
+	}
+
+	for period := from; period <= to; period++ {
+		earnings := PeriodEarnings{
+			Period:   period,
+			Date:     utils.PeriodStartTime(period).UTC().Format(time.DateOnly),
+			Finished: period < currentPeriod,
+		}
+
+		reward, err := super.GetReward(ctx, period)
+		if err != nil && !errors.Is(err, rpc.ErrNotFound) {
+			return nil, fmt.Errorf("> GetReward %v: %v", period, err)
+		}
+		earnings.Pool = utils.UnitsToSnt(reward.Pool)
+		earnings.UnitPeriodicReward = utils.UnitsToSnt(reward.UnitPeriodicReward)
+		earnings.UnitTaskReward = utils.UnitsToSnt(reward.UnitTaskReward)
+
+		rewardMachine, err := super.GetRewardMachine(ctx, period)
+		switch {
+		case err == nil:
+			earnings.TaskNum = rewardMachine.TaskNum
+			earnings.Claimed = rewardMachine.Claimed
+			earnings.PeriodicReward = utils.UnitsToSnt(reward.UnitPeriodicReward)
+			earnings.TaskReward = utils.UnitsToSnt(reward.UnitTaskReward * uint64(rewardMachine.TaskNum))
+		case !errors.Is(err, rpc.ErrNotFound):
+			return nil, fmt.Errorf("> GetRewardMachine %v: %v", period, err)
+		}
+
+		total := earnings.PeriodicReward + earnings.TaskReward
+		if earnings.Claimed {
+			earnings.ClaimedReward = total
+		} else if earnings.Finished {
+			earnings.ClaimableReward = total
+		}
+
+		for _, order := range orders {
+			if order.Status != distri_ai.OrderStatusCompleted {
+				continue
+			}
+			// Orders are attributed to the period in which they ended.
+			if utils.PeriodAt(orderEndTime(order)) == period {
+				earnings.CompletedOrders++
+				earnings.OrderRevenue += utils.UnitsToSnt(order.Total)
+			}
+		}
+
+		report.TotalClaimed += earnings.ClaimedReward
+		report.TotalClaimable += earnings.ClaimableReward
+		report.TotalOrderRevenue += earnings.OrderRevenue
+		report.Periods = append(report.Periods, earnings)
+	}
+
+	return report, nil
+}
+
+// EarningsRange converts a from..to range of dates (YYYY-MM-DD, UTC) into periods.
+// An empty to means the current period, an empty from means 30 days before to.
+func EarningsRange(from, to string) (uint32, uint32, error) {
+	toPeriod := utils.CurrentPeriod()
+	if to != "" {
+		t, err := time.Parse(time.DateOnly, to)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid to date: %v", err)
+		}
+		toPeriod = utils.PeriodAt(t)
+	}
+
+	fromPeriod := utils.PeriodAt(utils.PeriodStartTime(toPeriod).AddDate(0, 0, -30))
+	if from != "" {
+		t, err := time.Parse(time.DateOnly, from)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid from date: %v", err)
+		}
+		fromPeriod = utils.PeriodAt(t)
+	}
+
+	if fromPeriod > toPeriod {
+		return 0, 0, fmt.Errorf("from %v is after to %v", from, to)
+	}
+	return fromPeriod, toPeriod, nil
+}
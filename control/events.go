@@ -0,0 +1,71 @@
+package control
+
+import (
+	"SuperNet-Node/chain/super"
+	logs "SuperNet-Node/utils/log_utils"
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// listener indexes the transactions touching the machine, nil until StartEventListener.
+var listener *super.Listener
+
+// StartEventListener indexes the transactions of the program touching the machine,
+// and logs those of the buyers: orders placed, renewed or refunded. It stops when ctx is cancelled.
+func StartEventListener(ctx context.Context, super *super.WrapperSuper) {
+	listener = super.NewListener()
+	events, _ := listener.Subscribe(16)
+	tasks.Add(2)
+	go func() {
+		defer tasks.Done()
+		listener.Run(ctx)
+	}()
+	go func() {
+		defer tasks.Done()
+		// The listener closes the subscriptions when it returns.
+		for event := range events {
+			for _, instruction := range event.Instructions {
+				switch instruction {
+				case "PlaceOrder", "RenewOrder", "RefundOrder":
+					status := "confirmed"
+					if event.Failed {
+						status = "failed"
+					}
+					logs.Normal(fmt.Sprintf("%v %v on the machine, slot: %v, signature: %v",
+						instruction, status, event.Slot, event.Signature))
+				}
+			}
+		}
+	}()
+}
+
+// SubscribeEvents returns the events indexed from now on and the function to stop receiving them,
+// ok is false when the listener is not started.
+func SubscribeEvents(buffer int) (events <-chan super.ProgramEvent, cancel func(), ok bool) {
+	if listener == nil {
+		return nil, nil, false
+	}
+	events, cancel = listener.Subscribe(buffer)
+	return events, cancel, true
+}
+
+// NewEventFilter parses the instruction, from (slot) and limit query parameters of the event index.
+func NewEventFilter(instruction, from, limit string) (super.EventFilter, error) {
+	filter := super.EventFilter{Instruction: instruction}
+	if from != "" {
+		slot, err := strconv.ParseUint(from, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("invalid from slot: %v", from)
+		}
+		filter.FromSlot = slot
+	}
+	if limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			return filter, fmt.Errorf("invalid limit: %v", limit)
+		}
+		filter.Limit = n
+	}
+	return filter, nil
+}
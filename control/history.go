@@ -0,0 +1,51 @@
+package control
+
+import (
+	"SuperNet-Node/chain/super"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// NewHistoryFilter builds an audit log filter from user input. from and to are dates
+// (YYYY-MM-DD, UTC, to included) or RFC 3339 times; empty values match everything.
+func NewHistoryFilter(order, name, from, to string) (super.HistoryFilter, error) {
+	filter := super.HistoryFilter{Order: order, Name: name}
+	var err error
+	if from != "" {
+		if filter.From, err = parseHistoryTime(from, false); err != nil {
+			return filter, fmt.Errorf("invalid from: %v", err)
+		}
+	}
+	if to != "" {
+		if filter.To, err = parseHistoryTime(to, true); err != nil {
+			return filter, fmt.Errorf("invalid to: %v", err)
+		}
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.From.After(filter.To) {
+		return filter, fmt.Errorf("from %v is after to %v", from, to)
+	}
+	return filter, nil
+}
+
+func parseHistoryTime(s string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// WriteHistoryJSONL writes audit records as JSON lines, one record per line.
+func WriteHistoryJSONL(w io.Writer, records []super.AuditRecord) error {
+	encoder := json.NewEncoder(w)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package control
+
+import (
+	"SuperNet-Node/chain/super"
+	"SuperNet-Node/config"
+	logs "SuperNet-Node/utils/log_utils"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// StartInboxTask starts a ticker that submits the signed transactions dropped in the inbox.
+// Submitted files are moved to inbox/done, rejected ones to inbox/failed; a file is picked up once it
+// is unchanged over two polls. It stops when ctx is cancelled.
+func StartInboxTask(ctx context.Context, super *super.WrapperSuper) {
+	inbox := config.GlobalConfig.Offline.Inbox
+	if err := os.MkdirAll(inbox, 0755); err != nil {
+		logs.Error(fmt.Sprintf("MkdirAll inbox: %v", err))
+		return
+	}
+	logs.Normal(fmt.Sprintf("Owner key is offline, waiting for signed transactions in %v", inbox))
+
+	ticker := time.NewTicker(10 * time.Second)
+	tasks.Add(1)
+	go func() {
+		defer tasks.Done()
+		defer ticker.Stop()
+		// seen are the files of the inbox at the last poll. A file is read once it is unchanged
+		// since then, so that one still being copied is not read half-written.
+		seen := map[string]inboxFile{}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			paths, err := filepath.Glob(filepath.Join(inbox, "*.json"))
+			if err != nil {
+				logs.Error(fmt.Sprintf("Glob inbox: %v", err))
+				continue
+			}
+			current := map[string]inboxFile{}
+			for _, path := range paths {
+				info, err := os.Stat(path)
+				if err != nil {
+					continue
+				}
+				file := inboxFile{size: info.Size(), modTime: info.ModTime()}
+				last, ok := seen[path]
+				if !ok || !last.same(file) {
+					current[path] = file
+					continue
+				}
+				if last.unreadable {
+					// Read again once it changes.
+					current[path] = last
+					continue
+				}
+				if !submitInboxFile(context.WithoutCancel(ctx), super, path) {
+					file.unreadable = true
+					current[path] = file
+				}
+			}
+			seen = current
+		}
+	}()
+}
+
+// inboxFile is the state of a file of the inbox at a poll.
+type inboxFile struct {
+	size    int64
+	modTime time.Time
+	// unreadable is set when the file could not be read as an envelope.
+	unreadable bool
+}
+
+func (f inboxFile) same(other inboxFile) bool {
+	return f.size == other.size && f.modTime.Equal(other.modTime)
+}
+
+// submitInboxFile submits the envelope in path and files it away. It reports false, leaving the file
+// in the inbox, when the file cannot be read as an envelope, e.g. while it is still being written.
+func submitInboxFile(ctx context.Context, superWrapper *super.WrapperSuper, path string) bool {
+	envelope, err := super.ReadEnvelope(path)
+	if err != nil {
+		logs.Warning(fmt.Sprintf("Read %v: %v, it is read again once it changes", path, err))
+		return false
+	}
+	if len(envelope.Missing) > 0 {
+		err = fmt.Errorf("still needs the signatures of %v", envelope.Missing)
+	}
+	if err == nil {
+		_, err = super.SubmitEnvelope(ctx, superWrapper.Conn, envelope)
+	}
+
+	dir := "done"
+	if err != nil {
+		logs.Error(fmt.Sprintf("Submit %v: %v", path, err))
+		dir = "failed"
+	}
+	dest := filepath.Join(filepath.Dir(path), dir)
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		logs.Error(fmt.Sprintf("MkdirAll: %v", err))
+		return true
+	}
+	if err := os.Rename(path, filepath.Join(dest, filepath.Base(path))); err != nil {
+		logs.Error(fmt.Sprintf("Move %v: %v", path, err))
+	}
+	return true
+}
@@ -0,0 +1,768 @@
+package control
+
+import (
+	"SuperNet-Node/chain/super"
+	"SuperNet-Node/chain/super/distri_ai"
+	"SuperNet-Node/config"
+	"SuperNet-Node/docker"
+	"SuperNet-Node/pattern"
+	"SuperNet-Node/utils"
+	dbutils "SuperNet-Node/utils/db_utils"
+	logs "SuperNet-Node/utils/log_utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// OrderState is the local progress of the order served by this machine.
+type OrderState string
+
+const (
+	// OrderStateProvisioning means the workspace container is being prepared.
+	OrderStateProvisioning OrderState = "Provisioning"
+	// OrderStateStarting means the container runs and OrderStart is being sent.
+	OrderStateStarting OrderState = "Starting"
+	// OrderStateRunning means the order is Training on chain and the container serves the buyer.
+	OrderStateRunning OrderState = "Running"
+	// OrderStateCompleting means the order reached its end time and OrderCompleted is being sent.
+	OrderStateCompleting OrderState = "Completing"
+	// OrderStateFailing means provisioning failed and OrderFailed is being sent.
+	OrderStateFailing OrderState = "Failing"
+)
+
+// orderJournalKey is the Badger key holding the journal of the active order.
+const orderJournalKey = "orderJournal"
+
+// journalObserver is called with every journaled state, see ObserveOrderJournal.
+var journalObserver func(OrderJournal)
+
+// ObserveOrderJournal calls fn with a copy of the journal whenever it is saved,
+// and with an empty journal when the order is cleared. It must be set before orders are served.
+func ObserveOrderJournal(fn func(OrderJournal)) {
+	journalObserver = fn
+}
+
+// OrderJournal is the persisted state of the active order.
+// It is written before every side effect so that a restarted node can resume the order.
+type OrderJournal struct {
+	OrderPda    string     `json:"OrderPda"`
+	Buyer       string     `json:"Buyer"`
+	State       OrderState `json:"State"`
+	ContainerID string     `json:"ContainerID"`
+	Intent      string     `json:"Intent"`
+	EndTime     time.Time  `json:"EndTime"`
+	PendingTx   string     `json:"PendingTx"`
+	// AwaitingSignature is set when PendingTx was emitted for the offline owner key
+	// and the order waits for the signed transaction to land.
+	AwaitingSignature bool `json:"AwaitingSignature"`
+	// FailReason is the provisioning error reported by OrderFailed.
+	FailReason string `json:"FailReason"`
+}
+
+// RetryInterval is how often the transaction of a failed step is sent again while the machine
+// does not change, a variable so that node simulate can shorten it.
+var RetryInterval = 1 * time.Minute
+
+// OrderMachine drives the order served by this machine through its lifecycle:
+// Preparing -> Training -> Completed, or Failed/Refunded.
+type OrderMachine struct {
+	super   *super.WrapperSuper
+	isGPU   bool
+	journal *OrderJournal
+}
+
+// NewOrderMachine returns an OrderMachine without an active order; call Resume to load a journaled one.
+func NewOrderMachine(super *super.WrapperSuper, isGPU bool) *OrderMachine {
+	return &OrderMachine{
+		super: super,
+		isGPU: isGPU,
+	}
+}
+
+// Resume loads the journaled order, if any, and reconciles it against the chain and the Docker daemon.
+// Like the steps of Serve, it is finished even when ctx is cancelled.
+func (m *OrderMachine) Resume(ctx context.Context) error {
+	ctx = context.WithoutCancel(ctx)
+
+	journal, err := loadOrderJournal()
+	if err != nil {
+		return err
+	}
+	if journal == nil {
+		return nil
+	}
+	m.journal = journal
+	logs.Normal(fmt.Sprintf("Resuming order %v, state: %v, pending tx: %v", journal.OrderPda, journal.State, journal.PendingTx))
+
+	orderID, err := solana.PublicKeyFromBase58(journal.OrderPda)
+	if err != nil {
+		m.clear()
+		return fmt.Errorf("> PublicKeyFromBase58: %v", err)
+	}
+	m.super.ProgramSuperOrder = orderID
+
+	order, err := m.super.GetOrder(ctx)
+	if errors.Is(err, rpc.ErrNotFound) {
+		// The order account was closed while the node was down, only the local leftovers remain.
+		logs.Warning(fmt.Sprintf("Order %v no longer exists, dropping its journal", journal.OrderPda))
+		m.stopContainer()
+		m.clear()
+		return nil
+	}
+	if err != nil {
+		// The journal and the container are kept, acting on an unknown order could end a live one.
+		return fmt.Errorf("> GetOrder: %v", err)
+	}
+
+	exists, running := false, false
+	if journal.ContainerID != "" {
+		rt, err := containerRuntime()
+		if err != nil {
+			return err
+		}
+		exists, running, err = rt.Exists(ctx, journal.ContainerID)
+		if err != nil {
+			return fmt.Errorf("> Exists: %v", err)
+		}
+	}
+	logs.Normal(fmt.Sprintf("Order status on chain: %v, container exists: %v, running: %v", order.Status, exists, running))
+
+	switch order.Status {
+	case distri_ai.OrderStatusPreparing:
+		if journal.AwaitingSignature {
+			// The transaction is already in the outbox; emitting it again would race it for the nonce.
+			return nil
+		}
+		if journal.State == OrderStateStarting && running {
+			// The container is up, only OrderStart is missing.
+			return m.sendOrderStart(ctx)
+		}
+		if journal.State == OrderStateFailing {
+			return m.sendOrderFailed(ctx, order)
+		}
+		// Provisioning was interrupted; drop it and let the order be provisioned again.
+		m.stopContainer()
+		m.clear()
+	case distri_ai.OrderStatusTraining:
+		if journal.State == OrderStateCompleting || time.Now().After(orderEndTime(order)) {
+			if !journal.AwaitingSignature {
+				m.complete(ctx, order)
+			}
+			return nil
+		}
+		if !running {
+			// The container was lost, e.g. by a reboot; provision it again for the rest of the order.
+			m.stopContainer()
+			var orderPlacedMetadata pattern.OrderPlacedMetadata
+			if err := json.Unmarshal([]byte(order.Metadata), &orderPlacedMetadata); err != nil {
+				return fmt.Errorf("> json.Unmarshal: %v", err)
+			}
+			containerID, err := m.provision(ctx, order, orderPlacedMetadata)
+			if err != nil {
+				return fmt.Errorf("> provision: %v", err)
+			}
+			m.journal.ContainerID = containerID
+		}
+		m.journal.State = OrderStateRunning
+		m.journal.PendingTx = ""
+		m.journal.AwaitingSignature = false
+		m.save()
+	case distri_ai.OrderStatusRefunded:
+		m.refunded()
+	default:
+		// Completed or Failed: the order is settled, only the local leftovers remain.
+		m.stopContainer()
+		m.clear()
+	}
+	return nil
+}
+
+// Serve follows the machine and serves the orders placed on it until ctx is cancelled.
+// Machine updates are pushed over WebSocket; polling only takes over while the socket is down.
+// The step in progress, such as a transaction, is finished before it returns: the steps run on
+// ctx without its cancellation. A step whose transaction failed is retried every RetryInterval.
+func (m *OrderMachine) Serve(ctx context.Context) {
+	updates := m.super.WatchMachine(ctx)
+	// A failed step changes no account, so it is retried on a ticker until it goes through.
+	retry := time.NewTicker(RetryInterval)
+	defer retry.Stop()
+
+	for {
+		var machine distri_ai.Machine
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			machine = update
+		case <-retry.C:
+			if m.journal == nil {
+				continue
+			}
+			latest, err := m.super.GetMachine(ctx)
+			if err != nil {
+				logs.Error(fmt.Sprintf("GetMachine: %v", err))
+				continue
+			}
+			machine = latest
+		}
+		// An update received along with the shutdown is left to the next start.
+		if ctx.Err() != nil {
+			return
+		}
+		m.handle(ctx, machine)
+	}
+}
+
+// handle acts on the status of the machine.
+func (m *OrderMachine) handle(ctx context.Context, machine distri_ai.Machine) {
+	switch machine.Status.String() {
+	case "Idle":
+		logs.Normal("Machine is Idle, list it for rent with `node offer set`")
+	case "ForRent":
+		// The machine is listed again at the end of an order, which a paused node takes back.
+		if pause, err := GetPause(); err != nil {
+			logs.Error(fmt.Sprintf("GetPause: %v", err))
+		} else if pause != nil {
+			if err := holdOffer(context.WithoutCancel(ctx), m.super); err != nil {
+				logs.Error(fmt.Sprintf("Hold offer while paused: %v", err))
+			}
+			return
+		}
+		logs.Normal(fmt.Sprintf("Machine is ForRent, Price: %v SNT/h, MaxDuration: %vh, Disk: %vGB",
+			utils.UnitsToSnt(machine.Price), machine.MaxDuration, machine.Disk))
+	case "Renting":
+		if err := m.Run(ctx, machine); err != nil && !errors.Is(err, context.Canceled) {
+			logs.Error(fmt.Sprintf("Order: %v", err))
+		}
+	default:
+		logs.Error(fmt.Sprintf("machine status error, Status: %v", machine.Status))
+	}
+}
+
+// Run serves the order referenced by a Renting machine until the order ends or ctx is cancelled.
+func (m *OrderMachine) Run(ctx context.Context, machine distri_ai.Machine) error {
+	orderID := machine.OrderPda
+	if orderID.Equals(solana.SystemProgramID) {
+		return fmt.Errorf("machine OrderPda error, OrderPda: %v", orderID)
+	}
+	m.super.ProgramSuperOrder = orderID
+	step := context.WithoutCancel(ctx)
+
+	if m.journal != nil && m.journal.OrderPda != orderID.String() {
+		logs.Warning(fmt.Sprintf("Discarding journal of order %v, machine now serves %v", m.journal.OrderPda, orderID))
+		m.stopContainer()
+		m.clear()
+	}
+
+	if m.journal == nil {
+		order, err := m.super.GetOrder(step)
+		if err != nil {
+			return fmt.Errorf("> GetOrder: %v", err)
+		}
+		// A stale update can still report an order that was already handled.
+		if order.Status != distri_ai.OrderStatusPreparing {
+			return nil
+		}
+		if err := m.start(step, order); err != nil {
+			return err
+		}
+	} else if err := m.retryPending(step); err != nil {
+		return err
+	}
+	if m.journal == nil {
+		return nil
+	}
+
+	return m.follow(ctx)
+}
+
+// start provisions the container of a Preparing order and sends OrderStart.
+func (m *OrderMachine) start(ctx context.Context, order distri_ai.Order) error {
+	logs.Normal(fmt.Sprintf("Machine is Renting, Order: %v", order))
+
+	var orderPlacedMetadata pattern.OrderPlacedMetadata
+	if err := json.Unmarshal([]byte(order.Metadata), &orderPlacedMetadata); err != nil {
+		return fmt.Errorf("> json.Unmarshal: %v", err)
+	}
+
+	m.journal = &OrderJournal{
+		OrderPda: m.super.ProgramSuperOrder.String(),
+		Buyer:    order.Buyer.String(),
+		State:    OrderStateProvisioning,
+		Intent:   orderPlacedMetadata.OrderInfo.Intent,
+	}
+	m.save()
+
+	containerID, err := m.provision(ctx, order, orderPlacedMetadata)
+	if err != nil {
+		logs.Error(fmt.Sprintf("provision: %v", err))
+
+		m.journal.State = OrderStateFailing
+		m.journal.PendingTx = pattern.TX_HASHRATE_MARKET_ORDER_FAILED
+		m.journal.FailReason = err.Error()
+		m.save()
+		if err := m.sendOrderFailed(ctx, order); err != nil {
+			return err
+		}
+		if m.journal != nil {
+			// Follow the order until the signed OrderFailed lands.
+			return nil
+		}
+		return fmt.Errorf("> provision: %v", err)
+	}
+
+	m.journal.ContainerID = containerID
+	return m.sendOrderStart(ctx)
+}
+
+// sendOrderFailed reports the journaled provisioning failure of the order and forgets it.
+// The journal is kept when it cannot be sent, so that it is retried.
+func (m *OrderMachine) sendOrderFailed(ctx context.Context, order distri_ai.Order) error {
+	var orderPlacedMetadata pattern.OrderPlacedMetadata
+	if err := json.Unmarshal([]byte(order.Metadata), &orderPlacedMetadata); err != nil {
+		return fmt.Errorf("> json.Unmarshal: %v", err)
+	}
+	orderPlacedMetadata.OrderInfo.Message = m.journal.FailReason
+
+	if err := OrderFailed(ctx, m.super, orderPlacedMetadata, order.Buyer); err != nil {
+		if m.awaitSignature(err) {
+			return nil
+		}
+		return fmt.Errorf("> OrderFailed: %v", err)
+	}
+	m.clear()
+	return nil
+}
+
+// sendOrderStart marks the order as started on chain. The container and the journal are kept
+// when it fails, so that it is retried.
+func (m *OrderMachine) sendOrderStart(ctx context.Context) error {
+	m.journal.State = OrderStateStarting
+	m.journal.PendingTx = pattern.TX_HASHRATE_MARKET_ORDER_START
+	m.save()
+
+	if _, err := m.super.OrderStart(ctx); err != nil {
+		if m.awaitSignature(err) {
+			// follow moves the order to Running once the signed OrderStart lands.
+			return nil
+		}
+		return fmt.Errorf("> OrderStart: %v", err)
+	}
+
+	m.journal.State = OrderStateRunning
+	m.journal.PendingTx = ""
+	m.save()
+	return nil
+}
+
+// retryPending sends again the transaction of a step that failed while the order is still Preparing.
+// The completion is retried by follow, which holds the order it completes.
+func (m *OrderMachine) retryPending(ctx context.Context) error {
+	if m.journal.AwaitingSignature {
+		return nil
+	}
+	if m.journal.State != OrderStateFailing && m.journal.State != OrderStateStarting {
+		return nil
+	}
+	order, err := m.super.GetOrder(ctx)
+	if err != nil {
+		return fmt.Errorf("> GetOrder: %v", err)
+	}
+	// Otherwise the order moved on, e.g. refunded, which follow settles.
+	if order.Status != distri_ai.OrderStatusPreparing {
+		return nil
+	}
+	logs.Normal(fmt.Sprintf("Retrying %v of order %v", m.journal.PendingTx, m.super.ProgramSuperOrder))
+	if m.journal.State == OrderStateFailing {
+		return m.sendOrderFailed(ctx, order)
+	}
+	return m.sendOrderStart(ctx)
+}
+
+// follow watches the running order until it is completed, refunded or settled elsewhere.
+func (m *OrderMachine) follow(ctx context.Context) error {
+	orderCtx, cancelOrder := context.WithCancel(ctx)
+	defer cancelOrder()
+	orderUpdates := m.super.WatchOrder(orderCtx, m.super.ProgramSuperOrder)
+	step := context.WithoutCancel(ctx)
+
+	// The end of an order is not an account change, so it is scheduled on a timer
+	// that is rescheduled whenever a renewal changes the duration.
+	timer := NewOrderTimer(orderWarnings())
+	defer timer.Stop()
+	retry := time.NewTicker(RetryInterval)
+	defer retry.Stop()
+
+	var order distri_ai.Order
+	for {
+		select {
+		case newOrder, ok := <-orderUpdates:
+			if !ok {
+				m.exit(step, order)
+				return ctx.Err()
+			}
+			order = newOrder
+		case <-timer.C():
+			if m.onTimer(step, timer, order) {
+				return nil
+			}
+			continue
+		case <-retry.C:
+			// A failed OrderCompleted is sent again, no update comes until it goes through.
+			if m.journal.State == OrderStateCompleting && !m.journal.AwaitingSignature &&
+				order.Status == distri_ai.OrderStatusTraining && m.complete(step, order) {
+				return nil
+			}
+			continue
+		}
+
+		switch order.Status {
+		case distri_ai.OrderStatusPreparing:
+			// OrderStart is either confirmed, so this is a stale snapshot, or awaiting its signature.
+			continue
+		case distri_ai.OrderStatusTraining:
+			if m.journal.State == OrderStateStarting {
+				logs.Normal("OrderStart signed and confirmed")
+				m.journal.State = OrderStateRunning
+				m.journal.PendingTx = ""
+				m.journal.AwaitingSignature = false
+				m.save()
+			}
+			if m.journal.State == OrderStateCompleting && m.journal.AwaitingSignature {
+				continue
+			}
+			m.schedule(timer, order)
+		case distri_ai.OrderStatusRefunded:
+			m.refunded()
+			return nil
+		default:
+			if m.journal.AwaitingSignature {
+				logs.Normal(fmt.Sprintf("%v signed and confirmed, order %v is %v", m.journal.PendingTx, m.super.ProgramSuperOrder, order.Status))
+			} else {
+				logs.Error(fmt.Sprintf("Order error, ID: %v\norder: %v", m.super.ProgramSuperOrder, order))
+			}
+			m.stopContainer()
+			m.clear()
+			return nil
+		}
+	}
+}
+
+// exit leaves the order to the next start of the node, which resumes it from the journal,
+// or completes it when order.completeOnExit is set.
+func (m *OrderMachine) exit(ctx context.Context, order distri_ai.Order) {
+	if m.journal.State != OrderStateRunning || order.Status != distri_ai.OrderStatusTraining {
+		logs.Normal(fmt.Sprintf("Order %v is %v, it is resumed on the next start", m.super.ProgramSuperOrder, m.journal.State))
+		return
+	}
+	if config.CurrentOrder().CompleteOnExit {
+		logs.Normal(fmt.Sprintf("Completing order %v before exiting", m.super.ProgramSuperOrder))
+		m.complete(ctx, order)
+		return
+	}
+	logs.Normal(fmt.Sprintf("Leaving the container of order %v running, it is resumed on the next start", m.super.ProgramSuperOrder))
+}
+
+// schedule sets the timer to the end of a Training order, announcing the start of the order
+// and its renewals. An end that is already past makes the timer fire at once.
+func (m *OrderMachine) schedule(timer *OrderTimer, order distri_ai.Order) {
+	endTime := orderEndTime(order)
+	if !timer.Reset(endTime) || m.journal.EndTime.Equal(endTime) {
+		return
+	}
+
+	event := OrderEventRenewed
+	if m.journal.EndTime.IsZero() {
+		event = OrderEventStarted
+	} else {
+		logs.Normal(fmt.Sprintf("Order renewed, ends %v instead of %v",
+			endTime.Format(time.DateTime), m.journal.EndTime.Format(time.DateTime)))
+	}
+	m.journal.EndTime = endTime
+	m.save()
+	// uploadFile bounds the uploads of the buyer by the end of the order.
+	dbutils.Update(dbutils.GetDB(), []byte("orderEndTime"), []byte(endTime.Format(time.RFC3339)))
+	notifyOrder(m.newOrderEvent(event, order))
+}
+
+// onTimer handles a warning or the end of the order and reports whether the order is settled.
+func (m *OrderMachine) onTimer(ctx context.Context, timer *OrderTimer, order distri_ai.Order) bool {
+	if order.Status != distri_ai.OrderStatusTraining {
+		return false
+	}
+	if _, ended := timer.Fire(); !ended {
+		notifyOrder(m.newOrderEvent(OrderEventWarning, order))
+		return false
+	}
+
+	// A renewal can land just before the end, ahead of its account update.
+	latest, err := m.super.GetOrder(ctx)
+	if err != nil && !errors.Is(err, rpc.ErrNotFound) {
+		// Without the latest order a renewal cannot be ruled out, the container is kept until it is known.
+		logs.Error(fmt.Sprintf("GetOrder: %v, checking the end of the order again in %v", err, RetryInterval))
+		timer.Delay(RetryInterval)
+		return false
+	}
+	if err == nil && latest.Status == distri_ai.OrderStatusTraining && orderEndTime(latest).After(time.Now()) {
+		m.schedule(timer, latest)
+		return false
+	}
+	notifyOrder(m.newOrderEvent(OrderEventEnded, order))
+	return m.complete(ctx, order)
+}
+
+func (m *OrderMachine) newOrderEvent(event string, order distri_ai.Order) OrderEvent {
+	return OrderEvent{
+		Event:   event,
+		Order:   m.super.ProgramSuperOrder.String(),
+		Buyer:   order.Buyer.String(),
+		EndTime: orderEndTime(order),
+	}
+}
+
+// complete stops the container and sends OrderCompleted.
+// It reports whether the order is settled; it is not while OrderCompleted awaits its signature or has to be retried.
+func (m *OrderMachine) complete(ctx context.Context, order distri_ai.Order) bool {
+	logs.Normal(fmt.Sprintf("Order completed, Details: %v", order))
+
+	m.journal.State = OrderStateCompleting
+	m.journal.PendingTx = pattern.TX_HASHRATE_MARKET_ORDER_COMPLETED
+	m.save()
+
+	if err := OrderComplete(ctx, m.super, order.Metadata, m.isGPU, m.journal.ContainerID); err != nil {
+		if m.awaitSignature(err) {
+			return false
+		}
+		// The journal is kept so that follow retries the completion.
+		logs.Error(fmt.Sprintf("OrderComplete: %v", err))
+		return false
+	}
+	m.clear()
+	return true
+}
+
+// awaitSignature journals that the pending transaction was emitted for the offline owner key.
+func (m *OrderMachine) awaitSignature(err error) bool {
+	if !errors.Is(err, super.ErrAwaitingSignature) {
+		return false
+	}
+	m.journal.AwaitingSignature = true
+	m.save()
+	return true
+}
+
+// refunded releases the container of an order refunded by the buyer.
+func (m *OrderMachine) refunded() {
+	if err := OrderRefunded(m.journal.ContainerID, m.journal.OrderPda); err != nil {
+		logs.Error(fmt.Sprintf("OrderRefunded: %v", err))
+	}
+	m.clear()
+}
+
+// provision starts the container for the intent of the order and downloads its files.
+func (m *OrderMachine) provision(ctx context.Context, order distri_ai.Order, orderPlacedMetadata pattern.OrderPlacedMetadata) (string, error) {
+	// The workspace of the previous order must not leak into this one.
+	endGrace()
+	switch orderPlacedMetadata.OrderInfo.Intent {
+	case "train":
+		return m.provisionTrain(ctx, order, orderPlacedMetadata)
+	case "deploy":
+		return m.provisionDeploy(ctx, order, orderPlacedMetadata)
+	default:
+		return "", fmt.Errorf("OrderInfo.Intent error, Intent: %v", orderPlacedMetadata.OrderInfo.Intent)
+	}
+}
+
+func (m *OrderMachine) provisionTrain(ctx context.Context, order distri_ai.Order, orderPlacedMetadata pattern.OrderPlacedMetadata) (string, error) {
+	mlToken, err := dbutils.GenToken(order.Buyer.String())
+	if err != nil {
+		return "", fmt.Errorf("> GenToken: %v", err)
+	}
+	logs.Normal(fmt.Sprintf("From buyer: %v ; mlToken: %v", order.Buyer, mlToken))
+
+	rt, err := containerRuntime()
+	if err != nil {
+		return "", err
+	}
+	containerID, err := docker.RunWorkspaceContainer(ctx, rt, m.isGPU, mlToken)
+	if err != nil {
+		return "", fmt.Errorf("> RunWorkspaceContainer: %v", err)
+	}
+
+	url := orderPlacedMetadata.OrderInfo.DownloadURL
+	if len(url) > 0 {
+		modelDir := config.GlobalConfig.Console.WorkDirectory + "/ml-workspace"
+		var modelURL []utils.DownloadURL
+
+		// Easy debugging
+		for _, u := range url {
+			modelURL = append(modelURL, utils.DownloadURL{
+				URL: config.GlobalConfig.Console.IpfsNodeUrl + "/ipfs" + utils.EnsureLeadingSlash(u),
+				// URL:      u,
+				Checksum: "",
+				Name:     "CID.json",
+			})
+		}
+
+		logs.Normal("Downloading CID.json ...")
+		err = utils.DownloadFiles(modelDir, modelURL)
+		if err != nil {
+			logs.Error(fmt.Sprintf("DownloadFiles %v", err))
+		}
+
+		items, err := utils.GetCidItemsFromFile(modelDir + "/CID.json")
+		if err != nil {
+			logs.Error(fmt.Sprintf("GetCidItemsFromFile %v", err))
+		}
+
+		modelURL = nil
+		for _, item := range items {
+			modelURL = append(modelURL, utils.DownloadURL{
+				URL:      config.GlobalConfig.Console.IpfsNodeUrl + "/ipfs" + utils.EnsureLeadingSlash(item.Cid),
+				Checksum: "",
+				Name:     item.Name,
+			})
+		}
+
+		logs.Normal("Downloading the following files...")
+		for _, url := range modelURL {
+			logs.Normal(url.Name)
+		}
+
+		err = utils.DownloadFiles(modelDir, modelURL)
+		if err != nil {
+			logs.Error(fmt.Sprintf("DownloadFiles %v", err))
+		}
+	}
+	return containerID, nil
+}
+
+func (m *OrderMachine) provisionDeploy(ctx context.Context, order distri_ai.Order, orderPlacedMetadata pattern.OrderPlacedMetadata) (string, error) {
+	_, err := dbutils.GenToken(order.Buyer.String())
+	if err != nil {
+		return "", fmt.Errorf("> GenToken: %v", err)
+	}
+
+	// Easy debugging
+	var downloadDeployURL []string
+
+	url := orderPlacedMetadata.OrderInfo.DownloadURL
+	if len(url) > 0 {
+		deployDir := config.GlobalConfig.Console.WorkDirectory
+		var deployURL []utils.DownloadURL
+		deployURL = append(deployURL, utils.DownloadURL{
+			URL:      config.GlobalConfig.Console.IpfsNodeUrl + "/ipfs" + utils.EnsureLeadingSlash(url[0]),
+			Checksum: "",
+			Name:     "CID.json",
+		})
+
+		logs.Normal("Downloading CID.json ...")
+		err = utils.DownloadFiles(deployDir, deployURL)
+		if err != nil {
+			logs.Error(fmt.Sprintf("DownloadFiles: %v", err))
+		}
+
+		items, err := utils.GetCidItemsFromFile(deployDir + "/CID.json")
+		if err != nil {
+			logs.Error(fmt.Sprintf("GetCidItemsFromFile: %v", err))
+		}
+
+		err = os.Remove(deployDir + "/CID.json")
+		if err != nil {
+			logs.Error(fmt.Sprintf("Remove CID.json: %v", err))
+		}
+
+		for _, item := range items {
+			downloadDeployURL = append(downloadDeployURL, config.GlobalConfig.Console.IpfsNodeUrl+utils.EnsureLeadingSlash(item.Cid))
+		}
+	}
+
+	logs.Normal("Run deploy container ...")
+	logs.Normal(fmt.Sprintf("DownloadDeployURL: %v", downloadDeployURL))
+
+	rt, err := containerRuntime()
+	if err != nil {
+		return "", err
+	}
+	containerID, err := docker.RunDeployContainer(ctx, rt, m.isGPU, downloadDeployURL)
+	if err != nil {
+		return "", fmt.Errorf("> RunDeployContainer: %v", err)
+	}
+	return containerID, nil
+}
+
+// stopContainer removes the container of the journaled order if it still exists.
+func (m *OrderMachine) stopContainer() {
+	if m.journal == nil || m.journal.ContainerID == "" {
+		return
+	}
+	if err := stopContainerIfExists(m.journal.ContainerID, true); err != nil {
+		logs.Error(fmt.Sprintf("> StopWorkspaceContainer, containerID: %s, err: %v", m.journal.ContainerID, err))
+	}
+}
+
+// save journals the current state, logging instead of failing so the order keeps progressing.
+func (m *OrderMachine) save() {
+	if err := saveOrderJournal(m.journal); err != nil {
+		logs.Error(fmt.Sprintf("saveOrderJournal: %v", err))
+	}
+	if journalObserver != nil {
+		journalObserver(*m.journal)
+	}
+}
+
+// clear forgets the active order.
+func (m *OrderMachine) clear() {
+	m.journal = nil
+	if err := dbutils.Delete(dbutils.GetDB(), []byte(orderJournalKey)); err != nil {
+		logs.Error(fmt.Sprintf("Delete orderJournal: %v", err))
+	}
+	if err := dbutils.Delete(dbutils.GetDB(), []byte(orderEventKey)); err != nil {
+		logs.Error(fmt.Sprintf("Delete orderEvent: %v", err))
+	}
+	if journalObserver != nil {
+		journalObserver(OrderJournal{})
+	}
+}
+
+// orderEndTime returns the time at which a Training order has to be completed.
+func orderEndTime(order distri_ai.Order) time.Time {
+	return time.Unix(order.StartTime, 0).Add(time.Hour * time.Duration(order.Duration))
+}
+
+// GetOrderJournal returns the journal of the active order, nil when there is none.
+func GetOrderJournal() (*OrderJournal, error) {
+	return loadOrderJournal()
+}
+
+func loadOrderJournal() (*OrderJournal, error) {
+	data, err := dbutils.Get(dbutils.GetDB(), []byte(orderJournalKey))
+	if err != nil {
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("> dbutils.Get: %v", err)
+	}
+
+	var journal OrderJournal
+	if err := json.Unmarshal(data, &journal); err != nil {
+		return nil, fmt.Errorf("> json.Unmarshal: %v", err)
+	}
+	return &journal, nil
+}
+
+func saveOrderJournal(journal *OrderJournal) error {
+	data, err := json.Marshal(journal)
+	if err != nil {
+		return fmt.Errorf("> json.Marshal: %v", err)
+	}
+	return dbutils.Update(dbutils.GetDB(), []byte(orderJournalKey), data)
+}
@@ -0,0 +1,405 @@
+package control
+
+import (
+	"SuperNet-Node/chain"
+	"SuperNet-Node/chain/conn"
+	"SuperNet-Node/chain/fakerpc"
+	"SuperNet-Node/chain/super"
+	"SuperNet-Node/chain/super/distri_ai"
+	"SuperNet-Node/config"
+	"SuperNet-Node/docker"
+	"SuperNet-Node/machine_info"
+	"SuperNet-Node/machine_info/machine_uuid"
+	"SuperNet-Node/pattern"
+	"SuperNet-Node/utils"
+	dbutils "SuperNet-Node/utils/db_utils"
+	logs "SuperNet-Node/utils/log_utils"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+// testHour is the real time standing for an hour of the orders placed by the tests.
+const testHour = 3 * time.Second
+
+// testTimeout bounds the wait for a transition of the order.
+const testTimeout = 20 * time.Second
+
+// testFiles are the IPFS files of the train orders, by CID.
+var testFiles = map[string]string{
+	"QmTestCidJson": `[{"name":"train.py","cid":"QmTestTrain"}]`,
+	"QmTestTrain":   "print('training')\n",
+}
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "order-test")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	dbutils.SetPath(filepath.Join(dir, "badger"))
+	logs.SetDirectory(filepath.Join(dir, "logs"))
+	config.GlobalConfig.Console.AuditLog = filepath.Join(dir, "audit")
+	if err := super.OpenAuditLog(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	// The fake chain confirms at once and has no WebSocket, the node polls it.
+	conn.ConfirmPollInterval = 50 * time.Millisecond
+	conn.PollInterval = 200 * time.Millisecond
+	// A transaction fails within an outage of a few RetryInterval, leaving it to the order machine.
+	conn.RetryBackoff = 10 * time.Millisecond
+	conn.MaxRetryBackoff = 40 * time.Millisecond
+	RetryInterval = 200 * time.Millisecond
+
+	code := m.Run()
+	super.CloseAuditLog()
+	dbutils.CloseDB()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// hookRuntime is a FakeRuntime calling onRun once a container is created.
+type hookRuntime struct {
+	*docker.FakeRuntime
+	onRun func(spec docker.ContainerSpec)
+}
+
+func (r *hookRuntime) Run(ctx context.Context, spec docker.ContainerSpec) (string, error) {
+	id, err := r.FakeRuntime.Run(ctx, spec)
+	if r.onRun != nil {
+		r.onRun(spec)
+	}
+	return id, err
+}
+
+// testNode is a node serving orders on the fake chain, with the buyer placing them.
+type testNode struct {
+	t       *testing.T
+	cluster *fakerpc.Server
+	runtime *hookRuntime
+	node    *super.WrapperSuper
+	buyer   *super.WrapperBuyer
+}
+
+// newTestNode registers a machine for rent on a new fake chain and serves its orders until the test ends.
+func newTestNode(t *testing.T) *testNode {
+	cluster := fakerpc.NewServer(solana.MustPublicKeyFromBase58(pattern.PROGRAM_SUPER_ID))
+	// Orders start in the past, so that they end after testHour.
+	skew := time.Hour - testHour
+	cluster.SetClock(func() time.Time { return time.Now().Add(-skew) })
+	rpcServer := httptest.NewServer(cluster)
+	t.Cleanup(rpcServer.Close)
+	ipfs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := testFiles[strings.TrimPrefix(r.URL.Path, "/ipfs/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+	t.Cleanup(ipfs.Close)
+
+	owner, err := solana.NewRandomPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	buyer, err := solana.NewRandomPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.GlobalConfig.Base.Rpc = rpcServer.URL
+	config.GlobalConfig.Base.PrivateKey = owner.String()
+	config.GlobalConfig.Console.IpfsNodeUrl = ipfs.URL
+	config.GlobalConfig.Console.WorkDirectory = t.TempDir()
+	config.GlobalConfig.Order.GracePeriod = "0"
+	config.GlobalConfig.Order.CompleteOnExit = false
+	config.GlobalConfig.Order.Warnings = []string{}
+	runtime := &hookRuntime{FakeRuntime: docker.NewFakeRuntime()}
+	SetRuntime(runtime)
+
+	var uuid [16]byte
+	rand.Read(uuid[:])
+	info, err := chain.GetChainInfo(NewSolanaConfig(), machine_uuid.MachineUUID(hex.EncodeToString(uuid[:])))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := &testNode{
+		t:       t,
+		cluster: cluster,
+		runtime: runtime,
+		node:    super.NewSuperWrapper(info),
+		buyer:   super.NewBuyerWrapper(info.Conn, buyer, nil),
+	}
+	t.Cleanup(n.node.Conn.Close)
+
+	hwInfo := machine_info.MachineInfo{MachineUUID: n.node.MachineUUID}
+	hwInfo.CPUInfo.ModelName = "Test CPU"
+	if _, err := n.node.AddMachine(context.Background(), hwInfo); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.node.MakeOffer(context.Background(), utils.SntToUnits(1), 24, 100); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	orders := NewOrderMachine(n.node, false)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		orders.Serve(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		// The next test starts without an order.
+		dbutils.Delete(dbutils.GetDB(), []byte(orderJournalKey))
+	})
+	return n
+}
+
+// placeOrder places a train order of an hour, signed by the buyer.
+func (n *testNode) placeOrder() solana.PublicKey {
+	order, _, err := PlaceOrder(context.Background(), n.buyer, n.node.ProgramSuperMachine, 1, "train", "test", []string{"QmTestCidJson"})
+	if err != nil {
+		n.t.Fatal(err)
+	}
+	return order
+}
+
+// order reads an order from the fake chain.
+func (n *testNode) order(account solana.PublicKey) distri_ai.Order {
+	var order distri_ai.Order
+	data, ok := n.cluster.Account(account)
+	if !ok {
+		n.t.Fatalf("order %v does not exist", account)
+	}
+	if err := order.UnmarshalWithDecoder(bin.NewBorshDecoder(data)); err != nil {
+		n.t.Fatal(err)
+	}
+	return order
+}
+
+// waitFor polls cond until it holds, failing the test after testTimeout.
+func (n *testNode) waitFor(what string, cond func() bool) {
+	n.t.Helper()
+	deadline := time.Now().Add(testTimeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			n.t.Fatalf("timed out waiting for %v", what)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// waitOrder waits until the order has status.
+func (n *testNode) waitOrder(account solana.PublicKey, status distri_ai.OrderStatus) {
+	n.t.Helper()
+	n.waitFor(fmt.Sprintf("order %v", status), func() bool { return n.order(account).Status == status })
+}
+
+// waitSettled waits until the node dropped the order, removed its containers and the machine is for rent.
+func (n *testNode) waitSettled() {
+	n.t.Helper()
+	n.waitFor("the node to release the order", func() bool {
+		journal, err := loadOrderJournal()
+		if err != nil || journal != nil || len(n.runtime.Containers()) > 0 {
+			return false
+		}
+		var machine distri_ai.Machine
+		data, ok := n.cluster.Account(n.node.ProgramSuperMachine)
+		return ok && machine.UnmarshalWithDecoder(bin.NewBorshDecoder(data)) == nil &&
+			machine.Status == distri_ai.MachineStatusForRent
+	})
+}
+
+// journalState returns the state of the journaled order, "" when there is none.
+func (n *testNode) journalState() OrderState {
+	journal, err := loadOrderJournal()
+	if err != nil {
+		n.t.Fatal(err)
+	}
+	if journal == nil {
+		return ""
+	}
+	return journal.State
+}
+
+// instructions returns the instructions of the transactions landed on the fake chain.
+func (n *testNode) instructions() []string {
+	var instructions []string
+	for _, tx := range n.cluster.Transactions() {
+		if tx.Err != nil {
+			continue
+		}
+		names, _ := super.ParseLogs(n.node.ProgramSuperID, tx.Logs)
+		instructions = append(instructions, names...)
+	}
+	return instructions
+}
+
+// workspaceRunning reports whether the workspace container is running.
+func (n *testNode) workspaceRunning() bool {
+	return slices.ContainsFunc(n.runtime.Containers(), func(c docker.FakeContainer) bool {
+		return c.Spec.Name == pattern.ML_WORKSPACE_CONTAINER && c.Running
+	})
+}
+
+// outage takes the fake chain down for d.
+func (n *testNode) outage(d time.Duration) {
+	n.cluster.SetDown(true)
+	time.AfterFunc(d, func() { n.cluster.SetDown(false) })
+}
+
+// expectRetried checks that the transaction name of the order failed before it was confirmed.
+func (n *testNode) expectRetried(order solana.PublicKey, name string) {
+	n.t.Helper()
+	records, err := super.History(super.HistoryFilter{Order: order.String(), Name: name})
+	if err != nil {
+		n.t.Fatal(err)
+	}
+	var statuses []string
+	for _, record := range records {
+		statuses = append(statuses, record.Status)
+	}
+	if len(statuses) < 2 || statuses[0] != super.AuditFailed || statuses[len(statuses)-1] != super.AuditConfirmed {
+		n.t.Errorf("%v statuses = %v, want failed attempts then confirmed", name, statuses)
+	}
+}
+
+func (n *testNode) expectInstructions(want ...string) {
+	n.t.Helper()
+	if got := n.instructions(); !slices.Equal(got, want) {
+		n.t.Errorf("instructions = %v, want %v", got, want)
+	}
+}
+
+func TestOrderLifecycle(t *testing.T) {
+	n := newTestNode(t)
+	order := n.placeOrder()
+
+	n.waitOrder(order, distri_ai.OrderStatusTraining)
+	n.waitFor("the order to run", func() bool { return n.journalState() == OrderStateRunning })
+	if !n.workspaceRunning() {
+		t.Error("the workspace container is not running while the order is served")
+	}
+
+	n.waitOrder(order, distri_ai.OrderStatusCompleted)
+	n.waitSettled()
+	n.expectInstructions("AddMachine", "MakeOffer", "PlaceOrder", "StartOrder", "OrderCompleted")
+}
+
+func TestOrderRefunded(t *testing.T) {
+	n := newTestNode(t)
+	order := n.placeOrder()
+	n.waitFor("the order to run", func() bool { return n.journalState() == OrderStateRunning })
+
+	if _, err := n.buyer.RefundOrder(context.Background(), order, n.order(order)); err != nil {
+		t.Fatal(err)
+	}
+	n.waitSettled()
+	if status := n.order(order).Status; status != distri_ai.OrderStatusRefunded {
+		t.Errorf("order status = %v, want Refunded", status)
+	}
+	n.expectInstructions("AddMachine", "MakeOffer", "PlaceOrder", "StartOrder", "RefundOrder")
+}
+
+func TestOrderFailed(t *testing.T) {
+	n := newTestNode(t)
+	n.runtime.FailRun(pattern.ML_WORKSPACE_NAME, errors.New("start failure"))
+	order := n.placeOrder()
+
+	n.waitOrder(order, distri_ai.OrderStatusFailed)
+	n.waitSettled()
+	n.expectInstructions("AddMachine", "MakeOffer", "PlaceOrder", "OrderFailed")
+}
+
+func TestOrderStartRetried(t *testing.T) {
+	n := newTestNode(t)
+	// The chain goes down as the container starts, so that OrderStart fails.
+	n.runtime.onRun = func(spec docker.ContainerSpec) {
+		if spec.Name == pattern.ML_WORKSPACE_CONTAINER {
+			n.runtime.onRun = nil
+			n.outage(3 * RetryInterval)
+		}
+	}
+	order := n.placeOrder()
+
+	n.waitOrder(order, distri_ai.OrderStatusTraining)
+	n.waitOrder(order, distri_ai.OrderStatusCompleted)
+	n.waitSettled()
+	n.expectInstructions("AddMachine", "MakeOffer", "PlaceOrder", "StartOrder", "OrderCompleted")
+	n.expectRetried(order, "order_start")
+}
+
+func TestOrderFailedRetried(t *testing.T) {
+	n := newTestNode(t)
+	n.runtime.FailRun(pattern.ML_WORKSPACE_NAME, errors.New("start failure"))
+	n.runtime.onRun = func(spec docker.ContainerSpec) {
+		if spec.Name == pattern.ML_WORKSPACE_CONTAINER {
+			n.runtime.onRun = nil
+			n.outage(3 * RetryInterval)
+		}
+	}
+	order := n.placeOrder()
+
+	n.waitOrder(order, distri_ai.OrderStatusFailed)
+	n.waitSettled()
+	n.expectInstructions("AddMachine", "MakeOffer", "PlaceOrder", "OrderFailed")
+	n.expectRetried(order, "order_failed")
+}
+
+func TestOrderCompletedRetried(t *testing.T) {
+	n := newTestNode(t)
+	// The chain goes down as the order is scored, OrderCompleted is sent again once it is back.
+	n.runtime.onRun = func(spec docker.ContainerSpec) {
+		if spec.Name == pattern.SCORE_CONTAINER {
+			n.runtime.onRun = nil
+			n.outage(5 * RetryInterval)
+		}
+	}
+	order := n.placeOrder()
+	n.waitOrder(order, distri_ai.OrderStatusTraining)
+	n.waitFor("OrderCompleted to fail", func() bool { return n.journalState() == OrderStateCompleting })
+
+	n.waitOrder(order, distri_ai.OrderStatusCompleted)
+	n.waitSettled()
+	n.expectInstructions("AddMachine", "MakeOffer", "PlaceOrder", "StartOrder", "OrderCompleted")
+	n.expectRetried(order, "order_completed")
+}
+
+func TestOrderResumeChainDown(t *testing.T) {
+	n := newTestNode(t)
+	order := n.placeOrder()
+	n.waitFor("the order to run", func() bool { return n.journalState() == OrderStateRunning })
+
+	// A restart while the chain is unreachable leaves the order it cannot read alone.
+	n.cluster.SetDown(true)
+	if err := NewOrderMachine(n.node, false).Resume(context.Background()); err == nil {
+		t.Error("Resume with the chain down: no error")
+	}
+	n.cluster.SetDown(false)
+	if state := n.journalState(); state != OrderStateRunning {
+		t.Errorf("journal state %q after Resume, want %q", state, OrderStateRunning)
+	}
+	if !n.workspaceRunning() {
+		t.Error("Resume with the chain down stopped the workspace container")
+	}
+
+	n.waitOrder(order, distri_ai.OrderStatusCompleted)
+	n.waitSettled()
+}
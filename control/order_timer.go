@@ -0,0 +1,205 @@
+package control
+
+import (
+	"SuperNet-Node/config"
+	dbutils "SuperNet-Node/utils/db_utils"
+	logs "SuperNet-Node/utils/log_utils"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+const (
+	OrderEventStarted = "started"
+	OrderEventRenewed = "renewed"
+	OrderEventWarning = "warning"
+	OrderEventEnded   = "ended"
+)
+
+// orderEventKey is the Badger key holding the last event of the active order.
+const orderEventKey = "orderEvent"
+
+// orderEventFile is written in the directory mounted as /workspace, so the buyer sees it in the container.
+const orderEventFile = ".order.json"
+
+// OrderEvent is a notification about the order served by this machine.
+type OrderEvent struct {
+	Event   string    `json:"Event"`
+	Order   string    `json:"Order"`
+	Buyer   string    `json:"Buyer"`
+	EndTime time.Time `json:"EndTime"`
+	// Remaining is the time left until EndTime, in seconds.
+	Remaining int64     `json:"Remaining"`
+	Time      time.Time `json:"Time"`
+}
+
+// OrderTimer fires at the configured warnings before the end of an order, then at its end.
+type OrderTimer struct {
+	// warnings are sorted from the longest to the shortest.
+	warnings []time.Duration
+	end      time.Time
+	// next is the index of the next warning to fire, len(warnings) when only the end is left.
+	next  int
+	timer *time.Timer
+}
+
+// NewOrderTimer returns a stopped timer; Reset schedules it.
+func NewOrderTimer(warnings []time.Duration) *OrderTimer {
+	t := &OrderTimer{
+		warnings: slices.Clone(warnings),
+		timer:    time.NewTimer(time.Hour),
+	}
+	slices.SortFunc(t.warnings, func(a, b time.Duration) int { return int(b - a) })
+	t.timer.Stop()
+	return t
+}
+
+// C fires when the next event is due; Fire tells which one it is.
+func (t *OrderTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+// End returns the end time the timer is scheduled for.
+func (t *OrderTimer) End() time.Time {
+	return t.end
+}
+
+// Reset schedules the events of an order ending at end and reports whether end changed.
+// Warnings that are already past are skipped, so a renewal warns again before the new end.
+func (t *OrderTimer) Reset(end time.Time) bool {
+	if end.Equal(t.end) {
+		return false
+	}
+	t.end = end
+	t.next = 0
+	now := time.Now()
+	for t.next < len(t.warnings) && !now.Before(end.Add(-t.warnings[t.next])) {
+		t.next++
+	}
+	t.arm(now)
+	return true
+}
+
+// Fire returns the event that made C fire, the warning before the end or ended, and schedules the next one.
+func (t *OrderTimer) Fire() (warning time.Duration, ended bool) {
+	if t.next < len(t.warnings) {
+		warning = t.warnings[t.next]
+		t.next++
+		t.arm(time.Now())
+		return warning, false
+	}
+	return 0, true
+}
+
+// Delay fires C again after d, for an end that could not be handled yet.
+func (t *OrderTimer) Delay(d time.Duration) {
+	t.drain()
+	t.timer.Reset(d)
+}
+
+// Stop cancels the pending event.
+func (t *OrderTimer) Stop() {
+	t.timer.Stop()
+}
+
+func (t *OrderTimer) arm(now time.Time) {
+	t.drain()
+	at := t.end
+	if t.next < len(t.warnings) {
+		at = t.end.Add(-t.warnings[t.next])
+	}
+	t.timer.Reset(at.Sub(now))
+}
+
+// drain stops the timer and empties C, so that it can be reset.
+func (t *OrderTimer) drain() {
+	if !t.timer.Stop() {
+		select {
+		case <-t.timer.C:
+		default:
+		}
+	}
+}
+
+// orderWarnings parses the configured warnings, skipping invalid ones.
+func orderWarnings() []time.Duration {
+	var warnings []time.Duration
+	for _, s := range config.CurrentOrder().Warnings {
+		warning, err := time.ParseDuration(s)
+		if err != nil || warning <= 0 {
+			logs.Warning(fmt.Sprintf("Invalid order warning %q: %v", s, err))
+			continue
+		}
+		warnings = append(warnings, warning)
+	}
+	return warnings
+}
+
+// notifyOrder publishes event to the workspace, the node API and the webhook.
+// Failures are logged, a notification never holds up the order.
+func notifyOrder(event OrderEvent) {
+	event.Time = time.Now()
+	event.Remaining = int64(max(time.Until(event.EndTime), 0) / time.Second)
+	logs.Normal(fmt.Sprintf("Order %v %v, ends %v", event.Order, event.Event, event.EndTime.Format(time.DateTime)))
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		logs.Error(fmt.Sprintf("json.Marshal: %v", err))
+		return
+	}
+	if err := dbutils.Update(dbutils.GetDB(), []byte(orderEventKey), data); err != nil {
+		logs.Error(fmt.Sprintf("Update order event: %v", err))
+	}
+
+	dir := config.GlobalConfig.Console.WorkDirectory + "/ml-workspace"
+	if _, err := os.Stat(dir); err == nil {
+		if err := os.WriteFile(dir+"/"+orderEventFile, data, 0644); err != nil {
+			logs.Warning(fmt.Sprintf("Write %v: %v", orderEventFile, err))
+		}
+	}
+
+	if url := config.CurrentOrder().Webhook; url != "" {
+		go func() {
+			if err := postOrderEvent(url, data); err != nil {
+				logs.Warning(fmt.Sprintf("Order webhook %v: %v", url, err))
+			}
+		}()
+	}
+}
+
+func postOrderEvent(url string, data []byte) error {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("status %v", resp.Status)
+	}
+	return nil
+}
+
+// GetOrderEvent returns the last event of the active order, nil when no order is served.
+func GetOrderEvent() (*OrderEvent, error) {
+	data, err := dbutils.Get(dbutils.GetDB(), []byte(orderEventKey))
+	if err != nil {
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("> dbutils.Get: %v", err)
+	}
+
+	var event OrderEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, fmt.Errorf("> json.Unmarshal: %v", err)
+	}
+	return &event, nil
+}
@@ -0,0 +1,125 @@
+package control
+
+import (
+	"SuperNet-Node/chain/super"
+	"SuperNet-Node/chain/super/distri_ai"
+	"SuperNet-Node/utils"
+	dbutils "SuperNet-Node/utils/db_utils"
+	logs "SuperNet-Node/utils/log_utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// pauseKey is the Badger key holding the pause of the node, absent when it takes orders.
+const pauseKey = "pause"
+
+// Pause keeps the machine off the market, with the offer to restore when it is resumed.
+type Pause struct {
+	Since       time.Time `json:"Since"`
+	Price       uint64    `json:"Price"`
+	MaxDuration uint32    `json:"MaxDuration"`
+	Disk        uint32    `json:"Disk"`
+}
+
+func (p Pause) String() string {
+	return fmt.Sprintf("paused since %v, offer to restore: %v SNT/h, MaxDuration: %vh, Disk: %vGB",
+		p.Since.Format(time.DateTime), utils.UnitsToSnt(p.Price), p.MaxDuration, p.Disk)
+}
+
+// GetPause returns the pause of the node, nil when it takes orders.
+func GetPause() (*Pause, error) {
+	data, err := dbutils.Get(dbutils.GetDB(), []byte(pauseKey))
+	if err != nil {
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("> dbutils.Get: %v", err)
+	}
+	var pause Pause
+	if err := json.Unmarshal(data, &pause); err != nil {
+		return nil, fmt.Errorf("> json.Unmarshal: %v", err)
+	}
+	return &pause, nil
+}
+
+// PauseMachine stops taking orders: the offer is cancelled now if the machine is ForRent,
+// or once the order it serves ends. The order in progress is served until its end.
+func PauseMachine(ctx context.Context, superWrapper *super.WrapperSuper) (*Pause, error) {
+	if pause, err := GetPause(); err != nil || pause != nil {
+		return pause, err
+	}
+	machine, err := superWrapper.GetMachine(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("> GetMachine: %v", err)
+	}
+	if machine.Status == distri_ai.MachineStatusIdle {
+		return nil, errors.New("machine is Idle, there is no offer to pause")
+	}
+
+	pause := &Pause{
+		Since:       time.Now(),
+		Price:       machine.Price,
+		MaxDuration: machine.MaxDuration,
+		Disk:        machine.Disk,
+	}
+	data, err := json.Marshal(pause)
+	if err != nil {
+		return nil, fmt.Errorf("> json.Marshal: %v", err)
+	}
+	if err := dbutils.Update(dbutils.GetDB(), []byte(pauseKey), data); err != nil {
+		return nil, fmt.Errorf("> dbutils.Update: %v", err)
+	}
+	logs.Normal(fmt.Sprintf("Node %v", pause))
+
+	if machine.Status == distri_ai.MachineStatusForRent {
+		if err := holdOffer(ctx, superWrapper); err != nil {
+			return pause, err
+		}
+	}
+	return pause, nil
+}
+
+// ResumeMachine lifts the pause, listing the machine again with the offer it had when paused.
+func ResumeMachine(ctx context.Context, superWrapper *super.WrapperSuper) error {
+	pause, err := GetPause()
+	if err != nil {
+		return err
+	}
+	if pause == nil {
+		return errors.New("node is not paused")
+	}
+	machine, err := superWrapper.GetMachine(ctx)
+	if err != nil {
+		return fmt.Errorf("> GetMachine: %v", err)
+	}
+	if machine.Status == distri_ai.MachineStatusIdle {
+		if _, err := superWrapper.MakeOffer(ctx, pause.Price, pause.MaxDuration, pause.Disk); err != nil {
+			if !errors.Is(err, super.ErrAwaitingSignature) {
+				return fmt.Errorf("> MakeOffer: %v", err)
+			}
+			logs.Normal("The machine is listed again once the signed MakeOffer is submitted")
+		}
+	}
+	if err := dbutils.Delete(dbutils.GetDB(), []byte(pauseKey)); err != nil {
+		return fmt.Errorf("> dbutils.Delete: %v", err)
+	}
+	logs.Normal("Node resumed")
+	return nil
+}
+
+// holdOffer cancels the offer of a ForRent machine while the node is paused.
+func holdOffer(ctx context.Context, superWrapper *super.WrapperSuper) error {
+	if _, err := superWrapper.CancelOffer(ctx); err != nil {
+		if errors.Is(err, super.ErrAwaitingSignature) {
+			logs.Normal("The machine is off the market once the signed CancelOffer is submitted")
+			return nil
+		}
+		return fmt.Errorf("> CancelOffer: %v", err)
+	}
+	return nil
+}
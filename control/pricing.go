@@ -0,0 +1,214 @@
+package control
+
+import (
+	"SuperNet-Node/chain/super"
+	"SuperNet-Node/chain/super/distri_ai"
+	"SuperNet-Node/config"
+	"SuperNet-Node/machine_info"
+	"SuperNet-Node/utils"
+	logs "SuperNet-Node/utils/log_utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// PricingDecision is the outcome of a repricing with the reasons behind it.
+type PricingDecision struct {
+	Time        time.Time `json:"Time"`
+	Current     float64   `json:"Current"`
+	Price       float64   `json:"Price"`
+	Comparables int       `json:"Comparables"`
+	Median      float64   `json:"Median"`
+	Utilization float64   `json:"Utilization"`
+	Changed     bool      `json:"Changed"`
+	Signature   string    `json:"Signature"`
+	// Steps explain how the price was reached, or why the offer is left as is.
+	Steps []string `json:"Steps"`
+}
+
+func (d *PricingDecision) String() string {
+	action := "keep"
+	if d.Changed {
+		action = "reprice"
+	}
+	return fmt.Sprintf("Pricing %v: %.4f -> %.4f SNT/h (%v)", action, d.Current, d.Price, strings.Join(d.Steps, "; "))
+}
+
+func (d *PricingDecision) step(format string, a ...interface{}) {
+	d.Steps = append(d.Steps, fmt.Sprintf(format, a...))
+}
+
+// Reprice updates the price of the offer from the offers of comparable machines.
+// Only a ForRent machine is repriced: an Idle machine was taken off the market by its owner
+// and the offer of a Renting machine cannot change. With dryRun the offer is left as is.
+func Reprice(ctx context.Context, super *super.WrapperSuper, cfg config.PricingConfig, now time.Time, dryRun bool) (*PricingDecision, error) {
+	decision := &PricingDecision{Time: now}
+
+	machine, err := super.GetMachine(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("> GetMachine: %v", err)
+	}
+	decision.Current = utils.UnitsToSnt(machine.Price)
+	decision.Price = decision.Current
+	if machine.Status != distri_ai.MachineStatusForRent {
+		decision.step("machine is %v", machine.Status)
+		return decision, nil
+	}
+
+	var info machine_info.MachineInfo
+	if err := json.Unmarshal([]byte(machine.Metadata), &info); err != nil {
+		return nil, fmt.Errorf("> json.Unmarshal metadata: %v", err)
+	}
+
+	machines, err := getMarketMachines(ctx, super)
+	if err != nil {
+		return nil, err
+	}
+
+	var prices []float64
+	var renting int
+	for _, m := range machines {
+		if m.Pubkey.Equals(super.ProgramSuperMachine) || !comparable(info, m.Info, cfg.TFLOPSTolerance) {
+			continue
+		}
+		switch m.Status {
+		case distri_ai.MachineStatusForRent:
+			prices = append(prices, utils.UnitsToSnt(m.Price))
+		case distri_ai.MachineStatusRenting:
+			renting++
+		}
+	}
+	decision.Comparables = len(prices)
+	if len(prices) < cfg.MinComparables {
+		decision.step("%v comparable offers, %v needed", len(prices), cfg.MinComparables)
+		return decision, nil
+	}
+
+	decision.Median = median(prices)
+	price := decision.Median * (1 - cfg.Undercut/100)
+	decision.step("median of %v comparable offers %.4f, undercut %v%% to %.4f", len(prices), decision.Median, cfg.Undercut, price)
+
+	hour := now.Hour()
+	for _, period := range cfg.TimeOfDay {
+		if inHours(hour, period.From, period.To) && period.Multiplier > 0 {
+			price *= period.Multiplier
+			decision.step("hours %v-%v x%v to %.4f", period.From, period.To, period.Multiplier, price)
+			break
+		}
+	}
+
+	decision.Utilization = float64(renting) / float64(renting+len(prices))
+	utilization := cfg.Utilization
+	if utilization.High > 0 && utilization.HighMultiplier > 0 && decision.Utilization >= utilization.High {
+		price *= utilization.HighMultiplier
+		decision.step("utilization %.0f%% x%v to %.4f", decision.Utilization*100, utilization.HighMultiplier, price)
+	} else if utilization.LowMultiplier > 0 && decision.Utilization <= utilization.Low {
+		price *= utilization.LowMultiplier
+		decision.step("utilization %.0f%% x%v to %.4f", decision.Utilization*100, utilization.LowMultiplier, price)
+	}
+
+	if cfg.Floor > 0 && price < cfg.Floor {
+		price = cfg.Floor
+		decision.step("floor %v", cfg.Floor)
+	}
+	if cfg.Ceiling > 0 && price > cfg.Ceiling {
+		price = cfg.Ceiling
+		decision.step("ceiling %v", cfg.Ceiling)
+	}
+	// Round to the smallest unit so that the comparison below matches what is stored on chain.
+	price = utils.UnitsToSnt(utils.SntToUnits(price))
+	decision.Price = price
+
+	if decision.Current > 0 && math.Abs(price-decision.Current)/decision.Current*100 < cfg.MinChange {
+		decision.step("change below %v%%", cfg.MinChange)
+		decision.Price = decision.Current
+		return decision, nil
+	}
+	decision.Changed = true
+	if dryRun {
+		decision.step("dry run")
+		return decision, nil
+	}
+
+	decision.Signature, err = super.MakeOffer(ctx, utils.SntToUnits(price), machine.MaxDuration, machine.Disk)
+	if err != nil {
+		return decision, fmt.Errorf("> MakeOffer: %w", err)
+	}
+	return decision, nil
+}
+
+// getMarketMachines returns every machine of the market.
+func getMarketMachines(ctx context.Context, superWrapper *super.WrapperSuper) ([]super.MarketMachine, error) {
+	machines, err := super.GetMarketMachines(ctx, superWrapper.Conn, superWrapper.ProgramSuperID, super.MachineFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("> GetMarketMachines: %v", err)
+	}
+	return machines, nil
+}
+
+// comparable reports whether other has the same GPUs as ours, or about the same TFLOPS.
+func comparable(ours, other machine_info.MachineInfo, tolerance float64) bool {
+	if ours.GPUInfo.Model != "" && ours.GPUInfo.Number == other.GPUInfo.Number &&
+		strings.EqualFold(strings.TrimSpace(ours.GPUInfo.Model), strings.TrimSpace(other.GPUInfo.Model)) {
+		return true
+	}
+	a, b := totalTFLOPS(ours), totalTFLOPS(other)
+	if a <= 0 || b <= 0 {
+		return false
+	}
+	return math.Abs(a-b)/a*100 <= tolerance
+}
+
+func totalTFLOPS(info machine_info.MachineInfo) float64 {
+	return float64(info.TFLOPSInfo.TFLOPS) * float64(max(info.GPUInfo.Number, 1))
+}
+
+func median(values []float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// inHours reports whether hour is in [from, to), wrapping around midnight when from > to.
+func inHours(hour, from, to int) bool {
+	if from <= to {
+		return hour >= from && hour < to
+	}
+	return hour >= from || hour < to
+}
+
+// StartPricingTask starts a ticker that reprices the offer of the machine until ctx is cancelled.
+func StartPricingTask(ctx context.Context, super *super.WrapperSuper) {
+	cfg := config.CurrentPricing()
+	ticker := time.NewTicker(time.Duration(cfg.Interval) * time.Minute)
+	tasks.Add(1)
+	configuredTasks.Add(1)
+	go func() {
+		defer tasks.Done()
+		defer configuredTasks.Done()
+		defer ticker.Stop()
+		for {
+			// A MakeOffer in progress is finished on shutdown.
+			decision, err := Reprice(context.WithoutCancel(ctx), super, cfg, time.Now(), false)
+			if decision != nil {
+				logs.Normal(decision.String())
+			}
+			if err != nil {
+				logs.Error(fmt.Sprintf("Reprice: %v", err))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,123 @@
+package control
+
+import (
+	"SuperNet-Node/chain/super"
+	"SuperNet-Node/config"
+	logs "SuperNet-Node/utils/log_utils"
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var (
+	configuredMu sync.Mutex
+	// configuredCtx is the context the configured tasks were started with, cancel stops them.
+	configuredCtx    context.Context
+	configuredCancel context.CancelFunc
+	// configuredTasks tracks the configured tasks, so that a restart waits for the ones it stops.
+	configuredTasks sync.WaitGroup
+)
+
+// StartConfiguredTasks starts the reward auto claim and the pricing tasks enabled in the configuration,
+// until ctx is cancelled or Reload restarts them. Neither runs with an offline owner key.
+func StartConfiguredTasks(ctx context.Context, superWrapper *super.WrapperSuper) {
+	configuredMu.Lock()
+	defer configuredMu.Unlock()
+	startConfiguredTasks(ctx, superWrapper)
+}
+
+// startConfiguredTasks stops the configured tasks and starts them again once the stopped ones
+// have returned, so that two claims or repricings never overlap; configuredMu must be held.
+func startConfiguredTasks(ctx context.Context, superWrapper *super.WrapperSuper) {
+	if configuredCancel != nil {
+		configuredCancel()
+		configuredTasks.Wait()
+	}
+	configuredCtx = ctx
+	taskCtx, cancel := context.WithCancel(ctx)
+	configuredCancel = cancel
+
+	if superWrapper.Wallet.Offline() {
+		return
+	}
+	if config.CurrentReward().AutoClaim {
+		StartAutoClaimTask(taskCtx, superWrapper)
+	}
+	if config.CurrentPricing().Enabled {
+		StartPricingTask(taskCtx, superWrapper)
+	}
+}
+
+// ReloadReport lists the sections of config.yml changed since the node started or last reloaded.
+type ReloadReport struct {
+	// Applied are the sections applied to the running node.
+	Applied []string `json:"Applied"`
+	// Restart are the sections that keep their current values until the next start.
+	Restart []string `json:"Restart"`
+}
+
+func (r ReloadReport) String() string {
+	if len(r.Applied) == 0 && len(r.Restart) == 0 {
+		return "Configuration unchanged"
+	}
+	var lines []string
+	if len(r.Applied) > 0 {
+		lines = append(lines, fmt.Sprintf("Applied: %v", strings.Join(r.Applied, ", ")))
+	}
+	if len(r.Restart) > 0 {
+		lines = append(lines, fmt.Sprintf("Restart the node to apply: %v", strings.Join(r.Restart, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Reload reads config.yml again. The order, pricing and reward sections are applied at once,
+// restarting the tasks they drive; the other sections hold the connections, keys and ports
+// of the node and only apply on the next start.
+func Reload(superWrapper *super.WrapperSuper) (ReloadReport, error) {
+	var report ReloadReport
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return report, fmt.Errorf("> LoadConfig: %v", err)
+	}
+
+	configuredMu.Lock()
+	defer configuredMu.Unlock()
+
+	current := config.GlobalConfig
+	sections := []struct {
+		name     string
+		old, new interface{}
+		live     bool
+	}{
+		{"base", current.Base, cfg.Base, false},
+		{"console", current.Console, cfg.Console, false},
+		{"priorityFee", current.PriorityFee, cfg.PriorityFee, false},
+		{"feePayer", current.FeePayer, cfg.FeePayer, false},
+		{"offline", current.Offline, cfg.Offline, false},
+		{"runtime", current.Runtime, cfg.Runtime, false},
+		{"reward", current.Reward, cfg.Reward, true},
+		{"pricing", current.Pricing, cfg.Pricing, true},
+		{"order", current.Order, cfg.Order, true},
+	}
+	for _, section := range sections {
+		if reflect.DeepEqual(section.old, section.new) {
+			continue
+		}
+		if section.live {
+			report.Applied = append(report.Applied, section.name)
+		} else {
+			report.Restart = append(report.Restart, section.name)
+		}
+	}
+
+	tasksChanged := !reflect.DeepEqual(current.Reward, cfg.Reward) || !reflect.DeepEqual(current.Pricing, cfg.Pricing)
+	config.SetLive(cfg)
+	if tasksChanged && configuredCtx != nil {
+		startConfiguredTasks(configuredCtx, superWrapper)
+	}
+
+	logs.Normal(report.String())
+	return report, nil
+}
@@ -0,0 +1,157 @@
+package control
+
+import (
+	"SuperNet-Node/chain/super"
+	"SuperNet-Node/utils"
+	dbutils "SuperNet-Node/utils/db_utils"
+	logs "SuperNet-Node/utils/log_utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// claimRecordPrefix prefixes the Badger keys of claim results, followed by the period.
+const claimRecordPrefix = "claim/"
+
+// ClaimRecord is the recorded result of claiming the rewards of a period.
+type ClaimRecord struct {
+	Period    uint32    `json:"Period"`
+	Signature string    `json:"Signature"`
+	Error     string    `json:"Error"`
+	Time      time.Time `json:"Time"`
+}
+
+// UnclaimedPeriods returns the finished periods in which the machine earned rewards that are not claimed yet.
+func UnclaimedPeriods(ctx context.Context, super *super.WrapperSuper) ([]uint32, error) {
+	rewardMachines, err := super.GetRewardMachines(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("> GetRewardMachines: %v", err)
+	}
+
+	currentPeriod := utils.CurrentPeriod()
+	var periods []uint32
+	for _, rewardMachine := range rewardMachines {
+		if !rewardMachine.Claimed && rewardMachine.Period < currentPeriod {
+			periods = append(periods, rewardMachine.Period)
+		}
+	}
+	return periods, nil
+}
+
+// ClaimPeriod claims the rewards of a finished period and records the result.
+func ClaimPeriod(ctx context.Context, super *super.WrapperSuper, period uint32) (string, error) {
+	if period >= utils.CurrentPeriod() {
+		return "", fmt.Errorf("period %v is not finished yet", period)
+	}
+
+	rewardMachine, err := super.GetRewardMachine(ctx, period)
+	if err != nil {
+		if errors.Is(err, rpc.ErrNotFound) {
+			return "", fmt.Errorf("no rewards earned in period %v", period)
+		}
+		return "", fmt.Errorf("> GetRewardMachine: %v", err)
+	}
+	if rewardMachine.Claimed {
+		return "", fmt.Errorf("period %v is already claimed", period)
+	}
+
+	sig, err := super.Claim(ctx, period)
+	recordClaim(period, sig, err)
+	if err != nil {
+		return sig, fmt.Errorf("> Claim: %v", err)
+	}
+	return sig, nil
+}
+
+// ClaimUnclaimed claims every finished period with unclaimed rewards, continuing past failures.
+func ClaimUnclaimed(ctx context.Context, super *super.WrapperSuper) ([]ClaimRecord, error) {
+	periods, err := UnclaimedPeriods(ctx, super)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []ClaimRecord
+	for _, period := range periods {
+		sig, err := super.Claim(ctx, period)
+		records = append(records, recordClaim(period, sig, err))
+	}
+	return records, nil
+}
+
+// GetClaimRecord returns the recorded result of the last claim of a period.
+func GetClaimRecord(period uint32) (ClaimRecord, error) {
+	var record ClaimRecord
+
+	db, err := dbutils.OpenDB()
+	if err != nil {
+		return record, fmt.Errorf("> OpenDB: %v", err)
+	}
+	data, err := dbutils.Get(db, []byte(fmt.Sprintf("%s%d", claimRecordPrefix, period)))
+	if err != nil {
+		return record, err
+	}
+	err = json.Unmarshal(data, &record)
+	return record, err
+}
+
+// recordClaim stores the result of a claim in Badger and returns it.
+func recordClaim(period uint32, sig string, claimErr error) ClaimRecord {
+	record := ClaimRecord{
+		Period:    period,
+		Signature: sig,
+		Time:      time.Now(),
+	}
+	if claimErr != nil {
+		record.Error = claimErr.Error()
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		logs.Error(fmt.Sprintf("json.Marshal: %v", err))
+		return record
+	}
+	db, err := dbutils.OpenDB()
+	if err != nil {
+		logs.Error(fmt.Sprintf("OpenDB: %v", err))
+		return record
+	}
+	if err := dbutils.Update(db, []byte(fmt.Sprintf("%s%d", claimRecordPrefix, period)), data); err != nil {
+		logs.Error(fmt.Sprintf("Update claim record: %v", err))
+	}
+	return record
+}
+
+// StartAutoClaimTask starts a ticker that claims the rewards of every finished period until ctx is cancelled.
+func StartAutoClaimTask(ctx context.Context, super *super.WrapperSuper) {
+	ticker := time.NewTicker(1 * time.Hour)
+	tasks.Add(1)
+	configuredTasks.Add(1)
+	go func() {
+		defer tasks.Done()
+		defer configuredTasks.Done()
+		defer ticker.Stop()
+		for {
+			// A claim in progress is finished on shutdown, so that its record matches the chain.
+			records, err := ClaimUnclaimed(context.WithoutCancel(ctx), super)
+			if err != nil {
+				logs.Error(fmt.Sprintf("ClaimUnclaimed: %v", err))
+			}
+			for _, record := range records {
+				if record.Error != "" {
+					logs.Error(fmt.Sprintf("Claim period %v failed: %v", record.Period, record.Error))
+				} else {
+					logs.Vital(fmt.Sprintf("Claimed period %v : %v", record.Period, record.Signature))
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,303 @@
+package control
+
+import (
+	"SuperNet-Node/config"
+	dbutils "SuperNet-Node/utils/db_utils"
+	logs "SuperNet-Node/utils/log_utils"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// workspaceGraceKey is the Badger key holding the grace period of the last order.
+const workspaceGraceKey = "workspaceGrace"
+
+// wipeReportPrefix prefixes the Badger keys of wipe reports, followed by the time of the wipe.
+const wipeReportPrefix = "wipe/"
+
+// WorkspaceGrace is the time after an order during which its workspace is kept for the buyer.
+type WorkspaceGrace struct {
+	Order string    `json:"Order"`
+	Buyer string    `json:"Buyer"`
+	Start time.Time `json:"Start"`
+	Until time.Time `json:"Until"`
+}
+
+// WipeReport records the wipe of a workspace.
+type WipeReport struct {
+	Order  string    `json:"Order"`
+	Buyer  string    `json:"Buyer"`
+	Reason string    `json:"Reason"`
+	Time   time.Time `json:"Time"`
+	Files  int       `json:"Files"`
+	Bytes  int64     `json:"Bytes"`
+	// GraceUntil is the end of the grace period before the wipe, zero when there was none.
+	GraceUntil time.Time `json:"GraceUntil"`
+	Errors     []string  `json:"Errors"`
+}
+
+var (
+	graceMu    sync.Mutex
+	graceTimer *time.Timer
+)
+
+// WorkspaceDir returns the directory mounted as /workspace in the containers of the orders.
+func WorkspaceDir() string {
+	return config.GlobalConfig.Console.WorkDirectory + "/ml-workspace"
+}
+
+// gracePeriod returns the configured grace period, 0 when the workspace is wiped at once.
+func gracePeriod() time.Duration {
+	period := config.CurrentOrder().GracePeriod
+	grace, err := time.ParseDuration(period)
+	if err != nil {
+		logs.Warning(fmt.Sprintf("Invalid order grace period %q: %v", period, err))
+		return 0
+	}
+	return max(grace, 0)
+}
+
+// releaseWorkspace stops the container of an ended order and keeps its workspace
+// for the grace period, or wipes it at once when there is none.
+func releaseWorkspace(containerID string, order string) error {
+	if err := stopContainerIfExists(containerID, false); err != nil {
+		return err
+	}
+
+	grace := gracePeriod()
+	if grace == 0 {
+		_, err := WipeWorkspace("order ended")
+		return err
+	}
+	return startGrace(order, grace)
+}
+
+// startGrace keeps the workspace and schedules its wipe. The container of the order is already
+// stopped and removed, so no process of the buyer writes to the workspace meanwhile.
+func startGrace(order string, grace time.Duration) error {
+	graceMu.Lock()
+	defer graceMu.Unlock()
+
+	current, err := GetWorkspaceGrace()
+	if err != nil {
+		return err
+	}
+	if current != nil && current.Order == order {
+		// A completion retried after a restart keeps the grace period it started.
+		scheduleWipe(current.Until)
+		return nil
+	}
+
+	buyer, _ := dbutils.Get(dbutils.GetDB(), []byte("buyer"))
+	now := time.Now()
+	current = &WorkspaceGrace{
+		Order: order,
+		Buyer: string(buyer),
+		Start: now,
+		Until: now.Add(grace),
+	}
+	data, err := json.Marshal(current)
+	if err != nil {
+		return fmt.Errorf("> json.Marshal: %v", err)
+	}
+	if err := dbutils.Update(dbutils.GetDB(), []byte(workspaceGraceKey), data); err != nil {
+		return fmt.Errorf("> dbutils.Update: %v", err)
+	}
+	// uploadFile bounds the uploads by the end of the grace period instead of the order.
+	dbutils.Update(dbutils.GetDB(), []byte("orderEndTime"), []byte(current.Until.Format(time.RFC3339)))
+
+	logs.Normal(fmt.Sprintf("Workspace of order %v is kept until %v", order, current.Until.Format(time.DateTime)))
+	scheduleWipe(current.Until)
+	return nil
+}
+
+// ResumeWorkspaceGrace schedules the wipe of a workspace whose grace period was started before a restart.
+func ResumeWorkspaceGrace() error {
+	graceMu.Lock()
+	defer graceMu.Unlock()
+
+	grace, err := GetWorkspaceGrace()
+	if err != nil || grace == nil {
+		return err
+	}
+	logs.Normal(fmt.Sprintf("Workspace of order %v is kept until %v", grace.Order, grace.Until.Format(time.DateTime)))
+	scheduleWipe(grace.Until)
+	return nil
+}
+
+// scheduleWipe wipes the workspace at until; graceMu must be held.
+func scheduleWipe(until time.Time) {
+	if graceTimer != nil {
+		graceTimer.Stop()
+	}
+	graceTimer = time.AfterFunc(time.Until(until), func() {
+		graceMu.Lock()
+		defer graceMu.Unlock()
+
+		// The workspace may have been wiped early for a new order meanwhile.
+		grace, err := GetWorkspaceGrace()
+		if err != nil || grace == nil || !grace.Until.Equal(until) {
+			return
+		}
+		if _, err := WipeWorkspace("grace period ended"); err != nil {
+			logs.Error(fmt.Sprintf("WipeWorkspace: %v", err))
+		}
+	})
+}
+
+// stopGrace cancels the scheduled wipe of the workspace, waiting for one in progress.
+func stopGrace() {
+	graceMu.Lock()
+	defer graceMu.Unlock()
+	if graceTimer != nil {
+		graceTimer.Stop()
+	}
+}
+
+// GetWorkspaceGrace returns the current grace period, nil when there is none.
+func GetWorkspaceGrace() (*WorkspaceGrace, error) {
+	data, err := dbutils.Get(dbutils.GetDB(), []byte(workspaceGraceKey))
+	if err != nil {
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("> dbutils.Get: %v", err)
+	}
+
+	var grace WorkspaceGrace
+	if err := json.Unmarshal(data, &grace); err != nil {
+		return nil, fmt.Errorf("> json.Unmarshal: %v", err)
+	}
+	return &grace, nil
+}
+
+// WipeWorkspace overwrites every file of the workspace before removing it, ending the grace period if any,
+// and records a report. Files that cannot be overwritten are still removed and listed in the report.
+func WipeWorkspace(reason string) (*WipeReport, error) {
+	grace, err := GetWorkspaceGrace()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &WipeReport{
+		Reason: reason,
+		Time:   time.Now(),
+	}
+	if grace != nil {
+		report.Order, report.Buyer, report.GraceUntil = grace.Order, grace.Buyer, grace.Until
+	} else if buyer, err := dbutils.Get(dbutils.GetDB(), []byte("buyer")); err == nil {
+		report.Buyer = string(buyer)
+	}
+
+	dir := WorkspaceDir()
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				report.Errors = append(report.Errors, err.Error())
+			}
+			return nil
+		}
+		if d.IsDir() {
+			// Restore the permissions the buyer may have removed so that the directory can be emptied.
+			os.Chmod(path, 0755)
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		n, err := overwriteFile(path)
+		report.Files++
+		report.Bytes += n
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%v: %v", path, err))
+		}
+		return nil
+	})
+	if err != nil {
+		report.Errors = append(report.Errors, err.Error())
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return report, fmt.Errorf("> RemoveAll: %v", err)
+	}
+
+	db := dbutils.GetDB()
+	if err := dbutils.Delete(db, []byte(workspaceGraceKey)); err != nil {
+		logs.Error(fmt.Sprintf("Delete workspaceGrace: %v", err))
+	}
+	if data, err := json.Marshal(report); err == nil {
+		key := fmt.Sprintf("%s%020d", wipeReportPrefix, report.Time.UnixNano())
+		if err := dbutils.Update(db, []byte(key), data); err != nil {
+			logs.Error(fmt.Sprintf("Update wipe report: %v", err))
+		}
+	}
+	logs.Vital(fmt.Sprintf("Workspace wiped (%v): order %v, %v files, %v bytes, %v errors",
+		reason, report.Order, report.Files, report.Bytes, len(report.Errors)))
+	return report, nil
+}
+
+// WipeReports returns the wipe reports, oldest first.
+func WipeReports() ([]WipeReport, error) {
+	var reports []WipeReport
+	err := dbutils.Scan(dbutils.GetDB(), []byte(wipeReportPrefix), nil, func(key, value []byte) (bool, error) {
+		var report WipeReport
+		if err := json.Unmarshal(value, &report); err != nil {
+			return false, fmt.Errorf("> json.Unmarshal %s: %v", key, err)
+		}
+		reports = append(reports, report)
+		return true, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("> dbutils.Scan: %v", err)
+	}
+	return reports, nil
+}
+
+// endGrace wipes a workspace still in its grace period, so that a new order starts from an empty one.
+func endGrace() {
+	graceMu.Lock()
+	defer graceMu.Unlock()
+
+	grace, err := GetWorkspaceGrace()
+	if err != nil {
+		logs.Error(fmt.Sprintf("GetWorkspaceGrace: %v", err))
+		return
+	}
+	if grace == nil {
+		return
+	}
+	if graceTimer != nil {
+		graceTimer.Stop()
+	}
+	if _, err := WipeWorkspace("new order"); err != nil {
+		logs.Error(fmt.Sprintf("WipeWorkspace: %v", err))
+	}
+}
+
+// overwriteFile overwrites a file with random data and syncs it to disk, returning its size.
+func overwriteFile(path string) (int64, error) {
+	os.Chmod(path, 0600)
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.CopyN(f, rand.Reader, info.Size())
+	if err != nil {
+		return n, err
+	}
+	return n, f.Sync()
+}
@@ -0,0 +1,141 @@
+package daemon
+
+import (
+	"SuperNet-Node/chain/super"
+	"SuperNet-Node/control"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ErrNotRunning is returned when no node answers on the control socket.
+var ErrNotRunning = errors.New("node is not running")
+
+// Client calls the node running behind a control socket.
+type Client struct {
+	http *http.Client
+}
+
+// NewClient returns a client of the control socket at path.
+func NewClient(path string) *Client {
+	return &Client{http: &http.Client{
+		Transport: &http.Transport{
+			// A connection per call, so that a node which closed its socket no longer answers.
+			DisableKeepAlives: true,
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			},
+		},
+		// Stopping and pausing send transactions, which are retried for a while.
+		Timeout: 2 * time.Minute,
+	}}
+}
+
+func (c *Client) Status() (Status, error) {
+	var status Status
+	err := c.call(http.MethodGet, "/status", nil, &status)
+	return status, err
+}
+
+// Stop removes the machine from the market and shuts the node down.
+func (c *Client) Stop() error {
+	return c.call(http.MethodPost, "/stop", nil, nil)
+}
+
+func (c *Client) Pause() (control.Pause, error) {
+	var pause control.Pause
+	err := c.call(http.MethodPost, "/pause", nil, &pause)
+	return pause, err
+}
+
+func (c *Client) Resume() error {
+	return c.call(http.MethodPost, "/resume", nil, nil)
+}
+
+func (c *Client) Reload() (control.ReloadReport, error) {
+	var report control.ReloadReport
+	err := c.call(http.MethodPost, "/reload", nil, &report)
+	return report, err
+}
+
+// Claim claims the rewards of a finished period.
+func (c *Client) Claim(period uint32) (control.ClaimRecord, error) {
+	var record control.ClaimRecord
+	err := c.call(http.MethodPost, fmt.Sprintf("/claim?period=%d", period), nil, &record)
+	return record, err
+}
+
+// ClaimAll claims every finished period that is not claimed yet.
+func (c *Client) ClaimAll() ([]control.ClaimRecord, error) {
+	var records []control.ClaimRecord
+	err := c.call(http.MethodPost, "/claim?all=true", nil, &records)
+	return records, err
+}
+
+// AppendAudit has the node store a record in the audit log it holds.
+func (c *Client) AppendAudit(record super.AuditRecord) error {
+	return c.call(http.MethodPost, "/audit", record, nil)
+}
+
+// History returns the audit records of the node, filtered as node history.
+func (c *Client) History(order, typ, from, to string) ([]super.AuditRecord, error) {
+	query := url.Values{}
+	for key, value := range map[string]string{"order": order, "type": typ, "from": from, "to": to} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	var records []super.AuditRecord
+	err := c.call(http.MethodGet, "/history?"+query.Encode(), nil, &records)
+	return records, err
+}
+
+// call sends in, when not nil, as JSON and decodes the answer into out, when not nil.
+func (c *Client) call(method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("> json.Marshal: %v", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, "http://node"+path, body)
+	if err != nil {
+		return fmt.Errorf("> NewRequest: %v", err)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return ErrNotRunning
+		}
+		return fmt.Errorf("> Do: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var body struct {
+			Error string `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Error == "" {
+			return fmt.Errorf("control socket: %v", resp.Status)
+		}
+		return errors.New(body.Error)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("> json.Decode: %v", err)
+	}
+	return nil
+}
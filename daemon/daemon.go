@@ -0,0 +1,233 @@
+// Package daemon exposes the running node on a local Unix socket, so that node stop, status,
+// pause, reload and rewards claim act on the live daemon instead of initializing a node of their own.
+// The commands run next to the node also read and append the audit log the node holds through it.
+package daemon
+
+import (
+	"SuperNet-Node/chain/super"
+	"SuperNet-Node/config"
+	"SuperNet-Node/control"
+	"SuperNet-Node/nginx"
+	logs "SuperNet-Node/utils/log_utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Node is the running node the control socket acts on.
+type Node struct {
+	Super   *super.WrapperSuper
+	Started time.Time
+	// Stop shuts the node down, as SIGTERM does.
+	Stop func()
+}
+
+// Status is the state of the running node.
+type Status struct {
+	Pid     int       `json:"Pid"`
+	Started time.Time `json:"Started"`
+	Engine  string    `json:"Engine"`
+	Owner   string    `json:"Owner"`
+	Machine string    `json:"Machine"`
+	// MachineStatus is the status of the machine on chain, empty when it could not be read.
+	MachineStatus string                  `json:"MachineStatus"`
+	Price         uint64                  `json:"Price"`
+	MaxDuration   uint32                  `json:"MaxDuration"`
+	Disk          uint32                  `json:"Disk"`
+	Pause         *control.Pause          `json:"Pause"`
+	Order         *control.OrderJournal   `json:"Order"`
+	Grace         *control.WorkspaceGrace `json:"Grace"`
+}
+
+// Listen creates the control socket at path. A socket left by a node that did not shut down
+// is replaced; one that still answers means another node runs from the same directory.
+func Listen(path string) (net.Listener, error) {
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("another node is running, its control socket %v answers", path)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("> Remove: %v", err)
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("> Listen: %v", err)
+	}
+	// Stopping the node removes the machine from the market, only its user may ask for it.
+	if err := os.Chmod(path, 0600); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("> Chmod: %v", err)
+	}
+	return ln, nil
+}
+
+// Serve answers the requests on ln until it is closed, which removes the socket.
+func Serve(ln net.Listener, node *Node) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", node.status)
+	mux.HandleFunc("POST /stop", node.stop)
+	mux.HandleFunc("POST /pause", node.pause)
+	mux.HandleFunc("POST /resume", node.resume)
+	mux.HandleFunc("POST /reload", node.reload)
+	mux.HandleFunc("POST /claim", node.claim)
+	mux.HandleFunc("POST /audit", node.audit)
+	mux.HandleFunc("GET /history", node.history)
+
+	logs.Normal(fmt.Sprintf("Control socket: %v", ln.Addr()))
+	err := http.Serve(ln, mux)
+	if err != nil && !errors.Is(err, net.ErrClosed) {
+		return fmt.Errorf("> Serve: %v", err)
+	}
+	return nil
+}
+
+func (n *Node) status(w http.ResponseWriter, r *http.Request) {
+	status := Status{
+		Pid:     os.Getpid(),
+		Started: n.Started,
+		Engine:  config.GlobalConfig.Runtime.Engine,
+		Owner:   n.Super.Wallet.PublicKey().String(),
+		Machine: n.Super.ProgramSuperMachine.String(),
+	}
+	if machine, err := n.Super.GetMachine(r.Context()); err == nil && machine.Metadata != "" {
+		status.MachineStatus = machine.Status.String()
+		status.Price, status.MaxDuration, status.Disk = machine.Price, machine.MaxDuration, machine.Disk
+	}
+
+	var err error
+	if status.Pause, err = control.GetPause(); err != nil {
+		writeError(w, err)
+		return
+	}
+	if status.Order, err = control.GetOrderJournal(); err != nil {
+		writeError(w, err)
+		return
+	}
+	if status.Grace, err = control.GetWorkspaceGrace(); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, status)
+}
+
+// stop removes the machine from the market, as node stop always did, then shuts the node down.
+// The node keeps running when the machine could not be removed, so that it still serves its orders.
+func (n *Node) stop(w http.ResponseWriter, r *http.Request) {
+	logs.Normal("Stop requested on the control socket")
+	if err := control.RemoveMachine(sendContext(r), n.Super); err != nil {
+		logs.Error(err.Error())
+		writeError(w, err)
+		return
+	}
+	if err := nginx.StopNginx(); err != nil {
+		logs.Error(fmt.Sprintf("StopNginx: %v", err))
+	}
+	writeJSON(w, struct{}{})
+	n.Stop()
+}
+
+func (n *Node) pause(w http.ResponseWriter, r *http.Request) {
+	pause, err := control.PauseMachine(sendContext(r), n.Super)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, pause)
+}
+
+func (n *Node) resume(w http.ResponseWriter, r *http.Request) {
+	if err := control.ResumeMachine(sendContext(r), n.Super); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, struct{}{})
+}
+
+func (n *Node) reload(w http.ResponseWriter, r *http.Request) {
+	report, err := control.Reload(n.Super)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, report)
+}
+
+// claim claims the rewards of the period query parameter, or of every unclaimed period with all=true.
+// The claims are recorded in the database the node holds.
+func (n *Node) claim(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("all") == "true" {
+		records, err := control.ClaimUnclaimed(sendContext(r), n.Super)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, records)
+		return
+	}
+
+	period, err := strconv.ParseUint(r.URL.Query().Get("period"), 10, 32)
+	if err != nil {
+		writeError(w, fmt.Errorf("invalid period: %v", r.URL.Query().Get("period")))
+		return
+	}
+	sig, err := control.ClaimPeriod(sendContext(r), n.Super, uint32(period))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, control.ClaimRecord{Period: uint32(period), Signature: sig, Time: time.Now()})
+}
+
+// audit stores a record of a transaction sent by a command run next to the node,
+// as the node holds the audit log.
+func (n *Node) audit(w http.ResponseWriter, r *http.Request) {
+	var record super.AuditRecord
+	if err := json.NewDecoder(r.Body).Decode(&record); err != nil {
+		writeError(w, fmt.Errorf("> json.Decode: %v", err))
+		return
+	}
+	if err := super.AppendAudit(record); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, struct{}{})
+}
+
+// history returns the audit records matching the order, type, from and to query parameters.
+func (n *Node) history(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter, err := control.NewHistoryFilter(query.Get("order"), query.Get("type"), query.Get("from"), query.Get("to"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	records, err := super.History(filter)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, records)
+}
+
+// sendContext returns the context of the transactions sent for r. They are finished even when
+// the command gives up waiting for them, so that the node records their outcome.
+func sendContext(r *http.Request) context.Context {
+	return context.WithoutCancel(r.Context())
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
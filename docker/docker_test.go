@@ -0,0 +1,162 @@
+package docker
+
+import (
+	"SuperNet-Node/config"
+	"SuperNet-Node/pattern"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func setConsole(t *testing.T) string {
+	t.Helper()
+	console := config.GlobalConfig.Console
+	t.Cleanup(func() { config.GlobalConfig.Console = console })
+
+	dir := t.TempDir()
+	config.GlobalConfig.Console.WorkDirectory = dir
+	config.GlobalConfig.Console.WorkPort = "13011"
+	config.GlobalConfig.Console.ExpandPort1 = "13012"
+	config.GlobalConfig.Console.ExpandPort2 = ""
+	config.GlobalConfig.Console.ExpandPort3 = "13014"
+	return dir
+}
+
+// onlyContainer returns the single container of rt.
+func onlyContainer(t *testing.T, rt *FakeRuntime) FakeContainer {
+	t.Helper()
+	containers := rt.Containers()
+	if len(containers) != 1 {
+		t.Fatalf("containers: got %d, want 1: %+v", len(containers), containers)
+	}
+	return containers[0]
+}
+
+func TestRunWorkspaceContainer(t *testing.T) {
+	dir := setConsole(t)
+
+	for _, tc := range []struct {
+		gpu   bool
+		name  string
+		image string
+	}{
+		{false, pattern.ML_WORKSPACE_CONTAINER, pattern.ML_WORKSPACE_NAME},
+		{true, pattern.ML_WORKSPACE_GPU_CONTAINER, pattern.ML_WORKSPACE_GPU_NAME},
+	} {
+		rt := NewFakeRuntime()
+		id, err := RunWorkspaceContainer(context.Background(), rt, tc.gpu, "token")
+		if err != nil {
+			t.Fatalf("gpu %v: RunWorkspaceContainer: %v", tc.gpu, err)
+		}
+
+		c := onlyContainer(t, rt)
+		if c.ID != id || !c.Running {
+			t.Errorf("gpu %v: container %v running %v, want %v running", tc.gpu, c.ID, c.Running, id)
+		}
+		want := ContainerSpec{
+			Name:  tc.name,
+			Image: tc.image,
+			Env:   []string{"AUTHENTICATE_VIA_JUPYTER=token"},
+			Ports: map[string]string{"8080": "13011", "13012": "13012", "13014": "13014"},
+			Binds: []string{
+				dir + "/ml-workspace:/workspace",
+				"myvolume:/data",
+			},
+			GPU:     tc.gpu,
+			ShmSize: 512 * 1024 * 1024,
+			Restart: "always",
+			Tty:     true,
+		}
+		if !reflect.DeepEqual(c.Spec, want) {
+			t.Errorf("gpu %v: spec\n got %+v\nwant %+v", tc.gpu, c.Spec, want)
+		}
+	}
+}
+
+func TestRunWorkspaceContainerReplaces(t *testing.T) {
+	dir := setConsole(t)
+	rt := NewFakeRuntime()
+
+	first, err := RunWorkspaceContainer(context.Background(), rt, false, "first")
+	if err != nil {
+		t.Fatalf("RunWorkspaceContainer: %v", err)
+	}
+	// The files of the previous order are wiped with its container.
+	file := filepath.Join(dir, "ml-workspace", "model.bin")
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte("weights"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := RunWorkspaceContainer(context.Background(), rt, false, "second")
+	if err != nil {
+		t.Fatalf("RunWorkspaceContainer: %v", err)
+	}
+	c := onlyContainer(t, rt)
+	if c.ID != second || c.ID == first {
+		t.Errorf("container %v, want the second one %v", c.ID, second)
+	}
+	if !reflect.DeepEqual(c.Spec.Env, []string{"AUTHENTICATE_VIA_JUPYTER=second"}) {
+		t.Errorf("env %v, want the token of the second order", c.Spec.Env)
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Errorf("workspace of the previous order kept: %v", err)
+	}
+}
+
+func TestRunDeployContainer(t *testing.T) {
+	setConsole(t)
+	rt := NewFakeRuntime()
+
+	_, err := RunDeployContainer(context.Background(), rt, true, []string{
+		"https://ipfs.example.com/ipfs/QmModel",
+		"https://ipfs.example.com/ipfs/QmRequirements",
+	})
+	if err != nil {
+		t.Fatalf("RunDeployContainer: %v", err)
+	}
+	want := ContainerSpec{
+		Name:  pattern.MODELS_DEPLOY_CONTAINER,
+		Image: pattern.MODELS_DEPLOY_NAME,
+		Env: []string{
+			"DOWNLOAD_URL=https://ipfs.example.com",
+			"DEPLOY_FILE=ipfs/QmModel",
+			"REQUIREMENTS=ipfs/QmRequirements",
+		},
+		Ports:   map[string]string{"7860": "13011"},
+		GPU:     true,
+		Restart: "always",
+	}
+	if c := onlyContainer(t, rt); !reflect.DeepEqual(c.Spec, want) {
+		t.Errorf("spec\n got %+v\nwant %+v", c.Spec, want)
+	}
+
+	if _, err := RunDeployContainer(context.Background(), NewFakeRuntime(), false, nil); err == nil {
+		t.Error("RunDeployContainer without a download URL: no error")
+	}
+}
+
+func TestRunScoreContainer(t *testing.T) {
+	rt := NewFakeRuntime()
+	rt.SetLogs(pattern.SCORE_NAME, "starting\nScore: 60\nScore: 100\n")
+
+	score, err := RunScoreContainer(context.Background(), rt, true)
+	if err != nil {
+		t.Fatalf("RunScoreContainer: %v", err)
+	}
+	// Each score is averaged with the previous ones, from 0.
+	if score != 65 {
+		t.Errorf("score %v, want 65", score)
+	}
+	// The score container removes itself once it exits.
+	if containers := rt.Containers(); len(containers) != 0 {
+		t.Errorf("containers left: %+v", containers)
+	}
+	if images := rt.Images(); !reflect.DeepEqual(images, []string{pattern.SCORE_NAME}) {
+		t.Errorf("images %v, want %v", images, pattern.SCORE_NAME)
+	}
+}
@@ -0,0 +1,243 @@
+package docker
+
+import (
+	logs "SuperNet-Node/utils/log_utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/docker/go-connections/nat"
+)
+
+// DefaultPodmanHost is the Docker-compatible socket of a rootful Podman.
+const DefaultPodmanHost = "unix:///run/podman/podman.sock"
+
+// engineRuntime runs the containers through the Docker API, which Podman serves as well.
+type engineRuntime struct {
+	cli *client.Client
+	// podman exposes the GPUs as CDI devices instead of the nvidia runtime.
+	podman bool
+}
+
+// NewDockerRuntime returns a runtime on the Docker engine at host, DOCKER_HOST when empty.
+func NewDockerRuntime(host string) (Runtime, error) {
+	cli, err := newClient(host)
+	if err != nil {
+		return nil, err
+	}
+	return &engineRuntime{cli: cli}, nil
+}
+
+// NewPodmanRuntime returns a runtime on the Docker-compatible socket of Podman at host,
+// DefaultPodmanHost when empty.
+func NewPodmanRuntime(host string) (Runtime, error) {
+	if host == "" {
+		host = DefaultPodmanHost
+	}
+	cli, err := newClient(host)
+	if err != nil {
+		return nil, err
+	}
+	return &engineRuntime{cli: cli, podman: true}, nil
+}
+
+func newClient(host string) (*client.Client, error) {
+	opts := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}
+	if host != "" {
+		opts = append(opts, client.WithHost(host))
+	}
+	cli, err := client.NewClientWithOpts(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("> client.NewClientWithOpts: %v", err)
+	}
+	return cli, nil
+}
+
+func (r *engineRuntime) Pull(ctx context.Context, image string) error {
+	_, _, err := r.cli.ImageInspectWithRaw(ctx, image)
+	if err == nil {
+		logs.Normal(fmt.Sprintf("Image %s exists", image))
+		return nil
+	}
+	if !client.IsErrNotFound(err) {
+		return fmt.Errorf("> ImageInspectWithRaw: %v", err)
+	}
+
+	logs.Normal(fmt.Sprintf("Pulling image %s", image))
+	reader, err := r.cli.ImagePull(ctx, image, types.ImagePullOptions{})
+	if err != nil {
+		return fmt.Errorf("> ImagePull: %v", err)
+	}
+	defer reader.Close()
+	// The pull completes once its progress stream is consumed, which also carries its errors.
+	decoder := json.NewDecoder(reader)
+	// Layers report their progress many times, only their changes of status are logged.
+	statuses := map[string]string{}
+	for {
+		var message struct {
+			ID     string `json:"id"`
+			Status string `json:"status"`
+			Error  string `json:"error"`
+		}
+		if err := decoder.Decode(&message); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("> ImagePull: %v", err)
+		}
+		if message.Error != "" {
+			return fmt.Errorf("> ImagePull: %v", message.Error)
+		}
+		if statuses[message.ID] == message.Status {
+			continue
+		}
+		statuses[message.ID] = message.Status
+		if message.ID != "" {
+			logs.Normal(fmt.Sprintf("%v: %v", message.ID, message.Status))
+		} else {
+			logs.Normal(message.Status)
+		}
+	}
+}
+
+func (r *engineRuntime) Run(ctx context.Context, spec ContainerSpec) (string, error) {
+	if err := r.Pull(ctx, spec.Image); err != nil {
+		return "", err
+	}
+
+	exists, _, err := r.Exists(ctx, spec.Name)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		if err := r.Stop(ctx, spec.Name); err != nil {
+			return "", err
+		}
+	}
+
+	containerConfig := &container.Config{
+		Image:        spec.Image,
+		Env:          spec.Env,
+		Tty:          spec.Tty,
+		ExposedPorts: nat.PortSet{},
+	}
+	hostConfig := &container.HostConfig{
+		PortBindings: nat.PortMap{},
+		Binds:        spec.Binds,
+		AutoRemove:   spec.AutoRemove,
+		ShmSize:      spec.ShmSize,
+	}
+	if spec.Restart != "" {
+		hostConfig.RestartPolicy = container.RestartPolicy{Name: spec.Restart}
+	}
+	for containerPort, hostPort := range spec.Ports {
+		port := nat.Port(containerPort + "/tcp")
+		containerConfig.ExposedPorts[port] = struct{}{}
+		hostConfig.PortBindings[port] = []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: hostPort}}
+	}
+	if spec.GPU {
+		if r.podman {
+			hostConfig.Devices = []container.DeviceMapping{{PathOnHost: "nvidia.com/gpu=all"}}
+		} else {
+			hostConfig.Runtime = "nvidia"
+			hostConfig.DeviceRequests = []container.DeviceRequest{{Count: -1, Capabilities: [][]string{{"gpu"}}}}
+		}
+	}
+
+	resp, err := r.cli.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, spec.Name)
+	if err != nil {
+		return "", fmt.Errorf("> ContainerCreate: %v", err)
+	}
+	logs.Normal(fmt.Sprintf("Start running container %s", spec.Name))
+	if err := r.cli.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
+		return "", fmt.Errorf("> ContainerStart: %v", err)
+	}
+	return resp.ID, nil
+}
+
+func (r *engineRuntime) Stop(ctx context.Context, id string) error {
+	logs.Normal("Stop and remove container")
+	if err := r.cli.ContainerStop(ctx, id, container.StopOptions{}); err != nil && !client.IsErrNotFound(err) {
+		return fmt.Errorf("> ContainerStop: %v", err)
+	}
+	// An auto removed container may be gone once stopped.
+	if err := r.cli.ContainerRemove(ctx, id, types.ContainerRemoveOptions{Force: true}); err != nil && !client.IsErrNotFound(err) {
+		return fmt.Errorf("> ContainerRemove: %v", err)
+	}
+	return nil
+}
+
+func (r *engineRuntime) Logs(ctx context.Context, id string, follow bool) (io.ReadCloser, error) {
+	info, err := r.cli.ContainerInspect(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("> ContainerInspect: %v", err)
+	}
+	reader, err := r.cli.ContainerLogs(ctx, id, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     follow,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("> ContainerLogs: %v", err)
+	}
+	if info.Config != nil && info.Config.Tty {
+		return reader, nil
+	}
+
+	// Without a TTY stdout and stderr are multiplexed in a single stream.
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := stdcopy.StdCopy(pw, pw, reader)
+		reader.Close()
+		pw.CloseWithError(err)
+	}()
+	return pr, nil
+}
+
+func (r *engineRuntime) Stats(ctx context.Context, id string) (ContainerStats, error) {
+	resp, err := r.cli.ContainerStats(ctx, id, false)
+	if err != nil {
+		return ContainerStats{}, fmt.Errorf("> ContainerStats: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var stats types.StatsJSON
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return ContainerStats{}, fmt.Errorf("> json.Decode: %v", err)
+	}
+
+	out := ContainerStats{
+		MemoryUsage: stats.MemoryStats.Usage,
+		MemoryLimit: stats.MemoryStats.Limit,
+	}
+	// The usage between the previous sample and this one, as `docker stats` shows it.
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	cpus := float64(stats.CPUStats.OnlineCPUs)
+	if cpus == 0 {
+		cpus = float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		out.CPUPercent = cpuDelta / systemDelta * cpus * 100
+	}
+	return out, nil
+}
+
+func (r *engineRuntime) Exists(ctx context.Context, id string) (bool, bool, error) {
+	info, err := r.cli.ContainerInspect(ctx, id)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return false, false, nil
+		}
+		return false, false, fmt.Errorf("> ContainerInspect: %v", err)
+	}
+	return true, info.State != nil && info.State.Running, nil
+}
+
+func (r *engineRuntime) Close() error {
+	return r.cli.Close()
+}
@@ -0,0 +1,176 @@
+package docker
+
+import (
+	"SuperNet-Node/pattern"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FakeContainer is a container of a FakeRuntime.
+type FakeContainer struct {
+	ID      string
+	Spec    ContainerSpec
+	Running bool
+	Started time.Time
+}
+
+// FakeRuntime keeps its containers in memory and runs nothing, for tests and rehearsals.
+type FakeRuntime struct {
+	mu         sync.Mutex
+	images     map[string]bool
+	containers map[string]*FakeContainer
+	logs       map[string]string
+	failures   map[string]error
+	nextID     int
+}
+
+// NewFakeRuntime returns an empty fake runtime whose score container prints a score of 80.
+func NewFakeRuntime() *FakeRuntime {
+	return &FakeRuntime{
+		images:     map[string]bool{},
+		containers: map[string]*FakeContainer{},
+		logs:       map[string]string{pattern.SCORE_NAME: "Score: 80\n"},
+		failures:   map[string]error{},
+	}
+}
+
+// SetLogs sets the output of the containers of image.
+func (r *FakeRuntime) SetLogs(image string, logs string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logs[image] = logs
+}
+
+// FailRun makes the containers of image fail to start with err, nil clears it.
+func (r *FakeRuntime) FailRun(image string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err == nil {
+		delete(r.failures, image)
+	} else {
+		r.failures[image] = err
+	}
+}
+
+// Images returns the pulled images.
+func (r *FakeRuntime) Images() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var images []string
+	for image := range r.images {
+		images = append(images, image)
+	}
+	slices.Sort(images)
+	return images
+}
+
+// Containers returns the existing containers, in creation order.
+func (r *FakeRuntime) Containers() []FakeContainer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var containers []FakeContainer
+	for _, c := range r.containers {
+		containers = append(containers, *c)
+	}
+	slices.SortFunc(containers, func(a, b FakeContainer) int { return strings.Compare(a.ID, b.ID) })
+	return containers
+}
+
+func (r *FakeRuntime) Pull(ctx context.Context, image string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.images[image] = true
+	return nil
+}
+
+func (r *FakeRuntime) Run(ctx context.Context, spec ContainerSpec) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.images[spec.Image] = true
+	if c := r.find(spec.Name); c != nil {
+		delete(r.containers, c.ID)
+	}
+	if err := r.failures[spec.Image]; err != nil {
+		return "", fmt.Errorf("> ContainerStart: %v", err)
+	}
+
+	r.nextID++
+	c := &FakeContainer{
+		ID:      fmt.Sprintf("%064x", r.nextID),
+		Spec:    spec,
+		Running: true,
+		Started: time.Now(),
+	}
+	r.containers[c.ID] = c
+	return c.ID, nil
+}
+
+func (r *FakeRuntime) Stop(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c := r.find(id); c != nil {
+		delete(r.containers, c.ID)
+	}
+	return nil
+}
+
+// Logs returns the output set for the image of the container. Following them lets
+// the container exit, removing it with AutoRemove.
+func (r *FakeRuntime) Logs(ctx context.Context, id string, follow bool) (io.ReadCloser, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := r.find(id)
+	if c == nil {
+		return nil, fmt.Errorf("> ContainerLogs: no such container: %v", id)
+	}
+	logs := r.logs[c.Spec.Image]
+	if follow {
+		c.Running = false
+		if c.Spec.AutoRemove {
+			delete(r.containers, c.ID)
+		}
+	}
+	return io.NopCloser(strings.NewReader(logs)), nil
+}
+
+func (r *FakeRuntime) Stats(ctx context.Context, id string) (ContainerStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.find(id) == nil {
+		return ContainerStats{}, fmt.Errorf("> ContainerStats: no such container: %v", id)
+	}
+	return ContainerStats{}, nil
+}
+
+func (r *FakeRuntime) Exists(ctx context.Context, id string) (bool, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := r.find(id)
+	if c == nil {
+		return false, false, nil
+	}
+	return true, c.Running, nil
+}
+
+func (r *FakeRuntime) Close() error {
+	return nil
+}
+
+// find returns the container by ID or name, nil when there is none.
+func (r *FakeRuntime) find(id string) *FakeContainer {
+	id = strings.TrimSpace(id)
+	if c, ok := r.containers[id]; ok {
+		return c
+	}
+	for _, c := range r.containers {
+		if c.Spec.Name == id {
+			return c
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package docker
+
+import (
+	"SuperNet-Node/config"
+	"context"
+	"fmt"
+	"io"
+)
+
+// Container engines of RuntimeConfig.Engine.
+const (
+	EngineDocker = "docker"
+	EnginePodman = "podman"
+	EngineFake   = "fake"
+)
+
+// ContainerSpec describes a container to run.
+type ContainerSpec struct {
+	Name  string
+	Image string
+	Env   []string
+	// Ports maps the container ports to the host ports, e.g. "8080": "13011".
+	Ports map[string]string
+	// Binds are volumes in the "source:destination" form.
+	Binds []string
+	// GPU exposes every GPU of the host to the container.
+	GPU        bool
+	ShmSize    int64
+	Restart    string
+	AutoRemove bool
+	Tty        bool
+}
+
+// ContainerStats is a sample of the resource usage of a container.
+type ContainerStats struct {
+	CPUPercent  float64
+	MemoryUsage uint64
+	MemoryLimit uint64
+}
+
+// Runtime runs the containers of the node on a container engine.
+type Runtime interface {
+	// Pull pulls image unless it is already present.
+	Pull(ctx context.Context, image string) error
+	// Run pulls the image, replaces any container with the same name and starts the container,
+	// returning its ID.
+	Run(ctx context.Context, spec ContainerSpec) (string, error)
+	// Stop stops and removes a container.
+	Stop(ctx context.Context, id string) error
+	// Logs returns the stdout and stderr of a container, until it exits with follow.
+	Logs(ctx context.Context, id string, follow bool) (io.ReadCloser, error)
+	Stats(ctx context.Context, id string) (ContainerStats, error)
+	// Exists reports whether a container, by name or ID, exists and whether it is running.
+	Exists(ctx context.Context, id string) (exists bool, running bool, err error)
+	Close() error
+}
+
+// NewRuntime returns the runtime of the configured engine.
+func NewRuntime(cfg config.RuntimeConfig) (Runtime, error) {
+	switch cfg.Engine {
+	case EngineDocker, "":
+		return NewDockerRuntime(cfg.Host)
+	case EnginePodman:
+		return NewPodmanRuntime(cfg.Host)
+	case EngineFake:
+		return NewFakeRuntime(), nil
+	}
+	return nil, fmt.Errorf("unknown container engine %q", cfg.Engine)
+}
@@ -25,6 +25,12 @@ func setupApp() *cli.App {
 	app.Commands = []cli.Command{
 		cmd.ClientCommand,
 		cmd.DebugCommand,
+		cmd.WalletCommand,
+		cmd.TxCommand,
+		cmd.MarketCommand,
+		cmd.OrderCommand,
+		cmd.ChainCommand,
+		cmd.FakeRPCCommand,
 	}
 	app.Before = func(context *cli.Context) error {
 		initLog()
@@ -37,8 +43,13 @@ func setupApp() *cli.App {
 }
 
 func main() {
-	if err := setupApp().Run(os.Args); err != nil {
+	err := setupApp().Run(os.Args)
+	if err != nil {
 		logs.Error(err.Error())
+	}
+	// Flush the log file, which os.Exit would skip.
+	logs.Logger.Sync()
+	if err != nil {
 		os.Exit(1)
 	}
 }
@@ -28,6 +28,9 @@ const PROGRAM_SUPER_ID = "6yFTDdiS1W9T9yg6YejkwKggkEE4NYqdSSzVqQvuLn16"
 
 const SNT_TOKEN_ID = "896KfVVY6VRGQs1d9CKLnKUEgXXCCJcEEg7LwSK84vWE"
 
+// SNT_DECIMALS is the number of decimals of the SNT token mint
+const SNT_DECIMALS = 9
+
 const NO_GPU = "No GPU"
 
 const ModleCreatePath = "/home/SuperNet-Model-Create"
@@ -91,6 +94,28 @@ const (
 	TX_HASHRATE_MARKET_REMOVE_MACHINE = HASHRATE_MARKET + DOT + "remove_machine"
 
 	TX_HASHRATE_MARKET_SUBMIT_TASK = HASHRATE_MARKET + DOT + "submit_task"
+
+	TX_HASHRATE_MARKET_MAKE_OFFER = HASHRATE_MARKET + DOT + "make_offer"
+
+	TX_HASHRATE_MARKET_CANCEL_OFFER = HASHRATE_MARKET + DOT + "cancel_offer"
+
+	TX_HASHRATE_MARKET_CLAIM = HASHRATE_MARKET + DOT + "claim"
+
+	TX_HASHRATE_MARKET_PLACE_ORDER = HASHRATE_MARKET + DOT + "place_order"
+
+	TX_HASHRATE_MARKET_RENEW_ORDER = HASHRATE_MARKET + DOT + "renew_order"
+
+	TX_HASHRATE_MARKET_REFUND_ORDER = HASHRATE_MARKET + DOT + "refund_order"
+
+	TX_HASHRATE_MARKET_REMOVE_ORDER = HASHRATE_MARKET + DOT + "remove_order"
+
+	TX_HASHRATE_MARKET_MIGRATE_MACHINE_NEW = HASHRATE_MARKET + DOT + "migrate_machine_new"
+
+	TX_HASHRATE_MARKET_MIGRATE_MACHINE_RENAME = HASHRATE_MARKET + DOT + "migrate_machine_rename"
+
+	TX_HASHRATE_MARKET_MIGRATE_ORDER_NEW = HASHRATE_MARKET + DOT + "migrate_order_new"
+
+	TX_HASHRATE_MARKET_MIGRATE_ORDER_RENAME = HASHRATE_MARKET + DOT + "migrate_order_rename"
 )
 
 type MachineUUID [16]byte
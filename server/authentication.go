@@ -3,10 +3,19 @@ package server
 import (
 	dbutils "SuperNet-Node/utils/db_utils"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/dgraph-io/badger/v4"
 	"github.com/gagliardetto/solana-go"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// OwnerValidityPeriod is the validity period in seconds of an owner signature.
+	OwnerValidityPeriod = 1000
+	// ownerMessage is the message signed by the machine owner for the owner endpoints.
+	ownerMessage = "node/api"
 )
 
 // UserAuthentication authenticates a user by verifying a signature against a message and a public key.
@@ -24,19 +33,34 @@ func UserAuthentication(db *badger.DB, validityPeriod int64, signature string, m
 		return false, fmt.Errorf("> PublicKeyFromBase58 error: %v", err)
 	}
 
+	return verifySignature(publicKey, validityPeriod, signature, message)
+}
+
+// OwnerAuthentication verifies a signature of OwnerMessage by the machine owner.
+func OwnerAuthentication(owner solana.PublicKey, signature string) (bool, error) {
+	return verifySignature(owner, OwnerValidityPeriod, signature, ownerMessage)
+}
+
+// OwnerMessage returns the message the machine owner signs at t to call the owner endpoints.
+func OwnerMessage(owner solana.PublicKey, t time.Time) string {
+	return signedMessage(ownerMessage, t.Unix()/OwnerValidityPeriod, owner)
+}
+
+// verifySignature checks that publicKey signed message for the current or the previous validity period.
+func verifySignature(publicKey solana.PublicKey, validityPeriod int64, signature string, message string) (bool, error) {
 	out, err := solana.SignatureFromBase58(signature)
 	if err != nil {
 		return false, fmt.Errorf("> SignatureFromBase58 error: %v", err)
 	}
 
 	currentTime := time.Now().Unix() / validityPeriod
-	msg := fmt.Sprintf("%s/%v/%s", message, currentTime, publicKeyStr)
+	msg := signedMessage(message, currentTime, publicKey)
 
 	if publicKey.Verify([]byte(msg), out) {
 		return true, nil
 	} else {
 		currentTime -= 1
-		msg = fmt.Sprintf("%s/%v/%s", message, currentTime, publicKeyStr)
+		msg = signedMessage(message, currentTime, publicKey)
 
 		if publicKey.Verify([]byte(msg), out) {
 			return true, nil
@@ -45,3 +69,35 @@ func UserAuthentication(db *badger.DB, validityPeriod int64, signature string, m
 
 	return false, nil
 }
+
+func signedMessage(message string, period int64, publicKey solana.PublicKey) string {
+	return fmt.Sprintf("%s/%v/%s", message, period, publicKey)
+}
+
+// authenticateOwner returns a middleware accepting the requests whose signature query parameter
+// is a signature of OwnerMessage by owner, as printed by `wallet sign-api`.
+func authenticateOwner(owner solana.PublicKey) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ok, err := OwnerAuthentication(owner, c.Query("signature"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("> OwnerAuthentication %v", err.Error())})
+			return
+		}
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "verification failed"})
+		}
+	}
+}
+
+// authenticateOwnerOrBuyer returns a middleware accepting the signatures of the machine owner
+// and of the buyer of the last order.
+func authenticateOwnerOrBuyer(owner solana.PublicKey) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ok, _ := OwnerAuthentication(owner, c.Query("signature")); ok {
+			return
+		}
+		if !authenticateBuyer(c) {
+			c.Abort()
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package server
+
+import (
+	"SuperNet-Node/chain/super"
+	"SuperNet-Node/control"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getEarnings returns a handler reporting the earnings of the machine as JSON.
+// The range is taken from the optional from and to query parameters (YYYY-MM-DD, UTC).
+func getEarnings(superWrapper *super.WrapperSuper) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		from, to, err := control.EarningsRange(c.Query("from"), c.Query("to"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		report, err := control.GetEarnings(c.Request.Context(), superWrapper, from, to)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("> GetEarnings %v", err.Error())})
+			return
+		}
+		c.JSON(http.StatusOK, report)
+	}
+}
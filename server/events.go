@@ -0,0 +1,56 @@
+package server
+
+import (
+	"SuperNet-Node/chain/super"
+	"SuperNet-Node/control"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getEvents returns the indexed transactions of the program touching the machine, filtered by the
+// optional instruction, from (slot) and limit query parameters. follow=true then streams the new
+// ones as JSON lines until the client disconnects.
+func getEvents(c *gin.Context) {
+	filter, err := control.NewEventFilter(c.Query("instruction"), c.Query("from"), c.Query("limit"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Query("follow") != "true" {
+		events, err := super.Events(filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("> Events %v", err.Error())})
+			return
+		}
+		c.JSON(http.StatusOK, events)
+		return
+	}
+
+	events, cancel, ok := control.SubscribeEvents(16)
+	if !ok {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "event listener is not started"})
+		return
+	}
+	defer cancel()
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			if filter.Instruction != "" && !event.HasInstruction(filter.Instruction) {
+				return true
+			}
+			return json.NewEncoder(w).Encode(event) == nil
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"SuperNet-Node/chain/super"
+	"SuperNet-Node/control"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getHistory returns the audit log of the transactions sent by the node, filtered by the optional
+// order, type, from and to query parameters. format=jsonl streams JSON lines instead of an array.
+func getHistory(c *gin.Context) {
+	filter, err := control.NewHistoryFilter(c.Query("order"), c.Query("type"), c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	records, err := super.History(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("> History %v", err.Error())})
+		return
+	}
+
+	if c.Query("format") == "jsonl" {
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Status(http.StatusOK)
+		if err := control.WriteHistoryJSONL(c.Writer, records); err != nil {
+			c.Error(err)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, records)
+}
@@ -0,0 +1,16 @@
+package server
+
+import (
+	"SuperNet-Node/chain/super"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getRpcMetrics returns a handler reporting the health of every RPC endpoint
+// and how many calls of each method it served.
+func getRpcMetrics(superWrapper *super.WrapperSuper) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"endpoints": superWrapper.Conn.Pool.Metrics()})
+	}
+}
@@ -0,0 +1,26 @@
+package server
+
+import (
+	"SuperNet-Node/control"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getOrderEvent returns the last event of the order served by the machine, with the time
+// remaining until its end, so that the buyer can poll for warnings and renewals.
+func getOrderEvent(c *gin.Context) {
+	event, err := control.GetOrderEvent()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("> GetOrderEvent %v", err.Error())})
+		return
+	}
+	if event == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no order is served"})
+		return
+	}
+	event.Remaining = int64(max(time.Until(event.EndTime), 0) / time.Second)
+	c.JSON(http.StatusOK, event)
+}
@@ -1,15 +1,23 @@
 package server
 
 import (
+	"SuperNet-Node/chain/super"
 	"SuperNet-Node/config"
+	"SuperNet-Node/control"
 	"SuperNet-Node/middleware"
 	"SuperNet-Node/server/template"
 	"SuperNet-Node/utils"
 	dbutils "SuperNet-Node/utils/db_utils"
 	logs "SuperNet-Node/utils/log_utils"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/gagliardetto/solana-go"
@@ -17,8 +25,9 @@ import (
 )
 
 // StartServer is a function that initializes and starts a web server on the specified port.
-// It takes a serverPort string as an argument and returns an error if one occurs during startup.
-func StartServer(serverPort string) error {
+// It takes a serverPort string and the chain wrapper of the machine as arguments and returns an error if one occurs during startup.
+// The server is shut down when ctx is cancelled, letting the requests in progress finish.
+func StartServer(ctx context.Context, serverPort string, superWrapper *super.WrapperSuper) error {
 	logs.Normal("Start server")
 
 	r := gin.Default()
@@ -30,15 +39,42 @@ func StartServer(serverPort string) error {
 	workspace.GET("/debugToken/:signature", getDebugToken)
 	workspace.GET("/getToken/:signature", getToken)
 	upload.POST("/ipfs", uploadFile)
+	upload.GET("/files", listWorkspaceFiles)
+	upload.GET("/download", downloadWorkspaceFile)
+	// The server is public behind nginx, the reports of the node are for its owner only.
+	owner := r.Group("", authenticateOwner(superWrapper.Wallet.PublicKey()))
+	owner.GET(template.EARNINGS, getEarnings(superWrapper))
+	owner.GET(template.RPC_METRICS, getRpcMetrics(superWrapper))
+	owner.GET(template.HISTORY, getHistory)
+	owner.GET(template.WIPES, getWipeReports)
+	owner.GET(template.EVENTS, getEvents)
+	r.GET(template.ORDER_EVENT, authenticateOwnerOrBuyer(superWrapper.Wallet.PublicKey()), getOrderEvent)
+
+	srv := &http.Server{
+		Addr:    "127.0.0.1:" + serverPort,
+		Handler: r,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+		defer cancel()
+		// Followed event streams end with the event listener, others are cut at the timeout.
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			srv.Close()
+		}
+	}()
 
-	err := r.Run("127.0.0.1:" + serverPort)
-	if err != nil {
+	err := srv.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		logs.Error(fmt.Sprintf("gin run error: %v", err))
 		return err
 	}
 	return nil
 }
 
+// ShutdownTimeout bounds the wait for the requests in progress when the server is shut down.
+const ShutdownTimeout = 5 * time.Second
+
 // proxyHandler is a Gin middleware function that handles proxy requests.
 // It takes a Gin context as an argument, which contains the request details.
 func proxyHandler(c *gin.Context) {
@@ -207,18 +243,27 @@ func uploadFile(c *gin.Context) {
 		return
 	}
 
+	workspace, err := filepath.EvalSymlinks(control.WorkspaceDir())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("> EvalSymlinks %v", err.Error())})
+		return
+	}
 	for index, file := range uploadFile.FileList {
-		file.Path = config.GlobalConfig.Console.WorkDirectory + "/ml-workspace" + utils.EnsureLeadingSlash(file.Path)
-		isExists, err := utils.PathExists(file.Path)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("> PathExists %v", err.Error())})
-			return
+		// The paths are relative to the workspace, which they must not lead out of.
+		rel := filepath.FromSlash(strings.TrimLeft(file.Path, "/"))
+		if rel == "" {
+			rel = "."
 		}
-		if !isExists {
+		path, err := workspacePath(workspace, rel)
+		if errors.Is(err, fs.ErrNotExist) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("file not exists: %v", file.Path)})
 			return
 		}
-		uploadFile.FileList[index].Path = file.Path
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid path %v: %v", file.Path, err.Error())})
+			return
+		}
+		uploadFile.FileList[index].Path = path
 	}
 
 	resUploadFile := []ResUploadFile{}
@@ -231,6 +276,10 @@ func uploadFile(c *gin.Context) {
 			}
 
 			for _, fileItem := range files {
+				// Symlinks planted by the buyer are left out with the other non-regular files.
+				if info, err := os.Lstat(fileItem.Path); err != nil || !info.Mode().IsRegular() {
+					continue
+				}
 				cid, err := utils.UploadFileToIPFS(config.GlobalConfig.Console.IpfsNodeUrl, fileItem.Path, time.Until(timeout))
 				if err != nil {
 					c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("> UploadFileToIPFS fileItem %v", err.Error())})
@@ -241,7 +290,7 @@ func uploadFile(c *gin.Context) {
 					"/distri.ai/model/%v/%v%v",
 					publicKey,
 					uploadFile.ModelName,
-					utils.EnsureLeadingSlash(utils.RemovePrefix(fileItem.Path, config.GlobalConfig.Console.WorkDirectory+"/ml-workspace")))
+					utils.EnsureLeadingSlash(utils.RemovePrefix(fileItem.Path, workspace)))
 				err = utils.RmFileInIPFS(config.GlobalConfig.Console.IpfsNodeUrl, destination)
 				if err != nil {
 					logs.Normal(fmt.Sprintf("> RmFileInIPFS fileItem %v", err.Error()))
@@ -258,7 +307,7 @@ func uploadFile(c *gin.Context) {
 
 				resUploadFile = append(
 					resUploadFile,
-					ResUploadFile{Path: utils.RemovePrefix(fileItem.Path, config.GlobalConfig.Console.WorkDirectory+"/ml-workspace"), Cid: cid})
+					ResUploadFile{Path: utils.RemovePrefix(fileItem.Path, workspace), Cid: cid})
 			}
 		} else {
 			cid, err := utils.UploadFileToIPFS(config.GlobalConfig.Console.IpfsNodeUrl, file.Path, time.Until(timeout))
@@ -271,7 +320,7 @@ func uploadFile(c *gin.Context) {
 				"/distri.ai/model/%v/%v%v",
 				publicKey,
 				uploadFile.ModelName,
-				utils.EnsureLeadingSlash(utils.RemovePrefix(file.Path, config.GlobalConfig.Console.WorkDirectory+"/ml-workspace")))
+				utils.EnsureLeadingSlash(utils.RemovePrefix(file.Path, workspace)))
 			err = utils.RmFileInIPFS(config.GlobalConfig.Console.IpfsNodeUrl, destination)
 			if err != nil {
 				logs.Normal(fmt.Sprintf("> RmFileInIPFS fileItem %v", err.Error()))
@@ -288,7 +337,7 @@ func uploadFile(c *gin.Context) {
 
 			resUploadFile = append(
 				resUploadFile,
-				ResUploadFile{Path: utils.RemovePrefix(file.Path, config.GlobalConfig.Console.WorkDirectory+"/ml-workspace"), Cid: cid})
+				ResUploadFile{Path: utils.RemovePrefix(file.Path, workspace), Cid: cid})
 		}
 	}
 
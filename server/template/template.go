@@ -5,6 +5,12 @@ const (
 	UPLOAD_file = "/uploadfile"
 	PROXY       = "/proxy"
 	TOKEN       = "/token"
+	EARNINGS    = "/earnings"
+	RPC_METRICS = "/rpc/metrics"
+	HISTORY     = "/history"
+	ORDER_EVENT = "/order/event"
+	WIPES       = "/wipes"
+	EVENTS      = "/events"
 )
 
 const (
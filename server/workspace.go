@@ -0,0 +1,157 @@
+package server
+
+import (
+	"SuperNet-Node/control"
+	dbutils "SuperNet-Node/utils/db_utils"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WorkspaceFile is a file of the workspace.
+type WorkspaceFile struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// authenticateBuyer checks the signature query parameter against the buyer of the last order.
+func authenticateBuyer(c *gin.Context) bool {
+	ok, err := UserAuthentication(dbutils.GetDB(), 1000, c.Query("signature"), "upload/file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("> UserAuthentication %v", err.Error())})
+		return false
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "verification failed"})
+		return false
+	}
+	return true
+}
+
+// listWorkspaceFiles lists the files of the workspace, during the order and its grace period.
+func listWorkspaceFiles(c *gin.Context) {
+	if !authenticateBuyer(c) {
+		return
+	}
+
+	dir, err := filepath.EvalSymlinks(control.WorkspaceDir())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("> EvalSymlinks %v", err.Error())})
+		return
+	}
+	files := []WorkspaceFile{}
+	// WalkDir does not follow symlinks, which are left out with the other non-regular files.
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, WorkspaceFile{Path: filepath.ToSlash(rel), Size: info.Size()})
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("> WalkDir %v", err.Error())})
+		return
+	}
+
+	var graceUntil time.Time
+	if grace, err := control.GetWorkspaceGrace(); err == nil && grace != nil {
+		graceUntil = grace.Until
+	}
+	c.JSON(http.StatusOK, gin.H{"files": files, "graceUntil": graceUntil})
+}
+
+// downloadWorkspaceFile sends a file of the workspace, during the order and its grace period.
+func downloadWorkspaceFile(c *gin.Context) {
+	if !authenticateBuyer(c) {
+		return
+	}
+
+	path := filepath.FromSlash(c.Query("path"))
+	if !filepath.IsLocal(path) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid path: %v", c.Query("path"))})
+		return
+	}
+	file, info, err := openWorkspaceFile(path)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("> openWorkspaceFile %v", err.Error())})
+		return
+	}
+	defer file.Close()
+
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filepath.Base(path)}))
+	http.ServeContent(c.Writer, c.Request, info.Name(), info.ModTime(), file)
+}
+
+// errOutsideWorkspace is returned for a path that leads out of the workspace.
+var errOutsideWorkspace = errors.New("path leads out of the workspace")
+
+// workspacePath returns the path of the local path rel in the workspace dir, resolved with EvalSymlinks.
+// The buyer writes the workspace, so a path through a symlink is refused rather than followed.
+func workspacePath(dir, rel string) (string, error) {
+	if !filepath.IsLocal(rel) {
+		return "", errOutsideWorkspace
+	}
+	path := filepath.Join(dir, rel)
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("> EvalSymlinks: %w", err)
+	}
+	if resolved != path {
+		return "", errOutsideWorkspace
+	}
+	return path, nil
+}
+
+// openWorkspaceFile opens the regular file at the local path rel of the workspace.
+func openWorkspaceFile(rel string) (*os.File, fs.FileInfo, error) {
+	dir, err := filepath.EvalSymlinks(control.WorkspaceDir())
+	if err != nil {
+		return nil, nil, fmt.Errorf("> EvalSymlinks: %v", err)
+	}
+	path, err := workspacePath(dir, rel)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// O_NOFOLLOW refuses a symlink swapped in since the check, O_NONBLOCK keeps a FIFO from blocking.
+	file, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NOFOLLOW|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("> OpenFile: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("> Stat: %v", err)
+	}
+	if !info.Mode().IsRegular() {
+		file.Close()
+		return nil, nil, fmt.Errorf("not a regular file: %v", rel)
+	}
+	return file, info, nil
+}
+
+// getWipeReports returns the reports of the workspace wipes.
+func getWipeReports(c *gin.Context) {
+	reports, err := control.WipeReports()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("> WipeReports %v", err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, reports)
+}
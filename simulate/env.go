@@ -0,0 +1,442 @@
+// Package simulate rehearses the order lifecycle of the node against stand-ins: the fake chain
+// of fakerpc, the fake container runtime and a local IPFS node, with accelerated time.
+// The node runs its own code, only its surroundings are simulated.
+package simulate
+
+import (
+	"SuperNet-Node/chain"
+	"SuperNet-Node/chain/conn"
+	"SuperNet-Node/chain/fakerpc"
+	"SuperNet-Node/chain/super"
+	"SuperNet-Node/chain/super/distri_ai"
+	"SuperNet-Node/config"
+	"SuperNet-Node/control"
+	"SuperNet-Node/docker"
+	"SuperNet-Node/machine_info"
+	"SuperNet-Node/machine_info/machine_uuid"
+	"SuperNet-Node/pattern"
+	"SuperNet-Node/utils"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+// orderHours is the duration of the simulated orders.
+const orderHours = 1
+
+// watchInterval is how often the stand-ins are inspected for transitions.
+const watchInterval = 50 * time.Millisecond
+
+// ipfsFiles are the files of the train orders, by CID. The order points to the CID.json listing the others.
+var ipfsFiles = map[string]string{
+	"QmSimulateCidJson": `[{"name":"train.py","cid":"QmSimulateTrain"},{"name":"dataset.csv","cid":"QmSimulateDataset"}]`,
+	"QmSimulateTrain":   "print('training')\n",
+	"QmSimulateDataset": "x,y\n1,2\n",
+}
+
+// env is the world of one scenario: the stand-ins, the node serving orders and the buyer.
+type env struct {
+	opts  Options
+	start time.Time
+
+	cluster *fakerpc.Server
+	rpc     *httptest.Server
+	ipfs    *httptest.Server
+	runtime *docker.FakeRuntime
+
+	node  *super.WrapperSuper
+	buyer *super.WrapperBuyer
+	// stopNode cancels the node and waits until it returns, nil while it is not running.
+	stopNode func()
+
+	mu     sync.Mutex
+	events []Event
+	// order is the account of the order placed by the buyer.
+	order solana.PublicKey
+	// journal is the last state journaled by the node, "" when it has no order.
+	journal control.OrderState
+}
+
+// current receives the journal of the node, which is observed for the whole process.
+var (
+	currentMu sync.Mutex
+	current   *env
+)
+
+func init() {
+	control.ObserveOrderJournal(func(journal control.OrderJournal) {
+		currentMu.Lock()
+		e := current
+		currentMu.Unlock()
+		if e != nil {
+			e.onJournal(journal)
+		}
+	})
+}
+
+// newEnv starts the stand-ins and builds the node and the buyer on them. dir is emptied.
+func newEnv(opts Options, dir string) (*env, error) {
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("> RemoveAll: %v", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("> MkdirAll: %v", err)
+	}
+
+	e := &env{
+		opts:    opts,
+		start:   time.Now(),
+		runtime: docker.NewFakeRuntime(),
+	}
+
+	// Orders start in the past on the fake chain, so that they end after orderHours simulated hours.
+	programID := solana.MustPublicKeyFromBase58(pattern.PROGRAM_SUPER_ID)
+	e.cluster = fakerpc.NewServer(programID)
+	skew := orderHours*time.Hour - orderHours*opts.Hour
+	e.cluster.SetClock(func() time.Time { return time.Now().Add(-skew) })
+	e.rpc = httptest.NewServer(e.cluster)
+	e.ipfs = httptest.NewServer(http.HandlerFunc(e.serveIPFS))
+
+	owner, err := solana.NewRandomPrivateKey()
+	if err != nil {
+		e.close()
+		return nil, fmt.Errorf("> NewRandomPrivateKey: %v", err)
+	}
+	buyer, err := solana.NewRandomPrivateKey()
+	if err != nil {
+		e.close()
+		return nil, fmt.Errorf("> NewRandomPrivateKey: %v", err)
+	}
+
+	// The node reads its settings from the global configuration, pointed at the stand-ins.
+	config.GlobalConfig.Base.Rpc = e.rpc.URL
+	config.GlobalConfig.Base.Ws = ""
+	config.GlobalConfig.Base.Rpcs = nil
+	config.GlobalConfig.Base.PrivateKey = owner.String()
+	config.GlobalConfig.FeePayer = config.FeePayerConfig{}
+	config.GlobalConfig.Offline = config.OfflineConfig{}
+	config.GlobalConfig.Console.IpfsNodeUrl = e.ipfs.URL
+	config.GlobalConfig.Console.WorkDirectory = dir
+	config.GlobalConfig.Order.Webhook = ""
+	config.GlobalConfig.Order.GracePeriod = "0"
+	// The restart scenario expects the order to be left running when the node stops.
+	config.GlobalConfig.Order.CompleteOnExit = false
+	config.GlobalConfig.Order.Warnings = nil
+	for _, warning := range opts.Warnings {
+		config.GlobalConfig.Order.Warnings = append(config.GlobalConfig.Order.Warnings, e.scale(warning).String())
+	}
+	control.SetRuntime(e.runtime)
+
+	var uuid [16]byte
+	rand.Read(uuid[:])
+	info, err := chain.GetChainInfo(control.NewSolanaConfig(), machine_uuid.MachineUUID(hex.EncodeToString(uuid[:])))
+	if err != nil {
+		e.close()
+		return nil, fmt.Errorf("> GetChainInfo: %v", err)
+	}
+	e.node = super.NewSuperWrapper(info)
+	e.buyer = super.NewBuyerWrapper(info.Conn, buyer, nil)
+	return e, nil
+}
+
+// close stops the node and the stand-ins.
+func (e *env) close() {
+	if e.stopNode != nil {
+		e.stopNode()
+	}
+	if e.node != nil {
+		e.node.Conn.Close()
+	}
+	if e.rpc != nil {
+		e.rpc.Close()
+	}
+	if e.ipfs != nil {
+		e.ipfs.Close()
+	}
+}
+
+// scale converts a duration of the orders to real time.
+func (e *env) scale(d time.Duration) time.Duration {
+	return time.Duration(float64(d) * float64(e.opts.Hour) / float64(time.Hour))
+}
+
+// record adds an event to the report of the scenario.
+func (e *env) record(source string, format string, a ...interface{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = append(e.events, Event{
+		At:     time.Since(e.start),
+		Source: source,
+		Text:   fmt.Sprintf(format, a...),
+	})
+}
+
+func (e *env) onJournal(journal control.OrderJournal) {
+	e.mu.Lock()
+	changed := e.journal != journal.State
+	e.journal = journal.State
+	e.mu.Unlock()
+	switch {
+	case !changed:
+	case journal.State == "":
+		e.record("node", "order journal cleared")
+	case journal.PendingTx != "":
+		e.record("node", "order %v, sending %v", journal.State, journal.PendingTx)
+	default:
+		e.record("node", "order %v", journal.State)
+	}
+}
+
+func (e *env) serveIPFS(w http.ResponseWriter, r *http.Request) {
+	cid := strings.TrimPrefix(r.URL.Path, "/ipfs/")
+	content, ok := ipfsFiles[cid]
+	if !ok {
+		e.record("ipfs", "GET %v: not found", cid)
+		http.NotFound(w, r)
+		return
+	}
+	e.record("ipfs", "GET %v", cid)
+	w.Write([]byte(content))
+}
+
+// register adds the machine on chain and lists it for rent, as an operator does before `node start`.
+func (e *env) register() error {
+	hwInfo := machine_info.MachineInfo{MachineUUID: e.node.MachineUUID}
+	hwInfo.CPUInfo.ModelName = "Simulated CPU"
+	hwInfo.LocationInfo.Country = "Simulation"
+	hwInfo.MemoryInfo.RAM = 64
+	hwInfo.Score = 80
+	if _, err := e.node.AddMachine(context.Background(), hwInfo); err != nil {
+		return fmt.Errorf("> AddMachine: %v", err)
+	}
+	if _, err := e.node.MakeOffer(context.Background(), utils.SntToUnits(1), 24, 100); err != nil {
+		return fmt.Errorf("> MakeOffer: %v", err)
+	}
+	return nil
+}
+
+// startNode runs the order machine of the node, resuming its journal as `node start` does.
+func (e *env) startNode() {
+	ctx, cancel := context.WithCancel(context.Background())
+	orders := control.NewOrderMachine(e.node, false)
+	if err := orders.Resume(ctx); err != nil {
+		e.record("node", "resume failed: %v", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		orders.Serve(ctx)
+	}()
+	e.stopNode = func() {
+		cancel()
+		<-done
+		e.stopNode = nil
+	}
+	e.record("driver", "node started")
+}
+
+// restartNode stops the node and starts it again, as a crash or a reboot would.
+func (e *env) restartNode() {
+	if e.stopNode != nil {
+		e.stopNode()
+	}
+	e.record("driver", "node stopped")
+	e.startNode()
+}
+
+// placeOrder places a train order of orderHours hours on the machine, signed by the buyer.
+func (e *env) placeOrder() (solana.PublicKey, error) {
+	order, _, err := control.PlaceOrder(context.Background(), e.buyer, e.node.ProgramSuperMachine, orderHours, "train", "simulation",
+		[]string{"QmSimulateCidJson"})
+	if err != nil {
+		return order, fmt.Errorf("> PlaceOrder: %v", err)
+	}
+	e.mu.Lock()
+	e.order = order
+	e.mu.Unlock()
+	e.record("driver", "buyer placed order %v", order)
+	return order, nil
+}
+
+// refundOrder refunds the order, signed by the buyer.
+func (e *env) refundOrder(account solana.PublicKey) error {
+	order, ok := e.getOrder(account)
+	if !ok {
+		return fmt.Errorf("order %v does not exist", account)
+	}
+	if _, err := e.buyer.RefundOrder(context.Background(), account, order); err != nil {
+		return fmt.Errorf("> RefundOrder: %v", err)
+	}
+	e.record("driver", "buyer refunded order %v", account)
+	return nil
+}
+
+// getMachine reads the machine from the fake chain, bypassing the RPC.
+func (e *env) getMachine() (distri_ai.Machine, bool) {
+	var machine distri_ai.Machine
+	data, ok := e.cluster.Account(e.node.ProgramSuperMachine)
+	if !ok || machine.UnmarshalWithDecoder(bin.NewBorshDecoder(data)) != nil {
+		return machine, false
+	}
+	return machine, true
+}
+
+// getOrder reads an order from the fake chain, bypassing the RPC.
+func (e *env) getOrder(account solana.PublicKey) (distri_ai.Order, bool) {
+	var order distri_ai.Order
+	data, ok := e.cluster.Account(account)
+	if !ok || order.UnmarshalWithDecoder(bin.NewBorshDecoder(data)) != nil {
+		return order, false
+	}
+	return order, true
+}
+
+// endTime returns the real time at which the order ends, as the node computes it.
+func (e *env) endTime(order distri_ai.Order) time.Time {
+	return time.Unix(order.StartTime, 0).Add(time.Hour * time.Duration(order.Duration))
+}
+
+// watch records the transitions of the machine, the order and the containers until ctx is cancelled.
+func (e *env) watch(ctx context.Context) {
+	var machineStatus, orderStatus string
+	var containers []string
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		if machine, ok := e.getMachine(); ok && machine.Status.String() != machineStatus {
+			e.record("chain", "machine %v -> %v", orEmpty(machineStatus), machine.Status)
+			machineStatus = machine.Status.String()
+		}
+
+		e.mu.Lock()
+		account := e.order
+		e.mu.Unlock()
+		if order, ok := e.getOrder(account); ok && order.Status.String() != orderStatus {
+			e.record("chain", "order %v -> %v", orEmpty(orderStatus), order.Status)
+			orderStatus = order.Status.String()
+		}
+
+		var names []string
+		for _, c := range e.runtime.Containers() {
+			names = append(names, fmt.Sprintf("%v (%v)", c.Spec.Name, c.Spec.Image))
+		}
+		for _, name := range names {
+			if !slices.Contains(containers, name) {
+				e.record("runtime", "container %v started", name)
+			}
+		}
+		for _, name := range containers {
+			if !slices.Contains(names, name) {
+				e.record("runtime", "container %v removed", name)
+			}
+		}
+		containers = names
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitFor polls cond until it holds, failing after timeout.
+func (e *env) waitFor(what string, timeout time.Duration, cond func() bool) error {
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %v waiting for %v", timeout, what)
+		}
+		time.Sleep(watchInterval)
+	}
+	return nil
+}
+
+// waitOrder waits until the order has status.
+func (e *env) waitOrder(account solana.PublicKey, status distri_ai.OrderStatus, timeout time.Duration) error {
+	return e.waitFor(fmt.Sprintf("order %v", status), timeout, func() bool {
+		order, ok := e.getOrder(account)
+		return ok && order.Status == status
+	})
+}
+
+// waitSettled waits until the node dropped the order and the machine is for rent again.
+func (e *env) waitSettled(timeout time.Duration) error {
+	return e.waitFor("the node to release the order", timeout, func() bool {
+		e.mu.Lock()
+		journal := e.journal
+		e.mu.Unlock()
+		machine, ok := e.getMachine()
+		return journal == "" && len(e.runtime.Containers()) == 0 &&
+			ok && machine.Status == distri_ai.MachineStatusForRent
+	})
+}
+
+// journalState returns the last state journaled by the node, "" when it has no order.
+func (e *env) journalState() control.OrderState {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.journal
+}
+
+// containerRunning reports whether the workspace container is running.
+func (e *env) containerRunning() bool {
+	for _, c := range e.runtime.Containers() {
+		if c.Spec.Name == pattern.ML_WORKSPACE_CONTAINER && c.Running {
+			return true
+		}
+	}
+	return false
+}
+
+// workspaceFiles returns the names of the files in the workspace of the node.
+func (e *env) workspaceFiles() []string {
+	var names []string
+	entries, _ := os.ReadDir(control.WorkspaceDir())
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+// transactions returns the transactions landed on the fake chain.
+func (e *env) transactions() []Transaction {
+	var out []Transaction
+	for _, tx := range e.cluster.Transactions() {
+		instructions, _ := super.ParseLogs(e.node.ProgramSuperID, tx.Logs)
+		t := Transaction{
+			Slot:         tx.Slot,
+			Signature:    tx.Signature.String(),
+			Instructions: instructions,
+		}
+		if tx.Err != nil {
+			if programErr := conn.DecodeProgramError(tx.Err, tx.Logs); programErr != nil {
+				t.Err = programErr.String()
+			} else {
+				data, _ := json.Marshal(tx.Err)
+				t.Err = string(data)
+			}
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+func orEmpty(status string) string {
+	if status == "" {
+		return "(none)"
+	}
+	return status
+}
@@ -0,0 +1,308 @@
+package simulate
+
+import (
+	"SuperNet-Node/chain/conn"
+	"SuperNet-Node/chain/super/distri_ai"
+	"SuperNet-Node/control"
+	"SuperNet-Node/pattern"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// Options configures a simulation.
+type Options struct {
+	// Hour is the real time standing for an hour of the orders.
+	Hour time.Duration
+	// Warnings are the order warnings of the node, in order time, e.g. 30m.
+	Warnings []time.Duration
+	// Outage is how long the RPC stays down in the rpc-outage scenario, in real time.
+	Outage time.Duration
+	// Dir holds the workspaces of the scenarios.
+	Dir string
+	// Out receives the progress of the simulation.
+	Out io.Writer
+}
+
+// Event is a transition observed during a scenario.
+type Event struct {
+	// At is the time since the start of the scenario.
+	At time.Duration
+	// Source is chain, node, runtime, ipfs or driver, the latter being the simulation itself.
+	Source string
+	Text   string
+}
+
+// Transaction is a transaction landed on the fake chain during a scenario.
+type Transaction struct {
+	Slot         uint64
+	Signature    string
+	Instructions []string
+	// Err is empty when the transaction succeeded.
+	Err string
+}
+
+// Report is the outcome of a scenario.
+type Report struct {
+	Scenario     string
+	Passed       bool
+	Err          string
+	Duration     time.Duration
+	Events       []Event
+	Transactions []Transaction
+}
+
+func (r Report) String() string {
+	var sb strings.Builder
+	result := "PASS"
+	if !r.Passed {
+		result = "FAIL"
+	}
+	sb.WriteString(fmt.Sprintf("%v %v (%v)\n", result, r.Scenario, r.Duration.Round(time.Millisecond)))
+	if r.Err != "" {
+		sb.WriteString(fmt.Sprintf("  error: %v\n", r.Err))
+	}
+	sb.WriteString("  transitions:\n")
+	for _, event := range r.Events {
+		sb.WriteString(fmt.Sprintf("    %8.2fs  %-7v  %v\n", event.At.Seconds(), event.Source, event.Text))
+	}
+	sb.WriteString("  transactions:\n")
+	for _, tx := range r.Transactions {
+		status := "ok"
+		if tx.Err != "" {
+			status = "failed: " + tx.Err
+		}
+		sb.WriteString(fmt.Sprintf("    slot %-4v %-40v %v\n", tx.Slot, strings.Join(tx.Instructions, ", "), status))
+	}
+	return sb.String()
+}
+
+// Scenario is a scripted run of the node.
+type Scenario struct {
+	Name        string
+	Description string
+	run         func(e *env) error
+}
+
+// Scenarios are the scenarios run by default, in order.
+var Scenarios = []Scenario{
+	{
+		Name:        "happy-path",
+		Description: "a train order is provisioned, served until its end, scored and completed",
+		run:         happyPath,
+	},
+	{
+		Name:        "refund",
+		Description: "the buyer refunds the order while it is served",
+		run:         refund,
+	},
+	{
+		Name:        "container-failure",
+		Description: "the workspace container fails to start and the order is failed",
+		run:         containerFailure,
+	},
+	{
+		Name:        "restart",
+		Description: "the node is stopped while it serves the order, leaves its container running and resumes it on start",
+		run:         restart,
+	},
+	{
+		Name:        "rpc-outage",
+		Description: "the RPC is down when the order ends, the node completes it once it is back",
+		run:         rpcOutage,
+	},
+}
+
+// ErrUnknownScenario is returned by Run for a name that is not in Scenarios.
+var ErrUnknownScenario = errors.New("unknown scenario")
+
+// Run runs the named scenarios, every scenario when names is empty, and reports them.
+func Run(names []string, opts Options) ([]Report, error) {
+	scenarios := Scenarios
+	if len(names) > 0 {
+		scenarios = nil
+		for _, name := range names {
+			i := slices.IndexFunc(Scenarios, func(s Scenario) bool { return s.Name == name })
+			if i < 0 {
+				return nil, fmt.Errorf("%w: %v", ErrUnknownScenario, name)
+			}
+			scenarios = append(scenarios, Scenarios[i])
+		}
+	}
+
+	// Node transactions are confirmed at once on the fake chain, and there is no WebSocket to push updates.
+	conn.ConfirmPollInterval = watchInterval
+	conn.PollInterval = 4 * watchInterval
+	control.RetryInterval = 4 * watchInterval
+
+	var reports []Report
+	for _, scenario := range scenarios {
+		fmt.Fprintf(opts.Out, "=== %v: %v\n", scenario.Name, scenario.Description)
+		report := runScenario(scenario, opts)
+		fmt.Fprint(opts.Out, report.String())
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+func runScenario(scenario Scenario, opts Options) Report {
+	report := Report{Scenario: scenario.Name}
+	e, err := newEnv(opts, filepath.Join(opts.Dir, scenario.Name))
+	if err != nil {
+		report.Err = err.Error()
+		return report
+	}
+	currentMu.Lock()
+	current = e
+	currentMu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	watched := make(chan struct{})
+	go func() {
+		defer close(watched)
+		e.watch(ctx)
+	}()
+
+	err = scenario.run(e)
+	e.close()
+	cancel()
+	<-watched
+
+	currentMu.Lock()
+	current = nil
+	currentMu.Unlock()
+
+	report.Passed = err == nil
+	if err != nil {
+		report.Err = err.Error()
+	}
+	report.Duration = time.Since(e.start)
+	report.Events = e.events
+	report.Transactions = e.transactions()
+	return report
+}
+
+// timeout bounds every wait beyond the order time, covering the transactions and their retries.
+const timeout = 30 * time.Second
+
+// serve registers the machine, starts the node and places an order.
+func serve(e *env) (solana.PublicKey, error) {
+	if err := e.register(); err != nil {
+		return solana.PublicKey{}, err
+	}
+	e.startNode()
+	return e.placeOrder()
+}
+
+func happyPath(e *env) error {
+	order, err := serve(e)
+	if err != nil {
+		return err
+	}
+	if err := e.waitOrder(order, distri_ai.OrderStatusTraining, timeout); err != nil {
+		return err
+	}
+	files := e.workspaceFiles()
+	for _, name := range []string{"train.py", "dataset.csv"} {
+		if !slices.Contains(files, name) {
+			return fmt.Errorf("%v was not downloaded to the workspace, found %v", name, files)
+		}
+	}
+	e.record("driver", "workspace holds %v", strings.Join(files, ", "))
+
+	if err := e.waitOrder(order, distri_ai.OrderStatusCompleted, e.opts.Hour+timeout); err != nil {
+		return err
+	}
+	return e.waitSettled(timeout)
+}
+
+func refund(e *env) error {
+	order, err := serve(e)
+	if err != nil {
+		return err
+	}
+	if err := e.waitOrder(order, distri_ai.OrderStatusTraining, timeout); err != nil {
+		return err
+	}
+	time.Sleep(e.scale(15 * time.Minute))
+	if err := e.refundOrder(order); err != nil {
+		return err
+	}
+	if err := e.waitOrder(order, distri_ai.OrderStatusRefunded, timeout); err != nil {
+		return err
+	}
+	return e.waitSettled(timeout)
+}
+
+func containerFailure(e *env) error {
+	e.runtime.FailRun(pattern.ML_WORKSPACE_NAME, errors.New("simulated start failure"))
+	order, err := serve(e)
+	if err != nil {
+		return err
+	}
+	if err := e.waitOrder(order, distri_ai.OrderStatusFailed, timeout); err != nil {
+		return err
+	}
+	return e.waitSettled(timeout)
+}
+
+func restart(e *env) error {
+	order, err := serve(e)
+	if err != nil {
+		return err
+	}
+	if err := e.waitOrder(order, distri_ai.OrderStatusTraining, timeout); err != nil {
+		return err
+	}
+	time.Sleep(e.scale(15 * time.Minute))
+	e.stopNode()
+	e.record("driver", "node stopped")
+	if !e.containerRunning() {
+		return errors.New("the workspace container was stopped along with the node")
+	}
+	if e.journalState() == "" {
+		return errors.New("the order journal was cleared along with the node")
+	}
+
+	e.startNode()
+	if err := e.waitOrder(order, distri_ai.OrderStatusCompleted, e.opts.Hour+timeout); err != nil {
+		return err
+	}
+	return e.waitSettled(timeout)
+}
+
+func rpcOutage(e *env) error {
+	order, err := serve(e)
+	if err != nil {
+		return err
+	}
+	if err := e.waitOrder(order, distri_ai.OrderStatusTraining, timeout); err != nil {
+		return err
+	}
+
+	// The RPC goes down shortly before the end, so that the completion runs into it.
+	placed, _ := e.getOrder(order)
+	time.Sleep(time.Until(e.endTime(placed).Add(-e.scale(5 * time.Minute))))
+	e.cluster.SetDown(true)
+	e.record("driver", "RPC down for %v", e.opts.Outage)
+	time.Sleep(e.opts.Outage)
+	e.cluster.SetDown(false)
+	e.record("driver", "RPC back up")
+
+	if err := e.waitOrder(order, distri_ai.OrderStatusCompleted, timeout); err != nil {
+		// The node keeps the journal of a completion it gave up on and retries it when it starts.
+		e.record("driver", "order not completed: %v", err)
+		e.restartNode()
+		if err := e.waitOrder(order, distri_ai.OrderStatusCompleted, timeout); err != nil {
+			return err
+		}
+	}
+	return e.waitSettled(timeout)
+}
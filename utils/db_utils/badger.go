@@ -26,22 +26,42 @@ import (
 // }
 
 var (
-	db     *badger.DB
-	once   sync.Once
-	dbOpen = false
+	db      *badger.DB
+	once    sync.Once
+	dbOpen  = false
+	openErr error
+	// path is the directory of the database, see SetPath.
+	path = "/tmp/badger"
 )
 
+// SetPath sets the directory of the database; it has no effect once the database is open.
+func SetPath(dir string) {
+	path = dir
+}
+
 func GetDB() *badger.DB {
+	db, err := OpenDB()
+	if err != nil {
+		panic(err)
+	}
+	return db
+}
+
+// OpenDB is GetDB returning the error instead of panicking, e.g. when the node
+// running in another process holds the database.
+func OpenDB() (*badger.DB, error) {
 	once.Do(func() {
-		opts := badger.DefaultOptions("/tmp/badger").WithLoggingLevel(badger.WARNING)
-		var err error
-		db, err = badger.Open(opts)
-		if err != nil {
-			panic(err)
+		db, openErr = Open(path)
+		if openErr == nil {
+			dbOpen = true
 		}
-		dbOpen = true
 	})
-	return db
+	return db, openErr
+}
+
+// Open opens a database of its own at dir, such as the audit log.
+func Open(dir string) (*badger.DB, error) {
+	return badger.Open(badger.DefaultOptions(dir).WithLoggingLevel(badger.WARNING))
 }
 
 func CloseDB() {
@@ -90,3 +110,30 @@ func GenToken(buyer string) (string, error) {
 	Update(db, []byte("token"), []byte(mlToken))
 	return mlToken, nil
 }
+
+// Scan calls fn with the keys under prefix in ascending order, starting at start,
+// until fn returns false or an error.
+func Scan(db *badger.DB, prefix, start []byte, fn func(key, value []byte) (bool, error)) error {
+	return db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		if len(start) == 0 {
+			start = prefix
+		}
+		for it.Seek(start); it.ValidForPrefix(prefix); it.Next() {
+			item := it.Item()
+			value, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			next, err := fn(item.KeyCopy(nil), value)
+			if err != nil || !next {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -19,9 +19,14 @@ const (
 var Logger *zap.Logger
 
 func init() {
+	SetDirectory("./logs")
+}
+
+// SetDirectory writes the log file in dir from now on, e.g. to keep tests out of the source tree.
+func SetDirectory(dir string) {
 	t := time.Now()
 	formattedTime := t.Format("2006-01-02_15:04:05")
-	logFileName := fmt.Sprintf("./logs/log_%s.txt", formattedTime)
+	logFileName := fmt.Sprintf("%s/log_%s.txt", dir, formattedTime)
 	lumberjackLogger := &lumberjack.Logger{
 		Filename:   logFileName,
 		MaxSize:    100,
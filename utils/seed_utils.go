@@ -47,20 +47,70 @@ func GenTask(machineOwner solana.PublicKey, TaskUUID pattern.TaskUUID) [][]byte
 	return seedTask
 }
 
+func GenOrder(buyer solana.PublicKey, orderID [16]byte) [][]byte {
+
+	seedOrder := [][]byte{
+		[]byte("order"),
+		buyer.Bytes(),
+		orderID[:],
+	}
+	return seedOrder
+}
+
+// GenMachineNew returns the seeds of the account a machine is copied to by the first step of its migration.
+func GenMachineNew(machineOwner solana.PublicKey, machineUUID pattern.MachineUUID) [][]byte {
+
+	seedMachine := [][]byte{
+		[]byte("machine-new"),
+		machineOwner.Bytes(),
+		machineUUID[:],
+	}
+	return seedMachine
+}
+
+// GenOrderNew returns the seeds of the account an order is copied to by the first step of its migration.
+func GenOrderNew(buyer solana.PublicKey, orderID [16]byte) [][]byte {
+
+	seedOrder := [][]byte{
+		[]byte("order-new"),
+		buyer.Bytes(),
+		orderID[:],
+	}
+	return seedOrder
+}
+
 func GenReward() [][]byte {
+	return GenRewardByPeriod(CurrentPeriod())
+}
+
+func GenRewardByPeriod(period uint32) [][]byte {
 	seedReward := [][]byte{
 		[]byte("reward"),
-		PeriodBytes(),
+		PeriodToBytes(period),
 	}
 	return seedReward
 }
 
 func GenRewardMachine(machineOwner solana.PublicKey, machineUUID pattern.MachineUUID) [][]byte {
+	return GenRewardMachineByPeriod(CurrentPeriod(), machineOwner, machineUUID)
+}
+
+func GenRewardMachineByPeriod(period uint32, machineOwner solana.PublicKey, machineUUID pattern.MachineUUID) [][]byte {
 	seedRewardMachine := [][]byte{
 		[]byte("reward-machine"),
-		PeriodBytes(),
+		PeriodToBytes(period),
 		machineOwner.Bytes(),
 		[]byte(machineUUID[:]),
 	}
 	return seedRewardMachine
 }
+
+func GenRewardPool() [][]byte {
+
+	ecpc := solana.MustPublicKeyFromBase58(pattern.SNT_TOKEN_ID)
+	seedRewardPool := [][]byte{
+		[]byte("reward-pool"),
+		ecpc.Bytes(),
+	}
+	return seedRewardPool
+}
@@ -16,6 +16,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"mime/multipart"
 	"net"
 	"net/http"
@@ -271,12 +272,39 @@ func CurrentPeriod() uint32 {
 	return uint32((time.Now().Unix() - genesisTime) / periodDuration)
 }
 
+// SntToUnits converts an SNT amount to the base units of the token mint.
+func SntToUnits(snt float64) uint64 {
+	return uint64(math.Round(snt * math.Pow10(pattern.SNT_DECIMALS)))
+}
+
+// UnitsToSnt converts base units of the token mint to an SNT amount.
+func UnitsToSnt(units uint64) float64 {
+	return float64(units) / math.Pow10(pattern.SNT_DECIMALS)
+}
+
 func PeriodBytes() []byte {
+	return PeriodToBytes(CurrentPeriod())
+}
+
+func PeriodToBytes(period uint32) []byte {
 	bytes := make([]byte, 4)
-	binary.LittleEndian.PutUint32(bytes, CurrentPeriod())
+	binary.LittleEndian.PutUint32(bytes, period)
 	return bytes
 }
 
+// PeriodAt returns the reward period containing t.
+func PeriodAt(t time.Time) uint32 {
+	if t.Unix() < genesisTime {
+		return 0
+	}
+	return uint32((t.Unix() - genesisTime) / periodDuration)
+}
+
+// PeriodStartTime returns the time at which a reward period begins.
+func PeriodStartTime(period uint32) time.Time {
+	return time.Unix(genesisTime+int64(period)*periodDuration, 0)
+}
+
 func GetFilenameFromURL(rawURL string) (string, error) {
 	parsedURL, err := url.Parse(rawURL)
 	if err != nil {
@@ -378,9 +406,10 @@ type UploadCidItem struct {
 }
 
 func UploadFileToIPFS(ipfsNodeUrl, filePath string, timeout time.Duration) (string, error) {
-	file, err := os.Open(filePath)
+	// A symlink swapped in for the file is refused rather than followed.
+	file, err := os.OpenFile(filePath, os.O_RDONLY|syscall.O_NOFOLLOW, 0)
 	if err != nil {
-		return "", fmt.Errorf("> os.Open: %v", err)
+		return "", fmt.Errorf("> os.OpenFile: %v", err)
 	}
 	defer file.Close()
 